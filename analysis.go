@@ -0,0 +1,391 @@
+package mcnbt
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// neighborOffsets are the six face-adjacent directions used for connectivity checks
+var neighborOffsets = [6][3]int{
+	{1, 0, 0}, {-1, 0, 0},
+	{0, 1, 0}, {0, -1, 0},
+	{0, 0, 1}, {0, 0, -1},
+}
+
+// isAirPalette reports whether a palette entry is one of the air blocks
+func isAirPalette(p StandardPalette) bool {
+	switch p.Name {
+	case "minecraft:air", "minecraft:cave_air", "minecraft:void_air":
+		return true
+	}
+	return false
+}
+
+// airState returns the lowest palette index holding air. Palettes are not
+// guaranteed to put air at index 0, so converters use this to fill cells that
+// have no block.
+func (sf *StandardFormat) airState() (int, bool) {
+	state, found := 0, false
+	for i, p := range sf.Palette {
+		if isAirPalette(p) && (!found || i < state) {
+			state, found = i, true
+		}
+	}
+	return state, found
+}
+
+// isSolid reports whether a block occupies space, i.e. it is not air
+func (sf *StandardFormat) isSolid(block StandardBlock) bool {
+	if p, ok := sf.Palette[block.State]; ok && isAirPalette(p) {
+		return false
+	}
+	return true
+}
+
+// solidPositions returns the set of positions occupied by solid blocks
+func (sf *StandardFormat) solidPositions() map[[3]int]bool {
+	positions := make(map[[3]int]bool)
+	for _, block := range sf.Blocks {
+		if !sf.isSolid(block) {
+			continue
+		}
+		key := [3]int{int(block.Position.X), int(block.Position.Y), int(block.Position.Z)}
+		positions[key] = true
+	}
+	return positions
+}
+
+// AdjacencyGraph maps each solid block position to the positions of its
+// face-adjacent solid neighbors. Blocks with no solid neighbors map to an
+// empty slice.
+func (sf *StandardFormat) AdjacencyGraph() map[StandardBlockPosition][]StandardBlockPosition {
+	solid := sf.solidPositions()
+	graph := make(map[StandardBlockPosition][]StandardBlockPosition, len(solid))
+	for pos := range solid {
+		neighbors := []StandardBlockPosition{}
+		for _, offset := range neighborOffsets {
+			next := [3]int{pos[0] + offset[0], pos[1] + offset[1], pos[2] + offset[2]}
+			if solid[next] {
+				neighbors = append(neighbors, toBlockPosition(next))
+			}
+		}
+		graph[toBlockPosition(pos)] = neighbors
+	}
+	return graph
+}
+
+// toBlockPosition converts an integer position key to a StandardBlockPosition
+func toBlockPosition(pos [3]int) StandardBlockPosition {
+	return StandardBlockPosition{X: float64(pos[0]), Y: float64(pos[1]), Z: float64(pos[2])}
+}
+
+// ConnectedComponents groups solid blocks into 6-connected components.
+// Components are sorted largest-first, so the first entry is usually the main
+// structure and any remaining entries are floating or disconnected blocks.
+func (sf *StandardFormat) ConnectedComponents() [][]StandardBlockPosition {
+	graph := sf.AdjacencyGraph()
+
+	// Visit positions in a stable order so results are deterministic
+	keys := make([]StandardBlockPosition, 0, len(graph))
+	for key := range graph {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lessPosition(positionKey(keys[i]), positionKey(keys[j]))
+	})
+
+	visited := make(map[StandardBlockPosition]bool, len(graph))
+	var components [][]StandardBlockPosition
+
+	for _, start := range keys {
+		if visited[start] {
+			continue
+		}
+
+		var component []StandardBlockPosition
+		queue := []StandardBlockPosition{start}
+		visited[start] = true
+
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			component = append(component, current)
+
+			for _, next := range graph[current] {
+				if !visited[next] {
+					visited[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		components = append(components, component)
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		return len(components[i]) > len(components[j])
+	})
+
+	return components
+}
+
+// positionKey converts a StandardBlockPosition to an integer position key
+func positionKey(pos StandardBlockPosition) [3]int {
+	return [3]int{int(pos.X), int(pos.Y), int(pos.Z)}
+}
+
+// lessPosition orders positions by Y, then Z, then X
+func lessPosition(a, b [3]int) bool {
+	if a[1] != b[1] {
+		return a[1] < b[1]
+	}
+	if a[2] != b[2] {
+		return a[2] < b[2]
+	}
+	return a[0] < b[0]
+}
+
+// MaterialsByCategory groups solid block names by category, mapping each
+// category to a name→count bill of materials
+func (sf *StandardFormat) MaterialsByCategory() map[string]map[string]int {
+	result := make(map[string]map[string]int)
+	for _, block := range sf.Blocks {
+		if !sf.isSolid(block) {
+			continue
+		}
+		p, ok := sf.Palette[block.State]
+		if !ok {
+			continue
+		}
+		category := BlockCategory(p.Name)
+		if result[category] == nil {
+			result[category] = make(map[string]int)
+		}
+		result[category][p.Name]++
+	}
+	return result
+}
+
+// EntityCountsByCategory counts entities by EntityCategory, e.g. to gauge a
+// schematic's mob load
+func (sf *StandardFormat) EntityCountsByCategory() map[string]int {
+	counts := make(map[string]int)
+	for _, entity := range sf.Entities {
+		counts[EntityCategory(entity.ID)]++
+	}
+	return counts
+}
+
+// Heightmap returns the Y of the highest solid block in each (X, Z) column.
+// Columns without solid blocks are omitted.
+func (sf *StandardFormat) Heightmap() map[[2]int]int {
+	heights := make(map[[2]int]int)
+	for _, block := range sf.Blocks {
+		if !sf.isSolid(block) {
+			continue
+		}
+		column := [2]int{int(block.Position.X), int(block.Position.Z)}
+		y := int(block.Position.Y)
+		if h, ok := heights[column]; !ok || y > h {
+			heights[column] = y
+		}
+	}
+	return heights
+}
+
+// BlockHistogram counts solid blocks by name, skipping air
+func (sf *StandardFormat) BlockHistogram() map[string]int {
+	return sf.BlockHistogramWithProperties(false)
+}
+
+// BlockHistogramWithProperties counts solid blocks by name. When
+// withProperties is true, block states are counted separately using their
+// property string, e.g. "minecraft:oak_log[axis=y]".
+func (sf *StandardFormat) BlockHistogramWithProperties(withProperties bool) map[string]int {
+	histogram := make(map[string]int)
+	for _, block := range sf.Blocks {
+		if !sf.isSolid(block) {
+			continue
+		}
+		p, ok := sf.Palette[block.State]
+		if !ok {
+			continue
+		}
+		name := p.Name
+		if withProperties {
+			name = EncodePropertyString(p.Name, p.Properties)
+		}
+		histogram[name]++
+	}
+	return histogram
+}
+
+// MaterialPercentages returns each block name's share of all solid blocks, as
+// a percentage from 0 to 100
+func (sf *StandardFormat) MaterialPercentages() map[string]float64 {
+	histogram := sf.BlockHistogram()
+	total := 0
+	for _, count := range histogram {
+		total += count
+	}
+
+	percentages := make(map[string]float64, len(histogram))
+	for name, count := range histogram {
+		percentages[name] = float64(count) / float64(total) * 100
+	}
+	return percentages
+}
+
+// BoundingSphere returns a sphere enclosing every solid block, using Ritter's
+// approximation, which is at most a few percent larger than the minimal
+// sphere. The sphere covers whole blocks, so a single block at the origin is
+// centered on (0.5, 0.5, 0.5). With no solid blocks the radius is 0.
+func (sf *StandardFormat) BoundingSphere() (center StandardBlockPosition, radius float64) {
+	var points [][3]float64
+	for _, block := range sf.Blocks {
+		if sf.isSolid(block) {
+			p := block.Position
+			points = append(points, [3]float64{p.X + 0.5, p.Y + 0.5, p.Z + 0.5})
+		}
+	}
+	if len(points) == 0 {
+		return center, 0
+	}
+
+	dist := func(a, b [3]float64) float64 {
+		return math.Sqrt((a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1]) + (a[2]-b[2])*(a[2]-b[2]))
+	}
+	farthest := func(from [3]float64) [3]float64 {
+		best, bestDist := from, -1.0
+		for _, p := range points {
+			if d := dist(from, p); d > bestDist {
+				best, bestDist = p, d
+			}
+		}
+		return best
+	}
+
+	// Start from the sphere spanning two roughly opposite points, then grow it
+	// to take in any point left outside
+	a := farthest(points[0])
+	b := farthest(a)
+	c := [3]float64{(a[0] + b[0]) / 2, (a[1] + b[1]) / 2, (a[2] + b[2]) / 2}
+	radius = dist(a, b) / 2
+	for _, p := range points {
+		d := dist(c, p)
+		if d <= radius {
+			continue
+		}
+		newRadius := (radius + d) / 2
+		shift := (newRadius - radius) / d
+		for i := range c {
+			c[i] += (p[i] - c[i]) * shift
+		}
+		radius = newRadius
+	}
+
+	// Grow by half a block diagonal so the corners of every block are inside
+	radius += math.Sqrt(3) / 2
+	return StandardBlockPosition{X: c[0], Y: c[1], Z: c[2]}, radius
+}
+
+// Anomaly kinds reported by Anomalies
+const (
+	AnomalyIsolatedBlock = "isolated_block"
+	AnomalyWallGap       = "wall_gap"
+	AnomalyUniqueBlock   = "unique_block"
+)
+
+// Anomaly is a suspicious spot in a build found by Anomalies
+type Anomaly struct {
+	// One of the Anomaly* kinds
+	Kind string `json:"kind"`
+
+	// Where the anomaly is
+	Position StandardBlockPosition `json:"position"`
+
+	// Human-readable explanation
+	Description string `json:"description"`
+}
+
+// Anomalies is a heuristic linter that reports likely editing mistakes:
+// solid blocks with no solid neighbors, single-block holes in otherwise
+// solid walls, and palette entries used by exactly one block. Results are
+// ordered by position, then kind.
+func (sf *StandardFormat) Anomalies() []Anomaly {
+	var anomalies []Anomaly
+
+	for pos, neighbors := range sf.AdjacencyGraph() {
+		if len(neighbors) == 0 {
+			anomalies = append(anomalies, Anomaly{
+				Kind:        AnomalyIsolatedBlock,
+				Position:    pos,
+				Description: "solid block with no solid neighbors",
+			})
+		}
+	}
+
+	// A hole is surrounded on both sides along at least two axes, i.e. it is
+	// enclosed within the plane of a wall, floor or ceiling
+	solid := sf.solidPositions()
+	checked := make(map[[3]int]bool)
+	for pos := range solid {
+		for _, offset := range neighborOffsets {
+			gap := [3]int{pos[0] + offset[0], pos[1] + offset[1], pos[2] + offset[2]}
+			if solid[gap] || checked[gap] || !sf.inBounds(gap) {
+				continue
+			}
+			checked[gap] = true
+
+			enclosedAxes := 0
+			for axis := 0; axis < 3; axis++ {
+				before, after := gap, gap
+				before[axis]--
+				after[axis]++
+				if solid[before] && solid[after] {
+					enclosedAxes++
+				}
+			}
+			if enclosedAxes >= 2 {
+				anomalies = append(anomalies, Anomaly{
+					Kind:        AnomalyWallGap,
+					Position:    toBlockPosition(gap),
+					Description: "single-block gap in an otherwise solid surface",
+				})
+			}
+		}
+	}
+
+	uses := make(map[int][]StandardBlockPosition)
+	for _, block := range sf.Blocks {
+		if sf.isSolid(block) {
+			uses[block.State] = append(uses[block.State], block.Position)
+		}
+	}
+	for state, positions := range uses {
+		if len(positions) == 1 {
+			anomalies = append(anomalies, Anomaly{
+				Kind:        AnomalyUniqueBlock,
+				Position:    positions[0],
+				Description: fmt.Sprintf("%s is used by only this block", sf.Palette[state].Name),
+			})
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		a, b := positionKey(anomalies[i].Position), positionKey(anomalies[j].Position)
+		if a != b {
+			return lessPosition(a, b)
+		}
+		return anomalies[i].Kind < anomalies[j].Kind
+	})
+	return anomalies
+}
+
+// inBounds reports whether pos lies within [0, Size)
+func (sf *StandardFormat) inBounds(pos [3]int) bool {
+	return pos[0] >= 0 && pos[0] < sf.Size.X &&
+		pos[1] >= 0 && pos[1] < sf.Size.Y &&
+		pos[2] >= 0 && pos[2] < sf.Size.Z
+}
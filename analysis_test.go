@@ -0,0 +1,315 @@
+package mcnbt
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// newTestStandard builds a small StandardFormat with air at index 0 and stone at index 1
+func newTestStandard(sizeX, sizeY, sizeZ int) *StandardFormat {
+	return &StandardFormat{
+		Size: StandardSize{X: sizeX, Y: sizeY, Z: sizeZ},
+		Palette: map[int]StandardPalette{
+			0: {Name: "minecraft:air", Properties: map[string]string{}},
+			1: {Name: "minecraft:stone", Properties: map[string]string{}},
+		},
+	}
+}
+
+// addTestBlock appends a block with the given state at the given position
+func addTestBlock(sf *StandardFormat, x, y, z, state int) {
+	sf.Blocks = append(sf.Blocks, StandardBlock{
+		Type:     "block",
+		State:    state,
+		Position: StandardBlockPosition{X: float64(x), Y: float64(y), Z: float64(z)},
+	})
+}
+
+// TestConnectedComponents verifies that a detached block forms its own component
+func TestConnectedComponents(t *testing.T) {
+	sf := newTestStandard(6, 2, 2)
+
+	// Main 2x2x2 cube
+	for y := 0; y < 2; y++ {
+		for z := 0; z < 2; z++ {
+			for x := 0; x < 2; x++ {
+				addTestBlock(sf, x, y, z, 1)
+			}
+		}
+	}
+
+	// Air gap followed by a single floating block
+	addTestBlock(sf, 3, 0, 0, 0)
+	addTestBlock(sf, 5, 1, 1, 1)
+
+	components := sf.ConnectedComponents()
+	if len(components) != 2 {
+		t.Fatalf("Expected 2 components, got %d", len(components))
+	}
+
+	if len(components[0]) != 8 {
+		t.Errorf("Expected main component of 8 blocks, got %d", len(components[0]))
+	}
+
+	if len(components[1]) != 1 {
+		t.Fatalf("Expected stray component of 1 block, got %d", len(components[1]))
+	}
+
+	stray := components[1][0]
+	if stray != (StandardBlockPosition{X: 5, Y: 1, Z: 1}) {
+		t.Errorf("Unexpected stray block position: %+v", stray)
+	}
+}
+
+// TestMaterialsByCategory verifies blocks are grouped into their categories
+func TestMaterialsByCategory(t *testing.T) {
+	sf := newTestStandard(3, 1, 1)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:oak_planks"}
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 1, 0, 0, 2)
+	addTestBlock(sf, 2, 0, 0, 2)
+
+	materials := sf.MaterialsByCategory()
+
+	if got := materials["wood"]["minecraft:oak_planks"]; got != 2 {
+		t.Errorf("Expected 2 oak_planks under wood, got %d", got)
+	}
+	if got := materials["stone"]["minecraft:stone"]; got != 1 {
+		t.Errorf("Expected 1 stone under stone, got %d", got)
+	}
+	if _, ok := materials[categoryOther]["minecraft:air"]; ok {
+		t.Errorf("Air should not be counted as a material")
+	}
+}
+
+// TestEntityCountsByCategory verifies mixed entities are counted per category
+func TestEntityCountsByCategory(t *testing.T) {
+	sf := newTestStandard(1, 1, 1)
+	for _, id := range []string{
+		"minecraft:zombie", "minecraft:creeper", "minecraft:cow", "minecraft:villager",
+		"minecraft:sheep", "minecraft:arrow", "minecraft:item", "minecraft:armor_stand",
+	} {
+		sf.Entities = append(sf.Entities, StandardEntity{ID: id})
+	}
+
+	counts := sf.EntityCountsByCategory()
+	expected := map[string]int{"hostile": 2, "passive": 3, "projectile": 1, "item": 1, categoryOther: 1}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected %v, got %v", expected, counts)
+	}
+}
+
+// TestHeightmap verifies per-column heights for a stepped structure
+func TestHeightmap(t *testing.T) {
+	sf := newTestStandard(4, 3, 1)
+
+	// Steps of height 1, 2 and 3 with an empty column at X=3
+	for x := 0; x < 3; x++ {
+		for y := 0; y <= x; y++ {
+			addTestBlock(sf, x, y, 0, 1)
+		}
+	}
+	addTestBlock(sf, 3, 0, 0, 0)
+
+	heights := sf.Heightmap()
+	expected := map[[2]int]int{{0, 0}: 0, {1, 0}: 1, {2, 0}: 2}
+
+	if len(heights) != len(expected) {
+		t.Fatalf("Expected %d columns, got %d: %v", len(expected), len(heights), heights)
+	}
+	for column, h := range expected {
+		if heights[column] != h {
+			t.Errorf("Column %v: expected height %d, got %d", column, h, heights[column])
+		}
+	}
+}
+
+// TestAdjacencyGraph verifies neighbors for an L-shaped cluster and an isolated block
+func TestAdjacencyGraph(t *testing.T) {
+	sf := newTestStandard(4, 2, 1)
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 1, 0, 0, 1)
+	addTestBlock(sf, 1, 1, 0, 1)
+	addTestBlock(sf, 2, 0, 0, 0)
+	addTestBlock(sf, 3, 1, 0, 1)
+
+	graph := sf.AdjacencyGraph()
+	if len(graph) != 4 {
+		t.Fatalf("Expected 4 solid blocks in the graph, got %d", len(graph))
+	}
+
+	expected := map[StandardBlockPosition]int{
+		{X: 0, Y: 0, Z: 0}: 1,
+		{X: 1, Y: 0, Z: 0}: 2,
+		{X: 1, Y: 1, Z: 0}: 1,
+		{X: 3, Y: 1, Z: 0}: 0,
+	}
+	for pos, count := range expected {
+		if got := len(graph[pos]); got != count {
+			t.Errorf("Block %+v: expected %d neighbors, got %d (%v)", pos, count, got, graph[pos])
+		}
+	}
+
+	for _, neighbor := range graph[StandardBlockPosition{X: 1, Y: 1, Z: 0}] {
+		if neighbor != (StandardBlockPosition{X: 1, Y: 0, Z: 0}) {
+			t.Errorf("Unexpected neighbor of the top block: %+v", neighbor)
+		}
+	}
+}
+
+// TestBlockHistogram verifies counts add up to the number of non-air blocks
+func TestBlockHistogram(t *testing.T) {
+	data, err := ParseAnyFromFileAsJSON("testdata/color_field.schem")
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	solid := 0
+	for _, block := range standard.Blocks {
+		if standard.isSolid(block) {
+			solid++
+		}
+	}
+
+	for _, withProperties := range []bool{false, true} {
+		total := 0
+		for name, count := range standard.BlockHistogramWithProperties(withProperties) {
+			if name == "minecraft:air" {
+				t.Errorf("Air should not be counted")
+			}
+			total += count
+		}
+		if total != solid {
+			t.Errorf("withProperties=%v: expected %d blocks, got %d", withProperties, solid, total)
+		}
+	}
+}
+
+// TestBlockHistogramProperties verifies block states are split only when properties are included
+func TestBlockHistogramProperties(t *testing.T) {
+	sf := newTestStandard(2, 1, 1)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:oak_log", Properties: map[string]string{"axis": "y"}}
+	sf.Palette[3] = StandardPalette{Name: "minecraft:oak_log", Properties: map[string]string{"axis": "x"}}
+	addTestBlock(sf, 0, 0, 0, 2)
+	addTestBlock(sf, 1, 0, 0, 3)
+
+	if got := sf.BlockHistogram()["minecraft:oak_log"]; got != 2 {
+		t.Errorf("Expected 2 oak_log, got %d", got)
+	}
+	withProps := sf.BlockHistogramWithProperties(true)
+	if withProps["minecraft:oak_log[axis=y]"] != 1 || withProps["minecraft:oak_log[axis=x]"] != 1 {
+		t.Errorf("Unexpected histogram with properties: %v", withProps)
+	}
+}
+
+// TestMaterialPercentages verifies shares are computed from solid blocks and sum to 100
+func TestMaterialPercentages(t *testing.T) {
+	sf := newTestStandard(5, 1, 1)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:dirt"}
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 1, 0, 0, 1)
+	addTestBlock(sf, 2, 0, 0, 1)
+	addTestBlock(sf, 3, 0, 0, 2)
+	addTestBlock(sf, 4, 0, 0, 0)
+
+	percentages := sf.MaterialPercentages()
+	if got := percentages["minecraft:stone"]; math.Abs(got-75) > 1e-9 {
+		t.Errorf("Expected stone at 75%%, got %v", got)
+	}
+
+	sum := 0.0
+	for _, p := range percentages {
+		sum += p
+	}
+	if math.Abs(sum-100) > 1e-9 {
+		t.Errorf("Expected percentages to sum to 100, got %v", sum)
+	}
+
+	if len(newTestStandard(1, 1, 1).MaterialPercentages()) != 0 {
+		t.Errorf("Expected no percentages for an empty schematic")
+	}
+}
+
+// TestAnomaliesWallHole verifies a single-block hole in a wall is flagged
+func TestAnomaliesWallHole(t *testing.T) {
+	sf := newTestStandard(3, 3, 1)
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			state := 1
+			if x == 1 && y == 1 {
+				state = 0
+			}
+			addTestBlock(sf, x, y, 0, state)
+		}
+	}
+
+	anomalies := sf.Anomalies()
+	if len(anomalies) != 1 {
+		t.Fatalf("Expected 1 anomaly, got %d: %+v", len(anomalies), anomalies)
+	}
+	if anomalies[0].Kind != AnomalyWallGap || anomalies[0].Position != (StandardBlockPosition{X: 1, Y: 1}) {
+		t.Errorf("Expected a wall gap at 1,1,0, got %+v", anomalies[0])
+	}
+}
+
+// TestAnomaliesIsolatedUniqueBlock verifies a lone block of a one-off material is flagged twice
+func TestAnomaliesIsolatedUniqueBlock(t *testing.T) {
+	sf := newTestStandard(4, 1, 1)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:gold_block"}
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 1, 0, 0, 1)
+	addTestBlock(sf, 3, 0, 0, 2)
+
+	kinds := make(map[string]bool)
+	for _, a := range sf.Anomalies() {
+		if a.Position != (StandardBlockPosition{X: 3}) {
+			t.Errorf("Unexpected anomaly: %+v", a)
+		}
+		kinds[a.Kind] = true
+	}
+	if !kinds[AnomalyIsolatedBlock] || !kinds[AnomalyUniqueBlock] {
+		t.Errorf("Expected isolated and unique block anomalies, got %v", kinds)
+	}
+}
+
+// TestBoundingSphere verifies a cube's sphere is centered on it and covers every block corner
+func TestBoundingSphere(t *testing.T) {
+	cube := newTestCube(3, "minecraft:stone")
+	center, radius := cube.BoundingSphere()
+	if center != (StandardBlockPosition{X: 1.5, Y: 1.5, Z: 1.5}) {
+		t.Errorf("Expected center 1.5,1.5,1.5, got %+v", center)
+	}
+	if expected := math.Sqrt(3) * 1.5; math.Abs(radius-expected) > 1e-9 {
+		t.Errorf("Expected radius %v, got %v", expected, radius)
+	}
+
+	// An irregular cluster is only approximated, but every block must be inside
+	sf := newTestStandard(10, 5, 3)
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 9, 1, 2, 1)
+	addTestBlock(sf, 4, 4, 0, 1)
+	addTestBlock(sf, 2, 0, 2, 0)
+	center, radius = sf.BoundingSphere()
+	for _, block := range sf.Blocks {
+		if !sf.isSolid(block) {
+			continue
+		}
+		for _, corner := range [][3]float64{{0, 0, 0}, {1, 1, 1}, {0, 1, 0}, {1, 0, 1}} {
+			dx := block.Position.X + corner[0] - center.X
+			dy := block.Position.Y + corner[1] - center.Y
+			dz := block.Position.Z + corner[2] - center.Z
+			if d := math.Sqrt(dx*dx + dy*dy + dz*dz); d > radius+1e-9 {
+				t.Errorf("Block %+v corner %v is outside the sphere (%v > %v)", block.Position, corner, d, radius)
+			}
+		}
+	}
+
+	if _, r := newTestStandard(1, 1, 1).BoundingSphere(); r != 0 {
+		t.Errorf("Expected radius 0 for an empty schematic, got %v", r)
+	}
+}
@@ -0,0 +1,356 @@
+package mcnbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// NBT tag IDs, shared by the Java and Bedrock encodings
+const (
+	tagEnd byte = iota
+	tagByte
+	tagShort
+	tagInt
+	tagLong
+	tagFloat
+	tagDouble
+	tagByteArray
+	tagString
+	tagList
+	tagCompound
+	tagIntArray
+	tagLongArray
+)
+
+// leNBTReader reads Bedrock's little-endian NBT encoding. go-mc only reads the
+// big-endian Java encoding, so Bedrock files go through this reader instead.
+type leNBTReader struct {
+	r *bytes.Reader
+}
+
+// readRoot reads the root tag, which must be a compound
+func (d *leNBTReader) readRoot() (string, map[string]interface{}, error) {
+	tag, err := d.readByte()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read root tag: %w", err)
+	}
+	if tag != tagCompound {
+		return "", nil, fmt.Errorf("root tag is %d, expected a compound", tag)
+	}
+	name, err := d.readString()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read root name: %w", err)
+	}
+	root, err := d.readCompound()
+	if err != nil {
+		return "", nil, err
+	}
+	return name, root, nil
+}
+
+func (d *leNBTReader) readByte() (byte, error) {
+	var b [1]byte
+	_, err := io.ReadFull(d.r, b[:])
+	return b[0], err
+}
+
+func (d *leNBTReader) readString() (string, error) {
+	var n uint16
+	if err := binary.Read(d.r, binary.LittleEndian, &n); err != nil {
+		return "", err
+	}
+	if int(n) > d.r.Len() {
+		return "", fmt.Errorf("string length %d needs more than the %d bytes left", n, d.r.Len())
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// readLength reads an int32 length prefix for elements of at least elemSize
+// bytes each, rejecting negative values and lengths the remaining data can't
+// hold, so a corrupt length can't trigger a huge allocation
+func (d *leNBTReader) readLength(elemSize int) (int, error) {
+	var n int32
+	if err := binary.Read(d.r, binary.LittleEndian, &n); err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("negative length %d", n)
+	}
+	if int64(n)*int64(elemSize) > int64(d.r.Len()) {
+		return 0, fmt.Errorf("length %d needs more than the %d bytes left", n, d.r.Len())
+	}
+	return int(n), nil
+}
+
+// minPayloadSize is the smallest encoded payload of each tag type, used to
+// bound list lengths. End tags can't be list elements, so they count as one
+// byte to reject any non-empty list of them.
+var minPayloadSize = [...]int{
+	tagEnd:       1,
+	tagByte:      1,
+	tagShort:     2,
+	tagInt:       4,
+	tagLong:      8,
+	tagFloat:     4,
+	tagDouble:    8,
+	tagByteArray: 4,
+	tagString:    2,
+	tagList:      5,
+	tagCompound:  1,
+	tagIntArray:  4,
+	tagLongArray: 4,
+}
+
+func (d *leNBTReader) readCompound() (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	for {
+		tag, err := d.readByte()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tag type: %w", err)
+		}
+		if tag == tagEnd {
+			return m, nil
+		}
+		name, err := d.readString()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tag name: %w", err)
+		}
+		value, err := d.readPayload(tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tag %q: %w", name, err)
+		}
+		m[name] = value
+	}
+}
+
+// readPayload reads a tag payload into the same Go types go-mc produces when
+// decoding into interface{}
+func (d *leNBTReader) readPayload(tag byte) (interface{}, error) {
+	switch tag {
+	case tagByte:
+		b, err := d.readByte()
+		return int8(b), err
+	case tagShort:
+		var v int16
+		err := binary.Read(d.r, binary.LittleEndian, &v)
+		return v, err
+	case tagInt:
+		var v int32
+		err := binary.Read(d.r, binary.LittleEndian, &v)
+		return v, err
+	case tagLong:
+		var v int64
+		err := binary.Read(d.r, binary.LittleEndian, &v)
+		return v, err
+	case tagFloat:
+		var v uint32
+		err := binary.Read(d.r, binary.LittleEndian, &v)
+		return math.Float32frombits(v), err
+	case tagDouble:
+		var v uint64
+		err := binary.Read(d.r, binary.LittleEndian, &v)
+		return math.Float64frombits(v), err
+	case tagByteArray:
+		n, err := d.readLength(1)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, n)
+		_, err = io.ReadFull(d.r, buf)
+		return buf, err
+	case tagString:
+		return d.readString()
+	case tagList:
+		elem, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if int(elem) >= len(minPayloadSize) {
+			return nil, fmt.Errorf("unknown list element type %d", elem)
+		}
+		n, err := d.readLength(minPayloadSize[elem])
+		if err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			v, err := d.readPayload(elem)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read list element %d: %w", i, err)
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case tagCompound:
+		return d.readCompound()
+	case tagIntArray:
+		n, err := d.readLength(4)
+		if err != nil {
+			return nil, err
+		}
+		v := make([]int32, n)
+		err = binary.Read(d.r, binary.LittleEndian, v)
+		return v, err
+	case tagLongArray:
+		n, err := d.readLength(8)
+		if err != nil {
+			return nil, err
+		}
+		v := make([]int64, n)
+		err = binary.Read(d.r, binary.LittleEndian, v)
+		return v, err
+	}
+	return nil, fmt.Errorf("unknown tag type %d", tag)
+}
+
+// DecodeMcStructure decodes a Bedrock .mcstructure file into the standard
+// format. Bedrock stores two block layers; only layer 0 is mapped, so the
+// second layer (usually water in waterlogged blocks) is dropped. Cells
+// holding -1 (structure void) produce no block. Block state values are kept
+// as strings.
+func DecodeMcStructure(data []byte) (*StandardFormat, error) {
+	d := &leNBTReader{r: bytes.NewReader(data)}
+	_, root, err := d.readRoot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode mcstructure: %w", err)
+	}
+
+	size := numberList(root["size"])
+	if len(size) < 3 || size[0] < 0 || size[1] < 0 || size[2] < 0 {
+		return nil, fmt.Errorf("mcstructure has no valid size")
+	}
+	sf := &StandardFormat{
+		OriginalFormat: "mcstructure",
+		Size:           StandardSize{X: int(size[0]), Y: int(size[1]), Z: int(size[2])},
+		Palette:        make(map[int]StandardPalette),
+	}
+
+	structure, _ := root["structure"].(map[string]interface{})
+	if structure == nil {
+		return nil, fmt.Errorf("mcstructure has no structure compound")
+	}
+
+	palettes, _ := structure["palette"].(map[string]interface{})
+	defaultPalette, _ := palettes["default"].(map[string]interface{})
+	blockPalette, _ := defaultPalette["block_palette"].([]interface{})
+	for i, entry := range blockPalette {
+		m, _ := entry.(map[string]interface{})
+		name, _ := m["name"].(string)
+		props := make(map[string]string)
+		if states, ok := m["states"].(map[string]interface{}); ok {
+			for key, value := range states {
+				props[key] = bedrockStateString(value)
+			}
+		}
+		sf.Palette[i] = StandardPalette{Name: name, Properties: props}
+	}
+	positionData, _ := defaultPalette["block_position_data"].(map[string]interface{})
+
+	layers, _ := structure["block_indices"].([]interface{})
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("mcstructure has no block layers")
+	}
+	indices := numberList(layers[0])
+	if volume := sf.Size.X * sf.Size.Y * sf.Size.Z; len(indices) != volume {
+		return nil, fmt.Errorf("mcstructure layer 0 has %d entries, expected %d", len(indices), volume)
+	}
+
+	// Bedrock orders cells with Z varying fastest, then Y, then X
+	for i, v := range indices {
+		state := int(v)
+		if state < 0 {
+			continue
+		}
+		x := i / (sf.Size.Y * sf.Size.Z)
+		y := i / sf.Size.Z % sf.Size.Y
+		z := i % sf.Size.Z
+
+		sb := StandardBlock{
+			Type:     "block",
+			State:    state,
+			Position: StandardBlockPosition{X: float64(x), Y: float64(y), Z: float64(z)},
+		}
+		if p, ok := sf.Palette[state]; ok {
+			sb.ID = p.Name
+		}
+		if entry, ok := positionData[strconv.Itoa(i)].(map[string]interface{}); ok {
+			if te, ok := entry["block_entity_data"].(map[string]interface{}); ok {
+				sf.TileEntities = append(sf.TileEntities, standardTileEntity(sb.Position, te))
+			}
+		}
+		sf.Blocks = append(sf.Blocks, sb)
+	}
+
+	// Entity positions are in world space, relative to the structure's origin
+	origin := numberList(root["structure_world_origin"])
+	if len(origin) < 3 {
+		origin = []float64{0, 0, 0}
+	}
+	sf.Position = StandardPosition{X: int(origin[0]), Y: int(origin[1]), Z: int(origin[2])}
+
+	entities, _ := structure["entities"].([]interface{})
+	for _, e := range entities {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pos := numberList(m["Pos"])
+		if len(pos) < 3 {
+			continue
+		}
+		id, _ := m["identifier"].(string)
+		entity := StandardEntity{
+			ID: id,
+			Position: StandardBlockPosition{
+				X: pos[0] - origin[0],
+				Y: pos[1] - origin[1],
+				Z: pos[2] - origin[2],
+			},
+			NBT: m,
+		}
+		if rotation := numberList(m["Rotation"]); len(rotation) >= 2 {
+			entity.Rotation = StandardRotation{Yaw: rotation[0], Pitch: rotation[1]}
+		}
+		if motion := numberList(m["Motion"]); len(motion) >= 3 {
+			entity.Motion = StandardMotion{X: motion[0], Y: motion[1], Z: motion[2]}
+		}
+		sf.Entities = append(sf.Entities, entity)
+	}
+
+	return sf, nil
+}
+
+// numberList converts a decoded NBT list or int array into float64 values,
+// skipping non-numeric elements
+func numberList(v interface{}) []float64 {
+	var out []float64
+	switch list := v.(type) {
+	case []interface{}:
+		for _, item := range list {
+			if f, ok := toFloat64(item); ok {
+				out = append(out, f)
+			}
+		}
+	case []int32:
+		for _, item := range list {
+			out = append(out, float64(item))
+		}
+	}
+	return out
+}
+
+// bedrockStateString formats a Bedrock block state value as a property string
+func bedrockStateString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
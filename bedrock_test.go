@@ -0,0 +1,70 @@
+package mcnbt
+
+import (
+	"os"
+	"testing"
+)
+
+// TestDecodeMcStructure verifies layer 0 blocks, block entities and entities are mapped from a Bedrock fixture
+func TestDecodeMcStructure(t *testing.T) {
+	data, err := os.ReadFile("testdata/small_chest.mcstructure")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	standard, err := DecodeMcStructure(data)
+	if err != nil {
+		t.Fatalf("Failed to decode mcstructure: %v", err)
+	}
+
+	if standard.Size != (StandardSize{X: 2, Y: 2, Z: 1}) {
+		t.Errorf("Unexpected size %+v", standard.Size)
+	}
+
+	// One cell is structure void and produces no block
+	if len(standard.Blocks) != 3 || len(standard.TileEntities) != 1 || len(standard.Entities) != 1 {
+		t.Fatalf("Expected 3 blocks, 1 tile entity and 1 entity, got %d, %d and %d",
+			len(standard.Blocks), len(standard.TileEntities), len(standard.Entities))
+	}
+
+	chest, ok := standard.GetBlockAt(0, 1, 0)
+	if !ok {
+		t.Fatalf("Expected a block at 0,1,0")
+	}
+	if p := standard.Palette[chest.State]; p.Name != "minecraft:chest" || p.Properties["minecraft:cardinal_direction"] != "north" {
+		t.Errorf("Unexpected chest palette entry %+v", p)
+	}
+	if te := standard.TileEntities[0]; te.ID != "Chest" || te.Position != chest.Position {
+		t.Errorf("Expected a Chest tile entity at %+v, got %s at %+v", chest.Position, te.ID, te.Position)
+	}
+
+	if e := standard.Entities[0]; e.ID != "minecraft:pig" || e.Position != (StandardBlockPosition{X: 0.5, Y: 0, Z: 0.5}) {
+		t.Errorf("Unexpected entity %s at %+v", e.ID, e.Position)
+	}
+}
+
+// TestDecodeMcStructureHugeLengths verifies lengths larger than the data are
+// rejected before anything is allocated for them
+func TestDecodeMcStructureHugeLengths(t *testing.T) {
+	// Root compound with an empty name holding one tag named "a"
+	header := func(tag byte) []byte {
+		return []byte{tagCompound, 0, 0, tag, 1, 0, 'a'}
+	}
+	huge := []byte{0xff, 0xff, 0xff, 0x7f}
+
+	payloads := map[string][]byte{
+		"byte array": append(header(tagByteArray), huge...),
+		"int array":  append(header(tagIntArray), huge...),
+		"long array": append(header(tagLongArray), huge...),
+		"list":       append(append(header(tagList), tagLong), huge...),
+		"end list":   append(append(header(tagList), tagEnd), huge...),
+		"string":     append(header(tagString), 0xff, 0xff),
+	}
+	for name, payload := range payloads {
+		t.Run(name, func(t *testing.T) {
+			if _, err := DecodeMcStructure(payload); err == nil {
+				t.Errorf("Expected an error for a length larger than the data")
+			}
+		})
+	}
+}
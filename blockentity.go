@@ -0,0 +1,106 @@
+package mcnbt
+
+// ContainerMetadata returns the custom name and lock key of a container block
+// entity such as a chest. ok is false when it carries neither.
+// CustomName is returned as stored, which for modern versions is a JSON text component.
+func ContainerMetadata(te StandardTileEntity) (name, lock string, ok bool) {
+	nbtMap, isMap := te.NBT.(map[string]interface{})
+	if !isMap {
+		return "", "", false
+	}
+	name, hasName := nbtMap["CustomName"].(string)
+	lock, hasLock := nbtMap["Lock"].(string)
+	return name, lock, hasName || hasLock
+}
+
+// ItemStack is an item stack read from a container's Items list
+type ItemStack struct {
+	// Item ID (e.g., "minecraft:diamond_sword")
+	ID string `json:"id"`
+
+	// Stack size
+	Count int `json:"count"`
+
+	// Container slot
+	Slot int `json:"slot"`
+
+	// Custom name as stored, usually a JSON text component
+	CustomName string `json:"customName,omitempty"`
+
+	// Enchantment levels by enchantment ID
+	Enchantments map[string]int `json:"enchantments,omitempty"`
+}
+
+// ContainerItems returns the items stored in a container block entity
+func ContainerItems(te StandardTileEntity) []ItemStack {
+	nbtMap, ok := te.NBT.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	list, ok := nbtMap["Items"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	items := make([]ItemStack, 0, len(list))
+	for _, entry := range list {
+		if m, ok := entry.(map[string]interface{}); ok {
+			items = append(items, ParseItem(m))
+		}
+	}
+	return items
+}
+
+// ParseItem reads an item stack in either the legacy layout, where extra data
+// lives under "tag", or the 1.20.5+ layout, where it lives under "components"
+func ParseItem(m map[string]interface{}) ItemStack {
+	item := ItemStack{Enchantments: make(map[string]int)}
+	item.ID, _ = m["id"].(string)
+	item.Count = intField(m, "count", "Count")
+	item.Slot = intField(m, "Slot")
+
+	if tag, ok := m["tag"].(map[string]interface{}); ok {
+		if display, ok := tag["display"].(map[string]interface{}); ok {
+			item.CustomName, _ = display["Name"].(string)
+		}
+		if list, ok := tag["Enchantments"].([]interface{}); ok {
+			for _, entry := range list {
+				if e, ok := entry.(map[string]interface{}); ok {
+					if id, ok := e["id"].(string); ok {
+						item.Enchantments[id] = intField(e, "lvl")
+					}
+				}
+			}
+		}
+	}
+
+	if components, ok := m["components"].(map[string]interface{}); ok {
+		if name, ok := components["minecraft:custom_name"].(string); ok {
+			item.CustomName = name
+		}
+		if enchantments, ok := components["minecraft:enchantments"].(map[string]interface{}); ok {
+			// 1.20.5 nests levels under "levels"; 1.21.5 stores them directly
+			levels, ok := enchantments["levels"].(map[string]interface{})
+			if !ok {
+				levels = enchantments
+			}
+			for id, level := range levels {
+				if lvl, ok := toFloat64(level); ok {
+					item.Enchantments[id] = int(lvl)
+				}
+			}
+		}
+	}
+
+	return item
+}
+
+// intField returns the first of keys present in m as an int
+func intField(m map[string]interface{}, keys ...string) int {
+	for _, key := range keys {
+		if v, ok := toFloat64(m[key]); ok {
+			return int(v)
+		}
+	}
+	return 0
+}
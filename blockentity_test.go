@@ -0,0 +1,134 @@
+package mcnbt
+
+import (
+	"testing"
+)
+
+// TestContainerMetadataSurvivesConversion verifies a named, locked chest keeps both fields in every format
+func TestContainerMetadataSurvivesConversion(t *testing.T) {
+	const customName = `{"text":"Loot"}`
+	const lock = "secret"
+
+	sf := newTestStandard(1, 1, 1)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:chest", Properties: map[string]string{"facing": "north"}}
+	addTestBlock(sf, 0, 0, 0, 2)
+	sf.TileEntities = append(sf.TileEntities, StandardTileEntity{
+		ID: "minecraft:chest",
+		NBT: map[string]interface{}{
+			"id":         "minecraft:chest",
+			"CustomName": customName,
+			"Lock":       lock,
+		},
+	})
+
+	for _, format := range []string{"litematica", "worldedit", "create"} {
+		t.Run(format, func(t *testing.T) {
+			converted, err := ConvertFromStandard(sf, format)
+			if err != nil {
+				t.Fatalf("Failed to convert to %s: %v", format, err)
+			}
+			encoded, err := EncodeToBytes(converted, format)
+			if err != nil {
+				t.Fatalf("Failed to encode %s: %v", format, err)
+			}
+			decoded, err := DecodeAny(encoded)
+			if err != nil {
+				t.Fatalf("Failed to decode %s: %v", format, err)
+			}
+			roundTripped, err := ConvertToStandard(decoded)
+			if err != nil {
+				t.Fatalf("Failed to convert %s back: %v", format, err)
+			}
+
+			found := false
+			for _, te := range roundTripped.TileEntities {
+				name, gotLock, ok := ContainerMetadata(te)
+				if !ok {
+					continue
+				}
+				found = true
+				if name != customName {
+					t.Errorf("Expected CustomName %q, got %q", customName, name)
+				}
+				if gotLock != lock {
+					t.Errorf("Expected Lock %q, got %q", lock, gotLock)
+				}
+			}
+			if !found {
+				t.Errorf("Container metadata was lost")
+			}
+		})
+	}
+}
+
+// TestContainerItemLayouts verifies custom names and enchantments are read from legacy and component items
+func TestContainerItemLayouts(t *testing.T) {
+	legacy := map[string]interface{}{
+		"id":    "minecraft:diamond_sword",
+		"Count": int8(1),
+		"Slot":  int8(3),
+		"tag": map[string]interface{}{
+			"display": map[string]interface{}{"Name": `{"text":"Blade"}`},
+			"Enchantments": []map[string]interface{}{
+				{"id": "minecraft:sharpness", "lvl": int16(5)},
+			},
+		},
+	}
+	components := map[string]interface{}{
+		"id":    "minecraft:diamond_sword",
+		"count": int32(1),
+		"Slot":  int8(3),
+		"components": map[string]interface{}{
+			"minecraft:custom_name": `{"text":"Blade"}`,
+			"minecraft:enchantments": map[string]interface{}{
+				"levels": map[string]int32{"minecraft:sharpness": 5},
+			},
+		},
+	}
+
+	for name, item := range map[string]map[string]interface{}{"legacy": legacy, "components": components} {
+		t.Run(name, func(t *testing.T) {
+			fixture := map[string]interface{}{
+				"DataVersion": int32(3465),
+				"size":        []int32{1, 1, 1},
+				"palette":     []map[string]interface{}{{"Name": "minecraft:chest"}},
+				"blocks": []map[string]interface{}{{
+					"pos":   []int32{0, 0, 0},
+					"state": int32(0),
+					"nbt": map[string]interface{}{
+						"id":    "minecraft:chest",
+						"Items": []map[string]interface{}{item},
+					},
+				}},
+				"entities": []map[string]interface{}{},
+			}
+
+			data, err := DecodeAny(encodeTestNBT(t, fixture))
+			if err != nil {
+				t.Fatalf("Failed to decode fixture: %v", err)
+			}
+			standard, err := ConvertToStandard(data)
+			if err != nil {
+				t.Fatalf("Failed to convert fixture: %v", err)
+			}
+
+			if len(standard.TileEntities) != 1 {
+				t.Fatalf("Expected 1 tile entity, got %d", len(standard.TileEntities))
+			}
+			items := ContainerItems(standard.TileEntities[0])
+			if len(items) != 1 {
+				t.Fatalf("Expected 1 item, got %d", len(items))
+			}
+			got := items[0]
+			if got.ID != "minecraft:diamond_sword" || got.Count != 1 || got.Slot != 3 {
+				t.Errorf("Unexpected item: %+v", got)
+			}
+			if got.CustomName != `{"text":"Blade"}` {
+				t.Errorf("Expected custom name, got %q", got.CustomName)
+			}
+			if got.Enchantments["minecraft:sharpness"] != 5 {
+				t.Errorf("Expected sharpness 5, got %v", got.Enchantments)
+			}
+		})
+	}
+}
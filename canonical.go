@@ -0,0 +1,188 @@
+package mcnbt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// EncodeCanonicalNBT encodes data in the given format with deterministic
+// compound key ordering and fixed gzip settings, so the same input always
+// produces identical bytes. data may be a *StandardFormat or a typed format struct.
+func EncodeCanonicalNBT(data interface{}, format string) ([]byte, error) {
+	typed, err := toFormatStruct(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw bytes.Buffer
+	if err := nbt.NewEncoder(&raw).Encode(typed, formatRootNames[format]); err != nil {
+		return nil, fmt.Errorf("failed to encode NBT: %w", err)
+	}
+
+	var canonical bytes.Buffer
+	if err := canonicalizeNBT(bytes.NewReader(raw.Bytes()), &canonical); err != nil {
+		return nil, fmt.Errorf("failed to canonicalize NBT: %w", err)
+	}
+
+	// A zero-value gzip header has no timestamp or file name
+	var out bytes.Buffer
+	gz, err := gzip.NewWriterLevel(&out, gzip.DefaultCompression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gzip writer: %w", err)
+	}
+	if _, err := gz.Write(canonical.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to compress NBT: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress NBT: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// canonicalizeNBT rewrites a binary NBT stream with every compound's entries sorted by name
+func canonicalizeNBT(r *bytes.Reader, w *bytes.Buffer) error {
+	tagType, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	name, err := readNBTString(r)
+	if err != nil {
+		return err
+	}
+	w.WriteByte(tagType)
+	writeNBTString(w, name)
+	return canonicalizePayload(r, w, tagType)
+}
+
+// canonicalizePayload copies a single tag payload, sorting nested compound entries
+func canonicalizePayload(r *bytes.Reader, w *bytes.Buffer, tagType byte) error {
+	switch tagType {
+	case nbt.TagByte:
+		return copyN(r, w, 1)
+	case nbt.TagShort:
+		return copyN(r, w, 2)
+	case nbt.TagInt, nbt.TagFloat:
+		return copyN(r, w, 4)
+	case nbt.TagLong, nbt.TagDouble:
+		return copyN(r, w, 8)
+	case nbt.TagByteArray, nbt.TagIntArray, nbt.TagLongArray:
+		n, err := readInt32(r)
+		if err != nil {
+			return err
+		}
+		writeInt32(w, n)
+		size := map[byte]int{nbt.TagByteArray: 1, nbt.TagIntArray: 4, nbt.TagLongArray: 8}[tagType]
+		return copyN(r, w, int(n)*size)
+	case nbt.TagString:
+		s, err := readNBTString(r)
+		if err != nil {
+			return err
+		}
+		writeNBTString(w, s)
+		return nil
+	case nbt.TagList:
+		elemType, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		n, err := readInt32(r)
+		if err != nil {
+			return err
+		}
+		w.WriteByte(elemType)
+		writeInt32(w, n)
+		for i := int32(0); i < n; i++ {
+			if err := canonicalizePayload(r, w, elemType); err != nil {
+				return err
+			}
+		}
+		return nil
+	case nbt.TagCompound:
+		type entry struct {
+			name    string
+			encoded []byte
+		}
+		var entries []entry
+		for {
+			childType, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if childType == nbt.TagEnd {
+				break
+			}
+			name, err := readNBTString(r)
+			if err != nil {
+				return err
+			}
+			var child bytes.Buffer
+			child.WriteByte(childType)
+			writeNBTString(&child, name)
+			if err := canonicalizePayload(r, &child, childType); err != nil {
+				return err
+			}
+			entries = append(entries, entry{name: name, encoded: child.Bytes()})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].name < entries[j].name
+		})
+		for _, e := range entries {
+			w.Write(e.encoded)
+		}
+		w.WriteByte(nbt.TagEnd)
+		return nil
+	}
+	return fmt.Errorf("unknown NBT tag type 0x%x", tagType)
+}
+
+// copyN copies n bytes from r to w
+func copyN(r *bytes.Reader, w *bytes.Buffer, n int) error {
+	if n < 0 || n > r.Len() {
+		return io.ErrUnexpectedEOF
+	}
+	_, err := io.CopyN(w, r, int64(n))
+	return err
+}
+
+// readInt32 reads a big-endian int32
+func readInt32(r *bytes.Reader) (int32, error) {
+	var buf [4]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(buf[:])), nil
+}
+
+// writeInt32 writes a big-endian int32
+func writeInt32(w *bytes.Buffer, n int32) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(n))
+	w.Write(buf[:])
+}
+
+// readNBTString reads a length-prefixed NBT string
+func readNBTString(r *bytes.Reader) (string, error) {
+	var buf [2]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return "", err
+	}
+	s := make([]byte, binary.BigEndian.Uint16(buf[:]))
+	if _, err := io.ReadFull(r, s); err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+// writeNBTString writes a length-prefixed NBT string
+func writeNBTString(w *bytes.Buffer, s string) {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], uint16(len(s)))
+	w.Write(buf[:])
+	w.WriteString(s)
+}
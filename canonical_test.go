@@ -0,0 +1,43 @@
+package mcnbt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestEncodeCanonicalNBTDeterministic verifies repeated encodes are byte-equal
+func TestEncodeCanonicalNBTDeterministic(t *testing.T) {
+	data, err := ParseAnyFromFileAsJSON("testdata/color_field.schem")
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	for _, format := range []string{"litematica", "worldedit", "create"} {
+		t.Run(format, func(t *testing.T) {
+			first, err := EncodeCanonicalNBT(standard, format)
+			if err != nil {
+				t.Fatalf("Failed to encode: %v", err)
+			}
+
+			// Map iteration order is randomized, so repeat to catch ordering leaks
+			for i := 0; i < 5; i++ {
+				again, err := EncodeCanonicalNBT(standard, format)
+				if err != nil {
+					t.Fatalf("Failed to encode: %v", err)
+				}
+				if !bytes.Equal(first, again) {
+					t.Fatalf("Encode %d differs from the first encode", i+2)
+				}
+			}
+
+			if _, err := DecodeAny(first); err != nil {
+				t.Errorf("Canonical output does not decode: %v", err)
+			}
+		})
+	}
+}
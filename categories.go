@@ -0,0 +1,77 @@
+package mcnbt
+
+import (
+	"strings"
+)
+
+// blockCategories maps a category name to name fragments that identify it.
+// Categories are checked in order, so more specific entries come first.
+var blockCategories = []struct {
+	Category  string
+	Fragments []string
+}{
+	{"redstone", []string{"redstone", "repeater", "comparator", "piston", "observer", "lever", "button", "pressure_plate", "hopper", "dropper", "dispenser", "daylight_detector", "tripwire", "target", "rail"}},
+	{"glass", []string{"glass"}},
+	{"wood", []string{"planks", "_log", "_wood", "stripped_", "oak_", "spruce_", "birch_", "jungle_", "acacia_", "dark_oak_", "mangrove_", "cherry_", "bamboo", "crimson_", "warped_"}},
+	{"stone", []string{"stone", "cobble", "granite", "diorite", "andesite", "deepslate", "tuff", "basalt", "blackstone", "brick", "sandstone", "prismarine", "purpur", "quartz", "calcite", "obsidian", "end_stone"}},
+	{"decoration", []string{"wool", "carpet", "concrete", "terracotta", "banner", "flower", "pot", "lantern", "torch", "candle", "painting", "sign", "bed", "leaves", "sapling"}},
+	{"metal", []string{"iron", "gold", "copper", "netherite", "chain", "anvil"}},
+	{"earth", []string{"dirt", "grass", "sand", "gravel", "clay", "mud", "podzol", "mycelium", "snow", "ice"}},
+}
+
+// categoryOther is used for blocks that match no entry in blockCategories
+const categoryOther = "other"
+
+// BlockCategory classifies a block name such as "minecraft:oak_planks" into a category
+func BlockCategory(name string) string {
+	// Strip the namespace so fragments match regardless of mod prefix
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, c := range blockCategories {
+		for _, fragment := range c.Fragments {
+			if strings.Contains(name, fragment) {
+				return c.Category
+			}
+		}
+	}
+	return categoryOther
+}
+
+// entityCategories maps a category name to the entity IDs, without their
+// namespace, that belong to it
+var entityCategories = []struct {
+	Category string
+	IDs      []string
+}{
+	{"hostile", []string{"zombie", "husk", "drowned", "zombie_villager", "skeleton", "stray", "wither_skeleton", "creeper",
+		"spider", "cave_spider", "enderman", "endermite", "silverfish", "witch", "slime", "magma_cube", "blaze",
+		"ghast", "guardian", "elder_guardian", "shulker", "phantom", "pillager", "vindicator", "evoker", "vex",
+		"ravager", "illusioner", "piglin_brute", "hoglin", "zoglin", "zombified_piglin", "warden", "breeze",
+		"bogged", "wither", "ender_dragon"}},
+	{"passive", []string{"pig", "cow", "mooshroom", "sheep", "chicken", "horse", "donkey", "mule", "skeleton_horse",
+		"zombie_horse", "llama", "trader_llama", "rabbit", "wolf", "cat", "ocelot", "parrot", "fox", "bee",
+		"panda", "polar_bear", "turtle", "goat", "frog", "tadpole", "axolotl", "camel", "sniffer", "armadillo",
+		"villager", "wandering_trader", "iron_golem", "snow_golem", "allay", "strider", "piglin", "bat",
+		"squid", "glow_squid", "dolphin", "cod", "salmon", "pufferfish", "tropical_fish"}},
+	{"projectile", []string{"arrow", "spectral_arrow", "trident", "snowball", "egg", "ender_pearl", "eye_of_ender",
+		"experience_bottle", "potion", "fireball", "small_fireball", "dragon_fireball", "wither_skull",
+		"shulker_bullet", "llama_spit", "firework_rocket", "fishing_bobber", "wind_charge", "breeze_wind_charge"}},
+	{"item", []string{"item", "experience_orb"}},
+}
+
+// EntityCategory classifies an entity ID such as "minecraft:zombie" as
+// hostile, passive, projectile, item or other
+func EntityCategory(id string) string {
+	if i := strings.Index(id, ":"); i >= 0 {
+		id = id[i+1:]
+	}
+	for _, c := range entityCategories {
+		for _, candidate := range c.IDs {
+			if candidate == id {
+				return c.Category
+			}
+		}
+	}
+	return categoryOther
+}
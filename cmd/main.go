@@ -6,6 +6,7 @@ import (
 	"github.com/uberswe/mcnbt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -19,6 +20,7 @@ func main() {
 	path := os.Args[1]
 	outputFormat := "json"        // Default output format
 	outputPath := "./output.json" // Default output path
+	splitOutput := false
 
 	// Parse command line arguments
 	for i := 2; i < len(os.Args); i++ {
@@ -27,6 +29,8 @@ func main() {
 			outputFormat = strings.TrimPrefix(arg, "--format=")
 		} else if strings.HasPrefix(arg, "--output=") {
 			outputPath = strings.TrimPrefix(arg, "--output=")
+		} else if arg == "--split-output" {
+			splitOutput = true
 		} else if arg == "--help" {
 			printUsage()
 			os.Exit(0)
@@ -42,6 +46,11 @@ func main() {
 	// Debug: Print the type of data
 	log.Printf("Data type: %T", data)
 
+	if splitOutput {
+		writeSplitOutputs(data, outputFormat, outputPath)
+		return
+	}
+
 	// Convert to the requested format
 	var outputData interface{}
 
@@ -80,27 +89,65 @@ func main() {
 		// Large output or custom output path, save to file
 		log.Printf("Output is %d bytes, saving to file: %s", len(b), outputPath)
 
-		// Create the output file
-		outputFile, err := os.Create(outputPath)
-		if err != nil {
-			log.Fatalf("Failed to create output file: %v", err)
-		}
-		defer outputFile.Close()
+		writeJSONFile(outputData, outputPath)
+	}
+}
 
-		// Use MarshalIndent for pretty formatting in the file
-		prettyJSON, err := json.MarshalIndent(outputData, "", "	")
-		if err != nil {
-			log.Fatalf("Failed to marshal JSON with indentation: %v", err)
-		}
+// writeSplitOutputs writes blocks, entities and tile entities to separate
+// files named after outputPath, e.g. output_blocks.json
+func writeSplitOutputs(data interface{}, outputFormat, outputPath string) {
+	standardData, err := mcnbt.ConvertToStandard(data)
+	if err != nil {
+		log.Fatalf("Failed to convert to standard format: %v", err)
+	}
 
-		// Write the pretty JSON to the file
-		_, err = outputFile.Write(prettyJSON)
-		if err != nil {
-			log.Fatalf("Failed to write to output file: %v", err)
+	blocks, entities, tileEntities := standardData.SplitOutputs()
+	ext := filepath.Ext(outputPath)
+	base := strings.TrimSuffix(outputPath, ext)
+	parts := []struct {
+		suffix string
+		data   *mcnbt.StandardFormat
+	}{
+		{"_blocks", blocks},
+		{"_entities", entities},
+		{"_tile_entities", tileEntities},
+	}
+
+	for _, part := range parts {
+		// The original format can't be split, so json falls back to standard
+		var outputData interface{} = part.data
+		if outputFormat != "json" && outputFormat != "standard" {
+			outputData, err = mcnbt.ConvertFromStandard(part.data, outputFormat)
+			if err != nil {
+				log.Fatalf("Failed to convert to %s format: %v", outputFormat, err)
+			}
 		}
+		writeJSONFile(outputData, base+part.suffix+ext)
+	}
+}
 
-		log.Printf("Successfully saved JSON to %s", outputPath)
+// writeJSONFile saves outputData as indented JSON at path
+func writeJSONFile(outputData interface{}, path string) {
+	// Create the output file
+	outputFile, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("Failed to create output file: %v", err)
 	}
+	defer outputFile.Close()
+
+	// Use MarshalIndent for pretty formatting in the file
+	prettyJSON, err := json.MarshalIndent(outputData, "", "	")
+	if err != nil {
+		log.Fatalf("Failed to marshal JSON with indentation: %v", err)
+	}
+
+	// Write the pretty JSON to the file
+	_, err = outputFile.Write(prettyJSON)
+	if err != nil {
+		log.Fatalf("Failed to write to output file: %v", err)
+	}
+
+	log.Printf("Successfully saved JSON to %s", path)
 }
 
 func printUsage() {
@@ -108,6 +155,7 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, "Options:\n")
 	fmt.Fprintf(os.Stderr, "  --format=<format>   Output format (json, standard, litematica, worldedit, create, worldsave)\n")
 	fmt.Fprintf(os.Stderr, "  --output=<path>     Output file path\n")
+	fmt.Fprintf(os.Stderr, "  --split-output      Write blocks, entities and tile entities to separate files\n")
 	fmt.Fprintf(os.Stderr, "  --help              Show this help message\n")
 }
 
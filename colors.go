@@ -0,0 +1,26 @@
+package mcnbt
+
+// dyeColors lists the sixteen dye colors used as block name prefixes
+var dyeColors = map[string]bool{
+	"white": true, "orange": true, "magenta": true, "light_blue": true,
+	"yellow": true, "lime": true, "pink": true, "gray": true,
+	"light_gray": true, "cyan": true, "purple": true, "blue": true,
+	"brown": true, "green": true, "red": true, "black": true,
+}
+
+// colorFamilies lists block name suffixes that come in every dye color
+var colorFamilies = map[string]bool{
+	"wool":               true,
+	"carpet":             true,
+	"concrete":           true,
+	"concrete_powder":    true,
+	"terracotta":         true,
+	"glazed_terracotta":  true,
+	"stained_glass":      true,
+	"stained_glass_pane": true,
+	"bed":                true,
+	"banner":             true,
+	"wall_banner":        true,
+	"candle":             true,
+	"shulker_box":        true,
+}
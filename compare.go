@@ -0,0 +1,43 @@
+package mcnbt
+
+// EqualIgnoringOffset reports whether a and b contain the same solid blocks in
+// the same relative arrangement, ignoring where each sits in its bounding box,
+// its world position, and how the palettes are indexed.
+func EqualIgnoringOffset(a, b *StandardFormat) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	na, nb := a.clone(), b.clone()
+	na.FitBounds()
+	nb.FitBounds()
+
+	if na.Size != nb.Size {
+		return false
+	}
+
+	sa, sb := na.resolvedSolidBlocks(), nb.resolvedSolidBlocks()
+	if len(sa) != len(sb) {
+		return false
+	}
+	for pos, state := range sa {
+		other, ok := sb[pos]
+		if !ok || other.Name != state.Name || !equalProperties(other.Properties, state.Properties) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolvedSolidBlocks maps each solid block position to its palette entry
+func (sf *StandardFormat) resolvedSolidBlocks() map[[3]int]StandardPalette {
+	result := make(map[[3]int]StandardPalette)
+	for _, block := range sf.Blocks {
+		if !sf.isSolid(block) {
+			continue
+		}
+		key := [3]int{int(block.Position.X), int(block.Position.Y), int(block.Position.Z)}
+		result[key] = sf.Palette[block.State]
+	}
+	return result
+}
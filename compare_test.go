@@ -0,0 +1,43 @@
+package mcnbt
+
+import (
+	"testing"
+)
+
+// TestEqualIgnoringOffset verifies a translated copy compares equal and a changed copy does not
+func TestEqualIgnoringOffset(t *testing.T) {
+	original := newTestStandard(3, 3, 3)
+	original.Palette[2] = StandardPalette{Name: "minecraft:glass"}
+	addTestBlock(original, 0, 0, 0, 1)
+	addTestBlock(original, 1, 0, 0, 2)
+	addTestBlock(original, 1, 1, 0, 1)
+
+	// Same shape shifted inside a larger box, with a different palette order and position
+	translated := &StandardFormat{
+		Size:     StandardSize{X: 5, Y: 5, Z: 5},
+		Position: StandardPosition{X: 100, Y: 64, Z: -20},
+		Palette: map[int]StandardPalette{
+			0: {Name: "minecraft:glass"},
+			1: {Name: "minecraft:air"},
+			2: {Name: "minecraft:stone"},
+		},
+	}
+	addTestBlock(translated, 2, 1, 3, 2)
+	addTestBlock(translated, 3, 1, 3, 0)
+	addTestBlock(translated, 3, 2, 3, 2)
+	addTestBlock(translated, 0, 0, 0, 1)
+
+	if !EqualIgnoringOffset(original, translated) {
+		t.Errorf("Expected translated copy to be equal")
+	}
+
+	// The comparison must not modify its inputs
+	if translated.Size.X != 5 || len(translated.Blocks) != 4 {
+		t.Errorf("EqualIgnoringOffset modified its input")
+	}
+
+	translated.Blocks[2].State = 0
+	if EqualIgnoringOffset(original, translated) {
+		t.Errorf("Expected changed copy to differ")
+	}
+}
@@ -0,0 +1,305 @@
+package mcnbt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// animationFrameGap is the number of empty blocks between frames in AnimationFrames
+const animationFrameGap = 1
+
+// AnimationFrames lays frames out side by side along the X axis, separated by
+// a gap, producing a single "flipbook" schematic. All frames must share the
+// same Y and Z size. Palettes are merged, so frames may use different palettes.
+func AnimationFrames(frames []*StandardFormat) (*StandardFormat, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames given")
+	}
+
+	first := frames[0]
+	combined := &StandardFormat{
+		DataVersion:    first.DataVersion,
+		Version:        first.Version,
+		Metadata:       first.Metadata,
+		Position:       first.Position,
+		OriginalFormat: first.OriginalFormat,
+		Palette:        make(map[int]StandardPalette),
+		Size:           StandardSize{Y: first.Size.Y, Z: first.Size.Z},
+	}
+
+	offsetX := 0
+	for i, frame := range frames {
+		if frame == nil {
+			return nil, fmt.Errorf("frame %d is nil", i)
+		}
+		if frame.Size.Y != first.Size.Y || frame.Size.Z != first.Size.Z {
+			return nil, fmt.Errorf("frame %d has size %+v, incompatible with frame 0 size %+v", i, frame.Size, first.Size)
+		}
+
+		if i > 0 {
+			offsetX += animationFrameGap
+		}
+		combined.appendBlocks(frame, offsetX, 0, 0)
+		offsetX += frame.Size.X
+	}
+	combined.Size.X = offsetX
+
+	return combined, nil
+}
+
+// appendBlocks copies every block, tile entity, entity and scheduled tick
+// from src into sf, shifted by the given offset, remapping palette states into sf's palette.
+// sf's tile entities at positions src has a block for are dropped, since
+// that block replaces theirs.
+func (sf *StandardFormat) appendBlocks(src *StandardFormat, dx, dy, dz int) {
+	states := newPaletteIndex(sf.Palette)
+	stateMap := make(map[int]int, len(src.Palette))
+	for i, p := range src.Palette {
+		stateMap[i] = states.indexFor(p.Name, p.Properties)
+	}
+	move := func(pos *StandardBlockPosition) {
+		pos.X += float64(dx)
+		pos.Y += float64(dy)
+		pos.Z += float64(dz)
+	}
+
+	replaced := make(map[[3]int]bool, len(src.Blocks))
+	for _, block := range src.Blocks {
+		move(&block.Position)
+		if state, ok := stateMap[block.State]; ok {
+			block.State = state
+		}
+		sf.Blocks = append(sf.Blocks, block)
+		replaced[positionKey(block.Position)] = true
+	}
+
+	tileEntities := sf.TileEntities[:0]
+	for _, te := range sf.TileEntities {
+		if !replaced[positionKey(te.Position)] {
+			tileEntities = append(tileEntities, te)
+		}
+	}
+	sf.TileEntities = tileEntities
+	for _, te := range src.TileEntities {
+		move(&te.Position)
+		sf.TileEntities = append(sf.TileEntities, te)
+	}
+
+	for _, entity := range src.Entities {
+		move(&entity.Position)
+		sf.Entities = append(sf.Entities, entity)
+	}
+
+	for _, key := range tickKeys {
+		ticks := pendingTicks(src.Extra[key])
+		if len(ticks) == 0 {
+			continue
+		}
+		combined := append([]LitematicaPendingTick(nil), pendingTicks(sf.Extra[key])...)
+		for _, tick := range ticks {
+			tick.X += int32(dx)
+			tick.Y += int32(dy)
+			tick.Z += int32(dz)
+			combined = append(combined, tick)
+		}
+		if sf.Extra == nil {
+			sf.Extra = make(map[string]interface{})
+		}
+		sf.Extra[key] = combined
+	}
+}
+
+// Merge places overlay into base at offset, relative to base's origin, and
+// returns the combined schematic. Palettes are unioned, Size grows to the
+// bounding box of both, and overlay blocks replace base blocks at the same
+// position. Entities from both are kept. If offset is negative on an axis,
+// everything shifts so the box still starts at the origin and Position moves
+// to compensate. Base's biomes are kept, with overlay's pasted over them,
+// only if the merged box is base's own; otherwise they are dropped. Neither
+// input is modified.
+func Merge(base, overlay *StandardFormat, offset StandardPosition) (*StandardFormat, error) {
+	if base == nil || overlay == nil {
+		return nil, fmt.Errorf("base and overlay schematics are required")
+	}
+
+	minX, minY, minZ := min(0, offset.X), min(0, offset.Y), min(0, offset.Z)
+	maxX := max(base.Size.X, offset.X+overlay.Size.X)
+	maxY := max(base.Size.Y, offset.Y+overlay.Size.Y)
+	maxZ := max(base.Size.Z, offset.Z+overlay.Size.Z)
+
+	merged := base.clone()
+	merged.Blocks, merged.TileEntities, merged.Entities = nil, nil, nil
+	for _, key := range tickKeys {
+		delete(merged.Extra, key)
+	}
+	merged.Size = StandardSize{X: maxX - minX, Y: maxY - minY, Z: maxZ - minZ}
+	merged.Position.X += minX
+	merged.Position.Y += minY
+	merged.Position.Z += minZ
+
+	merged.appendBlocks(base, -minX, -minY, -minZ)
+	merged.appendBlocks(overlay, offset.X-minX, offset.Y-minY, offset.Z-minZ)
+	merged.dedupeBlocks()
+
+	// Biomes are only kept when base's grid covers the whole merged box
+	if merged.Size != base.Size {
+		merged.Biomes = nil
+	} else if merged.Biomes != nil && overlay.Biomes != nil {
+		merged.Biomes = merged.Biomes.pasted(overlay.Biomes, offset)
+	}
+
+	return merged, nil
+}
+
+// dedupeBlocks keeps only the last block at each position, ordering blocks
+// by Y, then Z, then X
+func (sf *StandardFormat) dedupeBlocks() {
+	blocks := make(map[[3]int]StandardBlock)
+	for _, block := range sf.Blocks {
+		blocks[positionKey(block.Position)] = block
+	}
+
+	keys := make([][3]int, 0, len(blocks))
+	for key := range blocks {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lessPosition(keys[i], keys[j])
+	})
+
+	sf.Blocks = make([]StandardBlock, 0, len(keys))
+	for _, key := range keys {
+		sf.Blocks = append(sf.Blocks, blocks[key])
+	}
+	sf.invalidateIndex()
+}
+
+// SplitOutputs splits sf into three schematics holding only its blocks, its
+// entities and its tile entities respectively, so each can be encoded on its
+// own. All three keep sf's metadata, size, position and palette. sf is not
+// modified.
+func (sf *StandardFormat) SplitOutputs() (blocks, entities, tileEntities *StandardFormat) {
+	blocks, entities, tileEntities = sf.clone(), sf.clone(), sf.clone()
+	blocks.Entities, blocks.TileEntities = nil, nil
+	entities.Blocks, entities.TileEntities = nil, nil
+	tileEntities.Blocks, tileEntities.Entities = nil, nil
+	return blocks, entities, tileEntities
+}
+
+// ParseMultiPart decodes a schematic split across several files and merges the
+// parts into one. Parts are merged in path order, each placed at its own
+// Position, so later parts win where they overlap. All parts must share the
+// same DataVersion. Numbers in paths compare by value, so part10 follows part9.
+func ParseMultiPart(paths []string) (*StandardFormat, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no part files given")
+	}
+
+	sorted := append([]string(nil), paths...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return naturalLess(sorted[i], sorted[j])
+	})
+
+	var combined *StandardFormat
+	for _, path := range sorted {
+		data, err := ParseAnyFromFileAsJSON(path)
+		if err != nil {
+			return nil, err
+		}
+		part, err := ConvertToStandard(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert part %s: %w", path, err)
+		}
+
+		if combined == nil {
+			combined = part
+			continue
+		}
+		if part.DataVersion != combined.DataVersion {
+			return nil, fmt.Errorf("part %s has data version %d, incompatible with %d", path, part.DataVersion, combined.DataVersion)
+		}
+
+		offset := StandardPosition{
+			X: part.Position.X - combined.Position.X,
+			Y: part.Position.Y - combined.Position.Y,
+			Z: part.Position.Z - combined.Position.Z,
+		}
+		if combined, err = Merge(combined, part, offset); err != nil {
+			return nil, fmt.Errorf("failed to merge part %s: %w", path, err)
+		}
+	}
+	return combined, nil
+}
+
+// naturalLess orders strings byte by byte, except that runs of digits
+// compare by their numeric value. Strings that compare equal that way, such
+// as part1 and part01, fall back to plain string order.
+func naturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if !isDigit(a[i]) || !isDigit(b[j]) {
+			if a[i] != b[j] {
+				return a[i] < b[j]
+			}
+			i++
+			j++
+			continue
+		}
+
+		startA, startB := i, j
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+		numA := strings.TrimLeft(a[startA:i], "0")
+		numB := strings.TrimLeft(b[startB:j], "0")
+		if len(numA) != len(numB) {
+			return len(numA) < len(numB)
+		}
+		if numA != numB {
+			return numA < numB
+		}
+	}
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	return a < b
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// PasteCoordinates returns the world position of the minimum corner when the
+// schematic is pasted at playerPos. WorldEdit stores this offset in its
+// WEOffset metadata; schematics without one fall back to sf.Position.
+func (sf *StandardFormat) PasteCoordinates(playerPos StandardPosition) StandardPosition {
+	offset, ok := sf.extraPosition("WEOffset")
+	if !ok {
+		offset = sf.Position
+	}
+	return StandardPosition{
+		X: playerPos.X + offset.X,
+		Y: playerPos.Y + offset.Y,
+		Z: playerPos.Z + offset.Z,
+	}
+}
+
+// extraPosition returns a position kept in Extra under key, if any. It is a
+// generic map when the standard format was loaded from JSON.
+func (sf *StandardFormat) extraPosition(key string) (StandardPosition, bool) {
+	switch v := sf.Extra[key].(type) {
+	case StandardPosition:
+		return v, true
+	case map[string]interface{}:
+		x, _ := toFloat64(v["x"])
+		y, _ := toFloat64(v["y"])
+		z, _ := toFloat64(v["z"])
+		return StandardPosition{X: int(x), Y: int(y), Z: int(z)}, true
+	}
+	return StandardPosition{}, false
+}
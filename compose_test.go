@@ -0,0 +1,247 @@
+package mcnbt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// newTestCube builds a solid size×size×size cube of a single block
+func newTestCube(size int, name string) *StandardFormat {
+	sf := &StandardFormat{
+		Size:    StandardSize{X: size, Y: size, Z: size},
+		Palette: map[int]StandardPalette{0: {Name: name, Properties: map[string]string{}}},
+	}
+	for y := 0; y < size; y++ {
+		for z := 0; z < size; z++ {
+			for x := 0; x < size; x++ {
+				addTestBlock(sf, x, y, z, 0)
+			}
+		}
+	}
+	return sf
+}
+
+// TestAnimationFrames verifies frames are placed side by side with a gap
+func TestAnimationFrames(t *testing.T) {
+	frames := []*StandardFormat{
+		newTestCube(2, "minecraft:stone"),
+		newTestCube(2, "minecraft:glass"),
+		newTestCube(2, "minecraft:stone"),
+	}
+
+	combined, err := AnimationFrames(frames)
+	if err != nil {
+		t.Fatalf("Failed to combine frames: %v", err)
+	}
+
+	// 3 frames of width 2 with 2 gaps of 1
+	if combined.Size.X != 8 {
+		t.Errorf("Expected combined width 8, got %d", combined.Size.X)
+	}
+	if combined.Size.Y != 2 || combined.Size.Z != 2 {
+		t.Errorf("Expected Y/Z size 2, got %+v", combined.Size)
+	}
+	if len(combined.Blocks) != 24 {
+		t.Errorf("Expected 24 blocks, got %d", len(combined.Blocks))
+	}
+	if len(combined.Palette) != 2 {
+		t.Errorf("Expected merged palette of 2 entries, got %d", len(combined.Palette))
+	}
+
+	for _, block := range combined.Blocks {
+		if block.Position.X == 2 || block.Position.X == 5 {
+			t.Fatalf("Found block in the gap at %+v", block.Position)
+		}
+		if block.Position.X >= 3 && block.Position.X < 5 {
+			if name := combined.Palette[block.State].Name; name != "minecraft:glass" {
+				t.Errorf("Expected glass in the second frame, got %s", name)
+			}
+		}
+	}
+
+	frames[1].Size.Y = 3
+	if _, err := AnimationFrames(frames); err == nil {
+		t.Errorf("Expected an error for incompatible frame sizes")
+	}
+}
+
+// TestMerge verifies two cubes side by side produce the union size and all blocks
+func TestMerge(t *testing.T) {
+	base := newTestCube(2, "minecraft:stone")
+	overlay := newTestCube(2, "minecraft:glass")
+	overlay.Entities = append(overlay.Entities, StandardEntity{ID: "minecraft:pig"})
+
+	merged, err := Merge(base, overlay, StandardPosition{X: 2})
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if merged.Size != (StandardSize{X: 4, Y: 2, Z: 2}) {
+		t.Errorf("Expected size 4x2x2, got %+v", merged.Size)
+	}
+	if len(merged.Blocks) != 16 || len(merged.Entities) != 1 {
+		t.Errorf("Expected 16 blocks and 1 entity, got %d and %d", len(merged.Blocks), len(merged.Entities))
+	}
+	if block, ok := merged.GetBlockAt(3, 1, 1); !ok || merged.Palette[block.State].Name != "minecraft:glass" {
+		t.Errorf("Expected glass at 3,1,1")
+	}
+
+	// Overlapping by one column, overlay blocks win
+	merged, err = Merge(base, overlay, StandardPosition{X: 1})
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if merged.Size.X != 3 {
+		t.Errorf("Expected X size 3, got %d", merged.Size.X)
+	}
+	if block, ok := merged.GetBlockAt(1, 0, 0); !ok || merged.Palette[block.State].Name != "minecraft:glass" {
+		t.Errorf("Expected overlay glass to replace stone at 1,0,0")
+	}
+	if len(base.Blocks) != 8 {
+		t.Errorf("Expected base to be left unchanged")
+	}
+}
+
+// TestPasteCoordinates verifies the WorldEdit paste offset is applied to the player position
+func TestPasteCoordinates(t *testing.T) {
+	player := StandardPosition{X: 100, Y: 64, Z: -50}
+	testCases := map[string]struct {
+		metadata WorldEditMetadata
+		expected StandardPosition
+	}{
+		"WEOffset metadata":       {WorldEditMetadata{WEOffsetX: -2, WEOffsetY: 1, WEOffsetZ: 3}, StandardPosition{X: 98, Y: 65, Z: -47}},
+		"Offset without metadata": {WorldEditMetadata{}, StandardPosition{X: 600, Y: 74, Z: -45}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			fixture := WorldEditNBT{
+				BlockData:   []byte{0},
+				DataVersion: 3465,
+				Width:       1,
+				Height:      1,
+				Length:      1,
+				Metadata:    tc.metadata,
+				Offset:      []int32{500, 10, 5},
+				Palette:     map[string]int32{"minecraft:stone": 0},
+				PaletteMax:  1,
+				Version:     2,
+			}
+
+			data, err := DecodeAny(encodeTestNBT(t, fixture))
+			if err != nil {
+				t.Fatalf("Failed to decode fixture: %v", err)
+			}
+			standard, err := ConvertToStandard(data)
+			if err != nil {
+				t.Fatalf("Failed to convert fixture: %v", err)
+			}
+
+			if got := standard.PasteCoordinates(player); got != tc.expected {
+				t.Errorf("Expected paste corner %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestParseMultiPart verifies two part files merge at their positions into one schematic
+func TestParseMultiPart(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i, name := range []string{"minecraft:stone", "minecraft:glass"} {
+		part := newTestCube(2, name)
+		part.DataVersion = 3465
+		part.Position = StandardPosition{X: 100 + 2*i, Y: 64, Z: 0}
+
+		encoded, err := EncodeToBytes(part, "litematica")
+		if err != nil {
+			t.Fatalf("Failed to encode part %d: %v", i, err)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("build.part%d.litematic", i+1))
+		if err := os.WriteFile(path, encoded, 0o644); err != nil {
+			t.Fatalf("Failed to write part %d: %v", i, err)
+		}
+		paths = append([]string{path}, paths...)
+	}
+
+	combined, err := ParseMultiPart(paths)
+	if err != nil {
+		t.Fatalf("Failed to parse parts: %v", err)
+	}
+	if len(combined.Blocks) != 16 {
+		t.Errorf("Expected 16 blocks, got %d", len(combined.Blocks))
+	}
+	if combined.Size != (StandardSize{X: 4, Y: 2, Z: 2}) {
+		t.Errorf("Expected size 4x2x2, got %+v", combined.Size)
+	}
+	if block, ok := combined.GetBlockAt(3, 0, 0); !ok || combined.Palette[block.State].Name != "minecraft:glass" {
+		t.Errorf("Expected glass from the second part at 3,0,0")
+	}
+}
+
+// TestParseMultiPartNumericOrder verifies part10 and later merge after part9,
+// so the last numbered part wins where parts overlap
+func TestParseMultiPartNumericOrder(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := 1; i <= 11; i++ {
+		name := "minecraft:stone"
+		if i == 11 {
+			name = "minecraft:glass"
+		}
+		part := newTestCube(1, name)
+		part.DataVersion = 3465
+
+		encoded, err := EncodeToBytes(part, "litematica")
+		if err != nil {
+			t.Fatalf("Failed to encode part %d: %v", i, err)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("build.part%d.litematic", i))
+		if err := os.WriteFile(path, encoded, 0o644); err != nil {
+			t.Fatalf("Failed to write part %d: %v", i, err)
+		}
+		paths = append(paths, path)
+	}
+
+	combined, err := ParseMultiPart(paths)
+	if err != nil {
+		t.Fatalf("Failed to parse parts: %v", err)
+	}
+	if block, ok := combined.GetBlockAt(0, 0, 0); !ok || combined.Palette[block.State].Name != "minecraft:glass" {
+		t.Errorf("Expected glass from part 11 at 0,0,0, got %+v", block)
+	}
+
+	sorted := []string{"part10", "part9", "part01", "part2", "part1"}
+	sort.Slice(sorted, func(i, j int) bool { return naturalLess(sorted[i], sorted[j]) })
+	if expected := []string{"part01", "part1", "part2", "part9", "part10"}; !reflect.DeepEqual(sorted, expected) {
+		t.Errorf("Expected %v, got %v", expected, sorted)
+	}
+}
+
+// TestSplitOutputs verifies the three outputs together hold every original record
+func TestSplitOutputs(t *testing.T) {
+	sf := newTestCube(2, "minecraft:stone")
+	sf.TileEntities = []StandardTileEntity{{ID: "minecraft:chest"}}
+	sf.Entities = []StandardEntity{{ID: "minecraft:pig"}}
+
+	blocks, entities, tileEntities := sf.SplitOutputs()
+	for _, part := range []*StandardFormat{blocks, entities, tileEntities} {
+		if part.Size != sf.Size || len(part.Palette) != len(sf.Palette) {
+			t.Errorf("Expected each output to keep size and palette, got %+v with %d palette entries", part.Size, len(part.Palette))
+		}
+	}
+
+	if len(blocks.Blocks) != 8 || len(blocks.Entities) != 0 || len(blocks.TileEntities) != 0 {
+		t.Errorf("Expected only 8 blocks in the block output, got %d, %d and %d",
+			len(blocks.Blocks), len(blocks.Entities), len(blocks.TileEntities))
+	}
+	if len(entities.Entities) != 1 || entities.Entities[0].ID != "minecraft:pig" || len(entities.Blocks) != 0 || len(entities.TileEntities) != 0 {
+		t.Errorf("Expected only the pig in the entity output, got %+v", entities)
+	}
+	if len(tileEntities.TileEntities) != 1 || tileEntities.TileEntities[0].ID != "minecraft:chest" || len(tileEntities.Blocks) != 0 || len(tileEntities.Entities) != 0 {
+		t.Errorf("Expected only the chest in the tile entity output, got %+v", tileEntities)
+	}
+}
@@ -0,0 +1,135 @@
+package mcnbt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/hex"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// decompressor opens a decompressing reader for data starting with magic.
+// The returned close function releases the reader's resources.
+type decompressor struct {
+	name  string
+	magic []byte
+	open  func(io.Reader) (io.Reader, func(), error)
+}
+
+var (
+	decompressorsMu sync.RWMutex
+	decompressors   []decompressor
+)
+
+func init() {
+	gz := func(r io.Reader) (io.Reader, func(), error) {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Some tools pad the file with zero bytes after the gzip member. Only read a
+		// single member so that padding is never parsed as another gzip header.
+		zr.Multistream(false)
+		return zr, func() {}, nil
+	}
+	zl := func(r io.Reader) (io.Reader, func(), error) {
+		zr, err := zlib.NewReader(r)
+		return zr, func() {}, err
+	}
+	zs := func(r io.Reader) (io.Reader, func(), error) {
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, zr.Close, nil
+	}
+
+	registerDecompressor("gzip", []byte{0x1f, 0x8b}, gz)
+	registerDecompressor("zlib", []byte{0x78, 0x01}, zl)
+	registerDecompressor("zlib", []byte{0x78, 0x9c}, zl)
+	registerDecompressor("zlib", []byte{0x78, 0xda}, zl)
+	registerDecompressor("zstd", zstdMagic, zs)
+}
+
+// RegisterDecompressor makes the decoder recognize data starting with magic
+// and read it through fn, e.g. to support lz4. fn receives the data starting
+// at the magic, so it must skip the magic itself if its format does not. Later registrations take
+// precedence, including over the built-in gzip, zlib and zstd support and the
+// leading 1 or 2 byte some tools write to mark gzip or zlib data. If the
+// reader returned by fn is an io.Closer it is closed once decoding is done.
+// DecodeInfo reports such data with the hex-encoded magic as its Compression.
+func RegisterDecompressor(magic []byte, fn func(io.Reader) (io.Reader, error)) {
+	registerDecompressor(hex.EncodeToString(magic), magic, func(r io.Reader) (io.Reader, func(), error) {
+		dr, err := fn(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		closeFn := func() {}
+		if c, ok := dr.(io.Closer); ok {
+			closeFn = func() { c.Close() }
+		}
+		return dr, closeFn, nil
+	})
+}
+
+// UnregisterDecompressor removes the decompressors registered for magic with
+// RegisterDecompressor. Built-in support for the same magic is kept.
+func UnregisterDecompressor(magic []byte) {
+	name := hex.EncodeToString(magic)
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+	kept := decompressors[:0:0]
+	for _, d := range decompressors {
+		if d.name != name || !bytes.Equal(d.magic, magic) {
+			kept = append(kept, d)
+		}
+	}
+	decompressors = kept
+}
+
+func registerDecompressor(name string, magic []byte, open func(io.Reader) (io.Reader, func(), error)) {
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+	d := decompressor{name: name, magic: append([]byte(nil), magic...), open: open}
+	decompressors = append([]decompressor{d}, decompressors...)
+}
+
+// findDecompressor returns the registered decompressor whose magic prefixes head
+func findDecompressor(head []byte) (decompressor, bool) {
+	decompressorsMu.RLock()
+	defer decompressorsMu.RUnlock()
+	for _, d := range decompressors {
+		if len(d.magic) > 0 && bytes.HasPrefix(head, d.magic) {
+			return d, true
+		}
+	}
+	return decompressor{}, false
+}
+
+// decompressorNamed returns the most recently registered decompressor with the given name
+func decompressorNamed(name string) (decompressor, bool) {
+	decompressorsMu.RLock()
+	defer decompressorsMu.RUnlock()
+	for _, d := range decompressors {
+		if d.name == name {
+			return d, true
+		}
+	}
+	return decompressor{}, false
+}
+
+// maxMagicLength returns the length of the longest registered magic
+func maxMagicLength() int {
+	decompressorsMu.RLock()
+	defer decompressorsMu.RUnlock()
+	n := 0
+	for _, d := range decompressors {
+		if len(d.magic) > n {
+			n = len(d.magic)
+		}
+	}
+	return n
+}
@@ -1,6 +1,9 @@
 package mcnbt
 
 import (
+	"context"
+	"encoding/json"
+	"os"
 	"testing"
 )
 
@@ -144,12 +147,7 @@ func TestBlockEntityCount(t *testing.T) {
 				t.Fatalf("Failed to convert %s: %v", name, err)
 			}
 
-			count := 0
-			for _, block := range standard.Blocks {
-				if block.Type == "block_entity" {
-					count++
-				}
-			}
+			count := len(standard.TileEntities)
 			blockEntityCounts[name] = count
 			t.Logf("%s: %d block entities", name, count)
 		})
@@ -237,12 +235,9 @@ func TestTypeFieldConsistency(t *testing.T) {
 
 			t.Logf("%s: type distribution: %v", name, typeCounts)
 
-			// Verify only valid types are used
+			// Entities and block entities have their own slices
 			for typ := range typeCounts {
-				switch typ {
-				case "block", "block_entity", "entity":
-					// valid
-				default:
+				if typ != "block" {
 					t.Errorf("%s: unexpected block type %q", name, typ)
 				}
 			}
@@ -307,3 +302,132 @@ func TestRoundTrip(t *testing.T) {
 		})
 	}
 }
+
+// TestConvertToStandardKeepsNBTTypes verifies mapping a decoded map onto the
+// typed structs keeps NBT number types in block entity data
+func TestConvertToStandardKeepsNBTTypes(t *testing.T) {
+	fixture := map[string]interface{}{
+		"size": []int32{1, 1, 1},
+		"palette": []interface{}{
+			map[string]interface{}{"Name": "minecraft:chest"},
+		},
+		"blocks": []interface{}{
+			map[string]interface{}{
+				"pos":   []int32{0, 0, 0},
+				"state": int32(0),
+				"nbt": map[string]interface{}{
+					"id":            "minecraft:chest",
+					"LootTableSeed": int64(1) << 40,
+					"Items": []interface{}{
+						map[string]interface{}{"Slot": int8(3), "id": "minecraft:stone", "Count": int32(64)},
+					},
+				},
+			},
+		},
+	}
+
+	standard, err := ConvertToStandard(fixture)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+	if len(standard.TileEntities) != 1 {
+		t.Fatalf("Expected 1 tile entity, got %d", len(standard.TileEntities))
+	}
+
+	nbtData, ok := standard.TileEntities[0].NBT.(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected compound NBT, got %T", standard.TileEntities[0].NBT)
+	}
+	if seed, ok := nbtData["LootTableSeed"].(int64); !ok || seed != 1<<40 {
+		t.Errorf("Expected LootTableSeed to stay int64 1<<40, got %T %v", nbtData["LootTableSeed"], nbtData["LootTableSeed"])
+	}
+	items, _ := nbtData["Items"].([]interface{})
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %v", nbtData["Items"])
+	}
+	item, _ := items[0].(map[string]interface{})
+	if _, ok := item["Slot"].(int8); !ok {
+		t.Errorf("Expected Slot to stay int8, got %T", item["Slot"])
+	}
+	if _, ok := item["Count"].(int32); !ok {
+		t.Errorf("Expected Count to stay int32, got %T", item["Count"])
+	}
+
+	// The mapped data is a copy, so the caller's map is left as it was
+	nbtData["id"] = "minecraft:barrel"
+	source := fixture["blocks"].([]interface{})[0].(map[string]interface{})["nbt"].(map[string]interface{})
+	if source["id"] != "minecraft:chest" {
+		t.Errorf("Expected the input map to be unchanged, got id %v", source["id"])
+	}
+}
+
+// decodeBenchmarkFixture decodes a fixture into the generic value ConvertToStandard takes
+func decodeBenchmarkFixture(b *testing.B, path string) map[string]interface{} {
+	b.Helper()
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		b.Fatalf("Failed to read %s: %v", path, err)
+	}
+	data, err := DecodeAny(payload)
+	if err != nil {
+		b.Fatalf("Failed to decode %s: %v", path, err)
+	}
+	return (*data.(*interface{})).(map[string]interface{})
+}
+
+// BenchmarkMapToStruct measures mapping a decoded Litematica file onto LitematicaNBT
+func BenchmarkMapToStruct(b *testing.B) {
+	root := normalizeLitematicaBlockStates(decodeBenchmarkFixture(b, "testdata/color_field.litematic"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := mapToStruct(root, &LitematicaNBT{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkMapToStructJSON measures the JSON round trip mapToStruct replaced, for comparison
+func BenchmarkMapToStructJSON(b *testing.B) {
+	root := normalizeLitematicaBlockStates(decodeBenchmarkFixture(b, "testdata/color_field.litematic"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data, err := json.Marshal(root)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := json.Unmarshal(data, &LitematicaNBT{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkConvertToStandardMap measures a full conversion of a decoded Litematica file
+func BenchmarkConvertToStandardMap(b *testing.B) {
+	root := decodeBenchmarkFixture(b, "testdata/color_field.litematic")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ConvertToStandard(root); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkConvertToStandardMapJSON measures a full conversion through the
+// JSON round trip ConvertToStandard used to make, for comparison
+func BenchmarkConvertToStandardMapJSON(b *testing.B) {
+	root := decodeBenchmarkFixture(b, "testdata/color_field.litematic")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data, err := json.Marshal(normalizeLitematicaBlockStates(root))
+		if err != nil {
+			b.Fatal(err)
+		}
+		litematica := &LitematicaNBT{}
+		if err := json.Unmarshal(data, litematica); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := convertLitematicaToStandard(context.Background(), litematica); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package mcnbt
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// blockCountTags holds the root tags CountNonAirBlocks reads from any of the
+// supported formats. Block data is typed so it is decoded in the same pass
+// that skips every other tag.
+type blockCountTags struct {
+	// Litematica
+	Metadata *struct{}                         `nbt:"Metadata"`
+	Regions  map[string]litematicaRegionBlocks `nbt:"Regions"`
+
+	// WorldEdit. Blocks is a byte array of numeric IDs in pre-1.13 files and a
+	// compound holding Palette and Data in Sponge v3, which wraps everything
+	// in a Schematic compound.
+	Palette   map[string]int32 `nbt:"Palette"`
+	BlockData []byte           `nbt:"BlockData"`
+	Blocks    nbt.RawMessage   `nbt:"Blocks"`
+	AddBlocks []byte           `nbt:"AddBlocks"`
+	Data      []byte           `nbt:"Data"`
+	Width     int16            `nbt:"Width"`
+	Height    int16            `nbt:"Height"`
+	Length    int16            `nbt:"Length"`
+	Schematic *blockCountTags  `nbt:"Schematic"`
+
+	// Structure and Create. blocks is a list of compounds or, in some Create
+	// exports, a compound of parallel arrays.
+	StructureBlocks  nbt.RawMessage     `nbt:"blocks"`
+	StructurePalette []StructurePalette `nbt:"palette"`
+}
+
+// structureBlockState is the part of a structure block that sets its state
+type structureBlockState struct {
+	Pos   []int32 `nbt:"pos"`
+	State int32   `nbt:"state"`
+	Name  string  `nbt:"Name"`
+}
+
+// CountNonAirBlocks reports how many blocks in a schematic file are not air,
+// matching the blocks ConvertToStandard would produce. Only the palette and
+// block data are decoded, and blocks are tallied straight from the packed
+// data without building a StandardFormat, so this is much faster and uses
+// far less memory than a full conversion. Nested gzip blobs are not expanded.
+func CountNonAirBlocks(data []byte) (int, error) {
+	nbtReader, _, closeFn, err := openNBTStream(bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	defer closeFn()
+
+	var root blockCountTags
+	if _, err := nbt.NewDecoder(nbtReader).Decode(&root); err != nil {
+		return 0, fmt.Errorf("failed to decode NBT: %w", err)
+	}
+	if root.Schematic != nil {
+		root = *root.Schematic
+	}
+
+	// Formats are checked in the order ConvertToStandard tries them
+	switch {
+	case root.Metadata != nil && root.Regions != nil:
+		return countLitematicaNonAir(root.Regions)
+	case root.BlockData != nil && root.Palette != nil:
+		return countWorldEditNonAir(root.Palette, root.BlockData, root.Width, root.Height, root.Length)
+	case root.Blocks.Type == nbt.TagByteArray:
+		return countWorldEditLegacyNonAir(root)
+	case root.Blocks.Type == nbt.TagCompound:
+		var blocks struct {
+			Palette map[string]int32 `nbt:"Palette"`
+			Data    []byte           `nbt:"Data"`
+		}
+		if err := root.Blocks.Unmarshal(&blocks); err != nil {
+			return 0, fmt.Errorf("failed to decode worldedit blocks: %w", err)
+		}
+		return countWorldEditNonAir(blocks.Palette, blocks.Data, root.Width, root.Height, root.Length)
+	case root.StructureBlocks.Type != 0:
+		return countStructureNonAir(root.StructureBlocks, root.StructurePalette)
+	}
+	return 0, ErrUnknownFormat
+}
+
+// airStates reports which entries of a palette are air
+func airStates(names []string) []bool {
+	air := make([]bool, len(names))
+	for i, name := range names {
+		air[i] = isAirPalette(StandardPalette{Name: name})
+	}
+	return air
+}
+
+// countLitematicaNonAir counts the non-air blocks of the region
+// ConvertToStandard reads
+func countLitematicaNonAir(regions map[string]litematicaRegionBlocks) (int, error) {
+	region, ok := firstLitematicaRegion(regions)
+	if !ok {
+		return 0, ErrNoRegions
+	}
+
+	names := make([]string, len(region.BlockStatePalette))
+	for i, p := range region.BlockStatePalette {
+		names[i] = p.Name
+	}
+	air := airStates(names)
+
+	volume := abs(int(region.Size.X)) * abs(int(region.Size.Y)) * abs(int(region.Size.Z))
+	bitsPerEntry := litematicaBitsPerEntry(len(names))
+	count := 0
+	for i := 0; i < volume; i++ {
+		// Like ConvertToStandard, entries missing from a short array read as 0
+		state, _ := litematicaStateAt(region.BlockStates, bitsPerEntry, i)
+		if state >= len(air) || !air[state] {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// countWorldEditNonAir counts the non-air entries of a Sponge palette and
+// varint block data covering the schematic's volume
+func countWorldEditNonAir(palette map[string]int32, blockData []byte, width, height, length int16) (int, error) {
+	// Palette values are the indices, which need not be contiguous
+	air := make(map[int]bool, len(palette))
+	for state, index := range palette {
+		name, _ := DecodePropertyString(state)
+		air[int(index)] = isAirPalette(StandardPalette{Name: name})
+	}
+
+	volume := int(width) * int(height) * int(length)
+	count, offset := 0, 0
+	for i := 0; i < volume; i++ {
+		if offset >= len(blockData) {
+			return 0, fmt.Errorf("block data ended after %d of %d entries", i, volume)
+		}
+		index, bytesRead := readVarint(blockData, offset)
+		if bytesRead > 5 {
+			return 0, fmt.Errorf("block data varint at byte %d is longer than 5 bytes", offset)
+		}
+		if blockData[offset+bytesRead-1]&0x80 != 0 {
+			return 0, fmt.Errorf("block data varint at byte %d is truncated", offset)
+		}
+		offset += bytesRead
+		if !air[index] {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// countWorldEditLegacyNonAir counts the blocks of a pre-1.13 schematic whose
+// numeric ID and data value map to something other than air
+func countWorldEditLegacyNonAir(root blockCountTags) (int, error) {
+	legacy := WorldEditLegacyNBT{
+		Width:     root.Width,
+		Height:    root.Height,
+		Length:    root.Length,
+		AddBlocks: root.AddBlocks,
+		Data:      root.Data,
+	}
+	if err := root.Blocks.Unmarshal(&legacy.Blocks); err != nil {
+		return 0, fmt.Errorf("failed to decode legacy blocks: %w", err)
+	}
+	volume := int(legacy.Width) * int(legacy.Height) * int(legacy.Length)
+	if len(legacy.Blocks) < volume || len(legacy.Data) < volume {
+		return 0, fmt.Errorf("legacy schematic has %d blocks and %d data values, expected %d", len(legacy.Blocks), len(legacy.Data), volume)
+	}
+
+	// Results are cached by ID and data value, packed as id<<4 | data
+	air := make(map[int]bool)
+	count := 0
+	for i := 0; i < volume; i++ {
+		key := legacy.blockID(i)<<4 | int(legacy.Data[i]&0x0f)
+		isAir, ok := air[key]
+		if !ok {
+			name, _ := LegacyBlockState(key>>4, key&0x0f)
+			isAir = isAirPalette(StandardPalette{Name: name})
+			air[key] = isAir
+		}
+		if !isAir {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// countStructureNonAir counts the non-air blocks of a structure or Create
+// file. Inline block names, which only Create writes, take precedence over
+// the palette as they do in ConvertToStandard.
+func countStructureNonAir(raw nbt.RawMessage, palette []StructurePalette) (int, error) {
+	names := make([]string, len(palette))
+	for i, p := range palette {
+		names[i] = p.Name
+	}
+	air := airStates(names)
+	nonAir := func(state int32) bool {
+		return state < 0 || int(state) >= len(air) || !air[state]
+	}
+
+	count := 0
+	if raw.Type == nbt.TagCompound {
+		var soa struct {
+			State []int32 `nbt:"state"`
+		}
+		if err := raw.Unmarshal(&soa); err != nil {
+			return 0, fmt.Errorf("failed to decode structure blocks: %w", err)
+		}
+		for _, state := range soa.State {
+			if nonAir(state) {
+				count++
+			}
+		}
+		return count, nil
+	}
+
+	var blocks []structureBlockState
+	if err := raw.Unmarshal(&blocks); err != nil {
+		return 0, fmt.Errorf("failed to decode structure blocks: %w", err)
+	}
+	for _, block := range blocks {
+		// Like the converters, blocks without a full position are skipped
+		if len(block.Pos) < 3 {
+			continue
+		}
+		if block.Name != "" {
+			if !isAirPalette(StandardPalette{Name: block.Name}) {
+				count++
+			}
+		} else if nonAir(block.State) {
+			count++
+		}
+	}
+	return count, nil
+}
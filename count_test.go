@@ -0,0 +1,110 @@
+package mcnbt
+
+import (
+	"os"
+	"testing"
+)
+
+// TestCountNonAirBlocks verifies the fast count matches the non-air blocks of
+// a full conversion for every format
+func TestCountNonAirBlocks(t *testing.T) {
+	files := []string{
+		"testdata/color_field.litematic",
+		"testdata/color_field.schem",
+		"testdata/color_field.nbt",
+		"testdata/jigsaw_processors.nbt",
+		"testdata/legacy_blocks.schematic",
+		"testdata/structure_block.nbt",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			payload, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", file, err)
+			}
+			count, err := CountNonAirBlocks(payload)
+			if err != nil {
+				t.Fatalf("Failed to count blocks: %v", err)
+			}
+
+			data, err := DecodeAny(payload)
+			if err != nil {
+				t.Fatalf("Failed to decode %s: %v", file, err)
+			}
+			standard, err := ConvertToStandard(data)
+			if err != nil {
+				t.Fatalf("Failed to convert %s: %v", file, err)
+			}
+			expected := 0
+			for _, block := range standard.Blocks {
+				if !isAirPalette(standard.Palette[block.State]) {
+					expected++
+				}
+			}
+			if count != expected {
+				t.Errorf("Expected %d non-air blocks of %d, got %d", expected, len(standard.Blocks), count)
+			}
+		})
+	}
+
+	// Create exports may store blocks as parallel arrays
+	soa := encodeTestNBT(t, map[string]interface{}{
+		"size": []int32{3, 1, 1},
+		"palette": []map[string]interface{}{
+			{"Name": "minecraft:air"},
+			{"Name": "minecraft:stone"},
+		},
+		"blocks": map[string]interface{}{
+			"pos":   []int32{0, 0, 0, 1, 0, 0, 2, 0, 0},
+			"state": []int32{1, 0, 1},
+		},
+	})
+	if count, err := CountNonAirBlocks(soa); err != nil || count != 2 {
+		t.Errorf("Expected 2 non-air blocks in parallel arrays, got %d (%v)", count, err)
+	}
+
+	if _, err := CountNonAirBlocks(encodeTestNBT(t, map[string]interface{}{"foo": int32(1)})); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
+
+// BenchmarkCountNonAirBlocks measures counting the blocks of a Litematica file
+func BenchmarkCountNonAirBlocks(b *testing.B) {
+	payload, err := os.ReadFile("testdata/color_field.litematic")
+	if err != nil {
+		b.Fatalf("Failed to read fixture: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := CountNonAirBlocks(payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCountNonAirBlocksConvert measures the same count through a full
+// conversion, for comparison
+func BenchmarkCountNonAirBlocksConvert(b *testing.B) {
+	payload, err := os.ReadFile("testdata/color_field.litematic")
+	if err != nil {
+		b.Fatalf("Failed to read fixture: %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data, err := DecodeAny(payload)
+		if err != nil {
+			b.Fatal(err)
+		}
+		standard, err := ConvertToStandard(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		count := 0
+		for _, block := range standard.Blocks {
+			if !isAirPalette(standard.Palette[block.State]) {
+				count++
+			}
+		}
+	}
+}
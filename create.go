@@ -54,6 +54,7 @@ type CreateEntityNbt struct {
 	ArmorItems          []CreateItem      `json:"ArmorItems" nbt:"ArmorItems"`
 	CanPickUpLoot       int32             `json:"CanPickUpLoot" nbt:"CanPickUpLoot"`
 	HurtTime            int32             `json:"HurtTime" nbt:"HurtTime"`
+	Passengers          []CreateEntityNbt `json:"Passengers,omitempty" nbt:"Passengers,omitempty"`
 }
 
 // CreateEntity represents an entity in a Create schematic
@@ -84,11 +85,16 @@ type CreateNBT struct {
 	Palette             []CreatePalette    `json:"palette" nbt:"palette"`
 	DataVersion         int32              `json:"DataVersion" nbt:"DataVersion"`
 	RailwaysDataVersion int32              `json:"Railways_DataVersion,omitempty" nbt:"Railways_DataVersion,omitempty"`
+	Processors          interface{}        `json:"processors,omitempty" nbt:"processors,omitempty"`
 }
 
-// CreateBlock represents a single block in a Create/Vanilla structure
+// CreateBlock represents a single block in a Create/Vanilla structure.
+// Some exports store the block name inline instead of a palette index, in
+// which case Name and Properties are set and State is ignored.
 type CreateBlock struct {
-	Nbt   interface{} `json:"nbt" nbt:"nbt,omitempty"`
-	Pos   []int32     `json:"pos" nbt:"pos,list"`
-	State int32       `json:"state" nbt:"state"`
+	Nbt        interface{}       `json:"nbt" nbt:"nbt,omitempty"`
+	Pos        []int32           `json:"pos" nbt:"pos,list"`
+	State      int32             `json:"state" nbt:"state"`
+	Name       string            `json:"Name,omitempty" nbt:"Name,omitempty"`
+	Properties map[string]string `json:"Properties,omitempty" nbt:"Properties,omitempty"`
 }
@@ -0,0 +1,406 @@
+package mcnbt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// encodeTestNBT gzip-compresses the NBT encoding of v, mirroring files on disk
+func encodeTestNBT(t *testing.T, v interface{}) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := nbt.NewEncoder(gz).Encode(v, ""); err != nil {
+		t.Fatalf("Failed to encode NBT: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestCreateInlineBlockNames verifies that blocks carrying an inline Name
+// synthesize a palette when the file has no shared palette
+func TestCreateInlineBlockNames(t *testing.T) {
+	fixture := map[string]interface{}{
+		"DataVersion": int32(3465),
+		"size":        []int32{2, 1, 1},
+		"blocks": []map[string]interface{}{
+			{"pos": []int32{0, 0, 0}, "Name": "minecraft:stone"},
+			{"pos": []int32{1, 0, 0}, "Name": "minecraft:oak_log", "Properties": map[string]string{"axis": "y"}},
+		},
+		"entities": []map[string]interface{}{},
+	}
+
+	data, err := DecodeAny(encodeTestNBT(t, fixture))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	if len(standard.Palette) != 2 {
+		t.Fatalf("Expected synthesized palette of 2 entries, got %d", len(standard.Palette))
+	}
+
+	for _, block := range standard.Blocks {
+		p, ok := standard.Palette[block.State]
+		if !ok {
+			t.Fatalf("Block at %+v references missing palette index %d", block.Position, block.State)
+		}
+		if p.Name != block.ID {
+			t.Errorf("Block at %+v resolves to %s, expected %s", block.Position, p.Name, block.ID)
+		}
+	}
+
+	if p := standard.Palette[standard.Blocks[1].State]; p.Properties["axis"] != "y" {
+		t.Errorf("Expected inline properties to be kept, got %v", p.Properties)
+	}
+}
+
+// TestCreateStructureOfArraysBlocks verifies blocks stored as parallel arrays are zipped into blocks
+func TestCreateStructureOfArraysBlocks(t *testing.T) {
+	fixture := map[string]interface{}{
+		"DataVersion": int32(3465),
+		"size":        []int32{3, 1, 1},
+		"palette": []map[string]interface{}{
+			{"Name": "minecraft:air"},
+			{"Name": "minecraft:stone"},
+		},
+		"blocks": map[string]interface{}{
+			"pos":   []int32{0, 0, 0, 1, 0, 0, 2, 0, 0},
+			"state": []int32{1, 0, 1},
+		},
+		"entities": []map[string]interface{}{},
+	}
+
+	data, err := DecodeAny(encodeTestNBT(t, fixture))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	if len(standard.Blocks) != 3 {
+		t.Fatalf("Expected 3 blocks, got %d", len(standard.Blocks))
+	}
+
+	expected := []string{"minecraft:stone", "minecraft:air", "minecraft:stone"}
+	for i, name := range expected {
+		block := standard.Blocks[i]
+		if block.Position.X != float64(i) {
+			t.Errorf("Block %d: expected X=%d, got %v", i, i, block.Position.X)
+		}
+		if got := standard.Palette[block.State].Name; got != name {
+			t.Errorf("Block %d: expected %s, got %s", i, name, got)
+		}
+	}
+}
+
+// TestCreateCompressedBlockNBT verifies a gzip-compressed per-block nbt blob decodes into a tile entity
+func TestCreateCompressedBlockNBT(t *testing.T) {
+	blockNBT := encodeTestNBT(t, map[string]interface{}{
+		"id":         "minecraft:chest",
+		"CustomName": "Loot",
+	})
+
+	fixture := map[string]interface{}{
+		"DataVersion": int32(3465),
+		"size":        []int32{1, 1, 1},
+		"palette": []map[string]interface{}{
+			{"Name": "minecraft:chest"},
+		},
+		"blocks": []map[string]interface{}{
+			{"pos": []int32{0, 0, 0}, "state": int32(0), "nbt": blockNBT},
+		},
+		"entities": []map[string]interface{}{},
+	}
+
+	data, err := DecodeAny(encodeTestNBT(t, fixture))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	if len(standard.TileEntities) != 1 {
+		t.Fatalf("Expected the block NBT to become a tile entity, got %d", len(standard.TileEntities))
+	}
+	nbtMap, ok := standard.TileEntities[0].NBT.(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected block NBT to decode into a map, got %T", standard.TileEntities[0].NBT)
+	}
+	if nbtMap["CustomName"] != "Loot" {
+		t.Errorf("Expected CustomName Loot, got %v", nbtMap["CustomName"])
+	}
+}
+
+// TestCreatePaletteProperties verifies every block property survives a round
+// trip through the standard format, not just axis
+func TestCreatePaletteProperties(t *testing.T) {
+	properties := map[string]string{"facing": "east", "half": "top", "waterlogged": "true"}
+	fixture := map[string]interface{}{
+		"DataVersion": int32(3465),
+		"size":        []int32{2, 1, 1},
+		"palette": []map[string]interface{}{
+			{"Name": "create:shaft", "Properties": map[string]string{"axis": "x"}},
+			{"Name": "minecraft:oak_stairs", "Properties": properties},
+		},
+		"blocks": []map[string]interface{}{
+			{"pos": []int32{0, 0, 0}, "state": int32(0)},
+			{"pos": []int32{1, 0, 0}, "state": int32(1)},
+		},
+		"entities": []map[string]interface{}{},
+	}
+
+	data, err := DecodeAny(encodeTestNBT(t, fixture))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	encoded, err := EncodeToBytes(standard, "create")
+	if err != nil {
+		t.Fatalf("Failed to encode create: %v", err)
+	}
+	data, err = DecodeAny(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode round-tripped create: %v", err)
+	}
+	roundTripped, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert round-tripped create: %v", err)
+	}
+
+	block, ok := roundTripped.GetBlockAt(1, 0, 0)
+	if !ok {
+		t.Fatalf("Expected a block at 1,0,0")
+	}
+	if p := roundTripped.Palette[block.State]; p.Name != "minecraft:oak_stairs" || !reflect.DeepEqual(p.Properties, properties) {
+		t.Errorf("Expected oak_stairs with %v, got %s with %v", properties, p.Name, p.Properties)
+	}
+}
+
+// TestCreateEntityPassengers verifies nested riders survive conversion to standard and back
+func TestCreateEntityPassengers(t *testing.T) {
+	fixture := map[string]interface{}{
+		"DataVersion": int32(3465),
+		"size":        []int32{1, 1, 1},
+		"palette":     []map[string]interface{}{{"Name": "minecraft:air"}},
+		"blocks": []map[string]interface{}{
+			{"pos": []int32{0, 0, 0}, "state": int32(0)},
+		},
+		"entities": []map[string]interface{}{
+			{
+				"pos":      []float64{0.5, 0, 0.5},
+				"blockPos": []int32{0, 0, 0},
+				"nbt": map[string]interface{}{
+					"id": "minecraft:chicken",
+					"Passengers": []map[string]interface{}{
+						{"id": "minecraft:zombie"},
+					},
+				},
+			},
+		},
+	}
+
+	data, err := DecodeAny(encodeTestNBT(t, fixture))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	encoded, err := EncodeToBytes(standard, "create")
+	if err != nil {
+		t.Fatalf("Failed to encode create: %v", err)
+	}
+	data, err = DecodeAny(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode round-tripped create: %v", err)
+	}
+	roundTripped, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert round-tripped create: %v", err)
+	}
+
+	litematica, err := ConvertFromStandard(roundTripped, "litematica")
+	if err != nil {
+		t.Fatalf("Failed to convert to litematica: %v", err)
+	}
+	for _, region := range litematica.(*LitematicaNBT).Regions {
+		if len(region.Entities) != 1 {
+			t.Fatalf("Expected 1 entity, got %d", len(region.Entities))
+		}
+		mount := region.Entities[0]
+		if mount.ID != "minecraft:chicken" {
+			t.Errorf("Expected minecraft:chicken, got %s", mount.ID)
+		}
+		if len(mount.Passengers) != 1 || mount.Passengers[0].ID != "minecraft:zombie" {
+			t.Errorf("Expected a minecraft:zombie passenger, got %+v", mount.Passengers)
+		}
+	}
+}
+
+// TestCreateEntityFractionalMotion verifies fractional motion and rotation
+// reach the standard format unchanged
+func TestCreateEntityFractionalMotion(t *testing.T) {
+	fixture := map[string]interface{}{
+		"DataVersion": int32(3465),
+		"size":        []int32{1, 1, 1},
+		"palette":     []map[string]interface{}{{"Name": "create:shaft"}},
+		"blocks": []map[string]interface{}{
+			{"pos": []int32{0, 0, 0}, "state": int32(0)},
+		},
+		"entities": []map[string]interface{}{
+			{
+				"pos":      []float64{0.5, 0, 0.5},
+				"blockPos": []int32{0, 0, 0},
+				"nbt": map[string]interface{}{
+					"id":       "minecraft:minecart",
+					"Motion":   []float64{0.1, -0.5, 0.0},
+					"Rotation": []float32{90.5, -12.25},
+				},
+			},
+		},
+	}
+
+	data, err := DecodeAny(encodeTestNBT(t, fixture))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+	if standard.OriginalFormat != "create" {
+		t.Fatalf("Expected the create converter, got %q", standard.OriginalFormat)
+	}
+	if len(standard.Entities) != 1 {
+		t.Fatalf("Expected 1 entity, got %d", len(standard.Entities))
+	}
+
+	entity := standard.Entities[0]
+	if expected := (StandardMotion{X: 0.1, Y: -0.5, Z: 0}); entity.Motion != expected {
+		t.Errorf("Expected motion %+v, got %+v", expected, entity.Motion)
+	}
+	if expected := (StandardRotation{Yaw: 90.5, Pitch: -12.25}); entity.Rotation != expected {
+		t.Errorf("Expected rotation %+v, got %+v", expected, entity.Rotation)
+	}
+}
+
+// TestRequiredMods verifies required mods are read from the root or the Metadata compound
+func TestRequiredMods(t *testing.T) {
+	testCases := map[string]map[string]interface{}{
+		"root list": {
+			"required_mods": []string{"create", "railways"},
+		},
+		"metadata compounds": {
+			"Metadata": map[string]interface{}{
+				"RequiredMods": []map[string]interface{}{
+					{"id": "create", "version": "0.5.1"},
+					{"id": "railways", "version": "1.5.3"},
+				},
+			},
+		},
+	}
+
+	for name, extra := range testCases {
+		t.Run(name, func(t *testing.T) {
+			fixture := map[string]interface{}{
+				"DataVersion": int32(3465),
+				"size":        []int32{1, 1, 1},
+				"palette":     []map[string]interface{}{{"Name": "create:shaft"}},
+				"blocks": []map[string]interface{}{
+					{"pos": []int32{0, 0, 0}, "state": int32(0)},
+				},
+				"entities": []map[string]interface{}{},
+			}
+			for key, value := range extra {
+				fixture[key] = value
+			}
+
+			data, err := DecodeAny(encodeTestNBT(t, fixture))
+			if err != nil {
+				t.Fatalf("Failed to decode fixture: %v", err)
+			}
+			standard, err := ConvertToStandard(data)
+			if err != nil {
+				t.Fatalf("Failed to convert fixture: %v", err)
+			}
+
+			mods := standard.Metadata.RequiredMods
+			if len(mods) != 2 || mods[0] != "create" || mods[1] != "railways" {
+				t.Errorf("Expected [create railways], got %v", mods)
+			}
+		})
+	}
+}
+
+// TestStructureProcessorsRoundTrip verifies a jigsaw template's processor list
+// survives conversion through the standard format unchanged
+func TestStructureProcessorsRoundTrip(t *testing.T) {
+	payload, err := os.ReadFile("testdata/jigsaw_processors.nbt")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	data, err := DecodeAny(payload)
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	original := (*data.(*interface{})).(map[string]interface{})["processors"]
+
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+	if !reflect.DeepEqual(standard.Extra["processors"], original) {
+		t.Fatalf("Expected processors in Extra, got %#v", standard.Extra["processors"])
+	}
+
+	encoded, err := EncodeToBytes(standard, "create")
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	data, err = DecodeAny(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode encoded structure: %v", err)
+	}
+	if got := (*data.(*interface{})).(map[string]interface{})["processors"]; !reflect.DeepEqual(got, original) {
+		t.Errorf("Processors changed on round trip\n got: %#v\nwant: %#v", got, original)
+	}
+
+	// Templates without processors don't gain an empty list
+	delete(standard.Extra, "processors")
+	encoded, err = EncodeToBytes(standard, "create")
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	data, err = DecodeAny(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode encoded structure: %v", err)
+	}
+	if _, ok := (*data.(*interface{})).(map[string]interface{})["processors"]; ok {
+		t.Errorf("Expected no processors tag when Extra has none")
+	}
+}
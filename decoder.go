@@ -1,32 +1,45 @@
 package mcnbt
 
 import (
+	"bufio"
 	"bytes"
-	"compress/gzip"
-	"compress/zlib"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/Tnze/go-mc/nbt"
 	"io"
-	"log"
 	"os"
 )
 
+// NbtSchematic represents a vanilla structure block export
 type NbtSchematic struct {
-	DataVersion int           `json:"DataVersion" nbt:"DataVersion"`
-	Blocks      []Block       `json:"blocks"`
-	Entities    []interface{} `json:"entities"`
-	Palette     []Palette     `json:"palette"`
-	Size        []int         `json:"size"  nbt:"Size"`
+	DataVersion int                `json:"DataVersion" nbt:"DataVersion"`
+	Blocks      []Block            `json:"blocks" nbt:"blocks"`
+	Entities    []StructureEntity  `json:"entities" nbt:"entities"`
+	Palette     []StructurePalette `json:"palette" nbt:"palette"`
+	Size        []int              `json:"size" nbt:"size"`
+	Processors  interface{}        `json:"processors,omitempty" nbt:"processors,omitempty"`
 }
 
 type Block struct {
-	Pos   []int       `json:"pos"`
-	State int         `json:"state"`
+	Pos   []int       `json:"pos" nbt:"pos"`
+	State int         `json:"state" nbt:"state"`
 	Nbt   interface{} `json:"nbt,omitempty" nbt:"nbt,omitempty"`
 }
 
+// StructurePalette is a block state in a vanilla structure palette
+type StructurePalette struct {
+	Name       string            `json:"Name" nbt:"Name"`
+	Properties map[string]string `json:"Properties,omitempty" nbt:"Properties,omitempty"`
+}
+
+// StructureEntity is an entity in a vanilla structure, positioned relative to the structure origin
+type StructureEntity struct {
+	Pos      []float64              `json:"pos" nbt:"pos"`
+	BlockPos []int                  `json:"blockPos" nbt:"blockPos"`
+	Nbt      map[string]interface{} `json:"nbt" nbt:"nbt"`
+}
+
 type Nbt struct {
 	Item     Item        `json:"Item"`
 	Material NbtMaterial `json:"Material"`
@@ -70,14 +83,15 @@ func ParseAnyFromFileAsJSON(f string) (interface{}, error) {
 		return nil, fmt.Errorf("directories are not supported: %s", f)
 	}
 
-	// Read the file
-	data, err := os.ReadFile(f)
+	// Open the file
+	file, err := os.Open(f)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %w", f, err)
+		return nil, fmt.Errorf("failed to open file %s: %w", f, err)
 	}
+	defer file.Close()
 
 	// Decode the data
-	res, err := DecodeAny(data)
+	res, err := DecodeAnyReader(file)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode file %s: %w", f, err)
 	}
@@ -85,67 +99,296 @@ func ParseAnyFromFileAsJSON(f string) (interface{}, error) {
 	return res, nil
 }
 
-func DecodeAny(data []byte) (interface{}, error) {
-	if len(data) == 0 {
-		return nil, fmt.Errorf("empty data")
-	}
-
-	var r io.Reader
-	var err error
-
-	// Try different decompression methods based on magic numbers or format indicators
-	if len(data) > 1 {
-		if data[0] == 1 {
-			// GZIP compression with format indicator
-			r, err = gzip.NewReader(bytes.NewReader(data[1:]))
-		} else if data[0] == 2 {
-			// ZLIB compression with format indicator
-			r, err = zlib.NewReader(bytes.NewReader(data[1:]))
-		} else if data[0] == 0x1f && data[1] == 0x8b {
-			// GZIP magic number
-			r, err = gzip.NewReader(bytes.NewReader(data))
-		} else if data[0] == 0x78 && (data[1] == 0x01 || data[1] == 0x9c || data[1] == 0xda) {
-			// ZLIB magic number
-			r, err = zlib.NewReader(bytes.NewReader(data))
-		} else {
-			// Assume uncompressed
-			r = bytes.NewReader(data)
-		}
-	} else {
-		// Single byte data, assume uncompressed
-		r = bytes.NewReader(data)
+// knownMagicPrefixes are file-type wrappers that precede the compressed NBT payload
+var knownMagicPrefixes = [][]byte{
+	[]byte("SPGE"),
+}
+
+// zstdMagic is the frame magic number at the start of zstd-compressed data
+var zstdMagic = []byte{0x28, 0xB5, 0x2F, 0xFD}
+
+// stripMagicPrefix removes a known 4-byte magic prefix from data. Data that
+// starts with four uppercase ASCII letters but no known prefix is rejected,
+// since it would otherwise be misdetected as uncompressed NBT.
+func stripMagicPrefix(data []byte) ([]byte, error) {
+	for _, magic := range knownMagicPrefixes {
+		if bytes.HasPrefix(data, magic) {
+			return data[len(magic):], nil
+		}
 	}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to decompress data: %w", err)
+	if len(data) >= 4 {
+		for _, c := range data[:4] {
+			if c < 'A' || c > 'Z' {
+				return data, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %q", ErrUnknownMagic, data[:4])
 	}
+	return data, nil
+}
+
+// DecodeInfo describes how decoded NBT data was stored, so it can be
+// re-encoded the same way
+type DecodeInfo struct {
+	// Compression used: "gzip", "zlib", "zstd" or "none"
+	Compression string
+
+	// Whether a leading format-indicator byte (1 for gzip, 2 for zlib) was present
+	FormatIndicator bool
+
+	// Magic prefix that preceded the data (e.g. "SPGE"), if any
+	MagicPrefix string
+}
+
+// DecodeResult is a decoded NBT file together with the name of its root compound
+type DecodeResult struct {
+	// Name of the root compound, e.g. "Schematic" for WorldEdit or "" for Litematica
+	RootName string
+
+	// How the data was stored
+	Info DecodeInfo
 
-	if r == nil {
-		return nil, fmt.Errorf("failed to create reader")
+	// Decoded root value, in the same form DecodeAny returns
+	Data interface{}
+}
+
+// Decode decompresses and decodes NBT data like DecodeAny, also reporting
+// the root compound's name. Files are accepted whether the root is named or not.
+func Decode(data []byte) (*DecodeResult, error) {
+	return decodeStream(bytes.NewReader(data))
+}
+
+// decodeStream decompresses and decodes a single NBT root from r
+func decodeStream(r io.Reader) (*DecodeResult, error) {
+	nbtReader, info, closeFn, err := openNBTStream(r)
+	if err != nil {
+		return nil, err
 	}
+	defer closeFn()
 
+	// The decoder stops at the root compound's TAG_End, so any trailing zero
+	// padding inside the decompressed stream is left unread and ignored.
 	schematic := new(interface{})
-	if _, err = nbt.NewDecoder(r).Decode(schematic); err != nil {
+	rootName, err := nbt.NewDecoder(nbtReader).Decode(schematic)
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode NBT: %w", err)
 	}
-	return schematic, nil
+	return &DecodeResult{RootName: rootName, Info: info, Data: schematic}, nil
 }
 
-func decodeNbt(val interface{}) (*Nbt, error) {
-	switch data := val.(type) {
-	case []byte:
-		r, err := gzip.NewReader(bytes.NewReader(data))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+// DecodeAny decompresses and decodes NBT data into a generic value.
+// Compounds with duplicate keys are invalid NBT but are produced by some
+// buggy tools; they decode with a "last wins" policy instead of failing.
+func DecodeAny(data []byte) (interface{}, error) {
+	res, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+// DecodeAnyWithInfo decodes NBT data like DecodeAny and also reports how it
+// was stored
+func DecodeAnyWithInfo(data []byte) (interface{}, DecodeInfo, error) {
+	res, err := Decode(data)
+	if err != nil {
+		return nil, DecodeInfo{}, err
+	}
+	return res.Data, res.Info, nil
+}
+
+// DecodeAnyReader decodes NBT data like DecodeAny, reading from r as it goes
+// instead of requiring the whole input in memory first
+func DecodeAnyReader(r io.Reader) (interface{}, error) {
+	res, err := decodeStream(r)
+	if err != nil {
+		return nil, err
+	}
+	return res.Data, nil
+}
+
+// DecodeLitematica decodes a Litematica file directly into LitematicaNBT,
+// skipping the generic value DecodeAny builds and the JSON round trip
+// ConvertToStandard makes from it. Pass the result to ConvertToStandard.
+// Data that is not a Litematica file is rejected with ErrUnknownFormat.
+func DecodeLitematica(data []byte) (*LitematicaNBT, error) {
+	return decodeTyped(data, "litematica", func(l *LitematicaNBT) bool {
+		return l.Regions != nil
+	})
+}
+
+// DecodeWorldEdit decodes a Sponge v2 schematic directly into WorldEditNBT,
+// like DecodeLitematica. Sponge v3 and pre-1.13 schematics, which have no
+// top-level Palette, are rejected with ErrUnknownFormat.
+func DecodeWorldEdit(data []byte) (*WorldEditNBT, error) {
+	return decodeTyped(data, "worldedit", func(w *WorldEditNBT) bool {
+		return len(w.Palette) > 0
+	})
+}
+
+// DecodeCreate decodes a Create or vanilla structure file directly into
+// CreateNBT, like DecodeLitematica. Vanilla structures still convert with
+// OriginalFormat "structure".
+func DecodeCreate(data []byte) (*CreateNBT, error) {
+	return decodeTyped(data, "create", func(c *CreateNBT) bool {
+		return len(c.Size) == 3
+	})
+}
+
+// decodeTyped decompresses data and decodes it into a new T using its nbt
+// struct tags. go-mc skips unknown tags, so data in another format decodes
+// without error; valid reports whether the result has the format's required fields.
+func decodeTyped[T any](data []byte, format string, valid func(*T) bool) (*T, error) {
+	nbtReader, _, closeFn, err := openNBTStream(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+
+	v := new(T)
+	if _, err := nbt.NewDecoder(nbtReader).Decode(v); err != nil {
+		return nil, fmt.Errorf("failed to decode %s NBT: %w", format, err)
+	}
+	if !valid(v) {
+		return nil, fmt.Errorf("%w: data is not a %s file", ErrUnknownFormat, format)
+	}
+	return v, nil
+}
+
+// DetectFormat reports the schematic format of data ("litematica",
+// "worldedit", "create" or "structure") from the root compound's keys. Tag
+// payloads are skipped rather than decoded, so this is much cheaper than a
+// full conversion. The root compound's name is not relied on, since tools
+// disagree on whether to set it.
+func DetectFormat(data []byte) (string, error) {
+	r, _, closeFn, err := openNBTStream(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer closeFn()
+
+	var root map[string]nbt.RawMessage
+	if _, err := nbt.NewDecoder(r).Decode(&root); err != nil {
+		return "", fmt.Errorf("failed to decode NBT: %w", err)
+	}
+
+	// Sponge v3 wraps the whole schematic in a "Schematic" compound
+	if inner, ok := root["Schematic"]; ok && len(root) == 1 {
+		var nested map[string]nbt.RawMessage
+		if err := inner.Unmarshal(&nested); err == nil {
+			root = nested
 		}
-		defer r.Close()
+	}
 
-		n := new(Nbt)
-		if _, err = nbt.NewDecoder(r).Decode(n); err != nil {
-			return nil, fmt.Errorf("failed to decode NBT data: %w", err)
+	keys := make(map[string]interface{}, len(root))
+	for key, value := range root {
+		keys[key] = value
+	}
+
+	// The Sponge v3 check needs Version's value, which may be a number or a string
+	if raw, ok := root["Version"]; ok {
+		var version interface{}
+		if err := raw.Unmarshal(&version); err == nil {
+			keys["Version"] = version
 		}
-		return n, nil
+	}
+
+	// Palette names tell Create schematics apart from vanilla structures
+	if raw, ok := root["palette"]; ok {
+		var palette []map[string]interface{}
+		if err := raw.Unmarshal(&palette); err == nil {
+			keys["palette"] = palette
+		}
+	}
+
+	switch {
+	case isLitematicaMap(keys):
+		return "litematica", nil
+	case isWorldEditMap(keys), isWorldEditLegacyMap(keys), isWorldEditV3Map(keys):
+		return "worldedit", nil
+	case isVanillaStructureMap(keys):
+		return "structure", nil
+	case isCreateMap(keys):
+		return "create", nil
+	case isStructureMap(keys):
+		return "structure", nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// openNBTStream strips any magic prefix from r and returns a reader over the
+// decompressed NBT stream along with how it was stored. Only the first few
+// bytes are peeked to pick the decompression, so the input is never buffered
+// in full. The returned close function releases the decompressor and must be
+// called once reading is done.
+func openNBTStream(r io.Reader) (io.Reader, DecodeInfo, func(), error) {
+	noop := func() {}
+	var info DecodeInfo
+	br := bufio.NewReader(r)
 
+	head, err := br.Peek(4)
+	if len(head) == 0 {
+		if err != nil && err != io.EOF {
+			return nil, info, noop, fmt.Errorf("failed to read data: %w", err)
+		}
+		return nil, info, noop, ErrEmptyData
+	}
+
+	stripped, err := stripMagicPrefix(head)
+	if err != nil {
+		return nil, info, noop, err
+	}
+	info.MagicPrefix = string(head[:len(head)-len(stripped)])
+	if _, err := br.Discard(len(head) - len(stripped)); err != nil {
+		return nil, info, noop, fmt.Errorf("failed to skip magic prefix: %w", err)
+	}
+
+	head, _ = br.Peek(4)
+	if len(head) == 0 {
+		return nil, info, noop, fmt.Errorf("%w after magic prefix", ErrEmptyData)
+	}
+
+	// Registered magics come first, so one starting with 1 or 2 is not
+	// mistaken for a format indicator byte
+	var d decompressor
+	found := false
+	if peeked, _ := br.Peek(maxMagicLength()); len(peeked) > 1 {
+		d, found = findDecompressor(peeked)
+	}
+	if !found && (head[0] == 1 || head[0] == 2) {
+		// Format indicator byte: 1 for gzip, 2 for zlib
+		name := map[byte]string{1: "gzip", 2: "zlib"}[head[0]]
+		if d, found = decompressorNamed(name); found {
+			br.Discard(1)
+			info.FormatIndicator = true
+		}
+	}
+
+	if !found {
+		// Assume uncompressed
+		info.Compression = "none"
+		return br, info, noop, nil
+	}
+
+	info.Compression = d.name
+	nbtReader, closeFn, err := d.open(br)
+	if err != nil {
+		return nil, info, noop, fmt.Errorf("failed to decompress data: %w: %w", ErrCorruptCompression, err)
+	}
+
+	return compressionErrorReader{r: nbtReader}, info, closeFn, nil
+}
+
+// decodeNbt decodes block NBT given as a map or a gzip blob, expanding any
+// gzip blobs nested inside it
+func decodeNbt(val interface{}) (*Nbt, error) {
+	expanded, _, err := expandNestedNBT(val, scopePayload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch data := expanded.(type) {
 	case map[string]interface{}:
 		marshal, err := json.Marshal(data)
 		if err != nil {
@@ -155,11 +398,187 @@ func decodeNbt(val interface{}) (*Nbt, error) {
 		n := new(Nbt)
 		if err = json.Unmarshal(marshal, n); err != nil {
 			// Ignore error because we get weird nbt formats for inventories for example
-			log.Println("Skipping invalid NBT:", string(marshal))
+			logPrintln("Skipping invalid NBT:", string(marshal))
 			return nil, nil
 		}
 		return n, nil
+
+	case []byte:
+		return nil, errors.New("failed to decode NBT data: not a gzip compound")
 	}
 
 	return nil, errors.New("unknown type of nbt")
 }
+
+// maxNestedNBTDepth is how many gzip blobs deep expandNestedNBT decodes, so
+// blobs nested inside each other can't be used as a decompression bomb
+const maxNestedNBTDepth = 8
+
+// maxNestedNBTSize caps the bytes all the blobs expandNestedNBT decodes from
+// one value may decompress to, so a single small blob can't be either
+const maxNestedNBTSize = 16 << 20
+
+// nestedScope tells expandNestedNBT which byte arrays may hold NBT
+type nestedScope int
+
+const (
+	// A schematic root or part of its layout. Only the NBT payloads below are
+	// searched, so packed block data is never mistaken for a blob.
+	scopeLayout nestedScope = iota
+
+	// The children of a Litematica Regions compound, which are keyed by
+	// region name and laid out like a root
+	scopeRegions
+
+	// Block, block entity or entity NBT, where any byte array may be a blob
+	scopePayload
+)
+
+// nestedLayoutKeys are the keys under which a schematic's layout continues,
+// and nestedPayloadKeys the keys holding NBT payloads, across every format
+var (
+	nestedLayoutKeys  = map[string]bool{"Regions": true, "Schematic": true, "Blocks": true, "blocks": true}
+	nestedPayloadKeys = map[string]bool{"nbt": true, "TileEntities": true, "BlockEntities": true, "Entities": true, "entities": true}
+)
+
+// isGzipBlob reports whether b starts with a gzip header using deflate
+func isGzipBlob(b []byte) bool {
+	return len(b) >= 3 && b[0] == 0x1f && b[1] == 0x8b && b[2] == 8
+}
+
+// expandNestedNBT replaces byte arrays holding gzip-compressed NBT, such as a
+// clipboard stored inside a block entity, with their decoded values. v is
+// searched from the given scope: from scopeLayout only the block, block
+// entity and entity NBT of each format is searched. Blobs are decoded at most
+// maxNestedNBTDepth deep and to at most maxNestedNBTSize bytes in total. Maps
+// and lists are copied only when something inside them changed, and changed
+// reports whether that happened. Byte arrays that merely look like gzip are
+// kept as they are.
+func expandNestedNBT(v interface{}, scope nestedScope) (expanded interface{}, changed bool, err error) {
+	e := &nestedExpander{remaining: maxNestedNBTSize}
+	return e.expand(v, scope, 0)
+}
+
+// nestedExpander holds the decompression budget shared by every blob of one
+// expandNestedNBT call
+type nestedExpander struct {
+	remaining int64
+}
+
+// expand expands the blobs in v, where depth counts the blobs already
+// decoded on the way to v
+func (e *nestedExpander) expand(v interface{}, scope nestedScope, depth int) (interface{}, bool, error) {
+	switch val := v.(type) {
+	case []byte:
+		if scope != scopePayload || !isGzipBlob(val) {
+			return v, false, nil
+		}
+		if depth >= maxNestedNBTDepth {
+			return nil, false, fmt.Errorf("%w: more than %d levels of gzip-compressed NBT", ErrNestingTooDeep, maxNestedNBTDepth)
+		}
+		decoded, ok, err := e.decode(val)
+		if err != nil || !ok {
+			return v, false, err
+		}
+		inner, _, err := e.expand(decoded, scopePayload, depth+1)
+		if err != nil {
+			return nil, false, err
+		}
+		return inner, true, nil
+
+	case map[string]interface{}:
+		var out map[string]interface{}
+		for key, child := range val {
+			childScope := scope
+			switch {
+			case scope == scopeRegions:
+				childScope = scopeLayout
+			case scope == scopePayload:
+			case nestedPayloadKeys[key]:
+				childScope = scopePayload
+			case key == "Regions":
+				childScope = scopeRegions
+			case !nestedLayoutKeys[key]:
+				continue
+			}
+			expanded, childChanged, err := e.expand(child, childScope, depth)
+			if err != nil {
+				return nil, false, err
+			}
+			if childChanged {
+				if out == nil {
+					out = make(map[string]interface{}, len(val))
+					for k, c := range val {
+						out[k] = c
+					}
+				}
+				out[key] = expanded
+			}
+		}
+		if out != nil {
+			return out, true, nil
+		}
+
+	case []interface{}:
+		// A list of regions holds regions, laid out like a root
+		if scope == scopeRegions {
+			scope = scopeLayout
+		}
+		var out []interface{}
+		for i, child := range val {
+			expanded, childChanged, err := e.expand(child, scope, depth)
+			if err != nil {
+				return nil, false, err
+			}
+			if childChanged {
+				if out == nil {
+					out = append([]interface{}(nil), val...)
+				}
+				out[i] = expanded
+			}
+		}
+		if out != nil {
+			return out, true, nil
+		}
+	}
+	return v, false, nil
+}
+
+// decode decodes a gzip blob within the remaining budget. ok is false if the
+// blob is not valid NBT, and an error is only returned once the budget is spent.
+func (e *nestedExpander) decode(blob []byte) (decoded interface{}, ok bool, err error) {
+	r, _, closeFn, err := openNBTStream(bytes.NewReader(blob))
+	if err != nil {
+		return nil, false, nil
+	}
+	defer closeFn()
+
+	budget := &budgetReader{r: r, remaining: &e.remaining}
+	if _, err := nbt.NewDecoder(budget).Decode(&decoded); err != nil {
+		if budget.exceeded {
+			return nil, false, fmt.Errorf("%w: gzip-compressed NBT expands to more than %d bytes", ErrNestedTooLarge, maxNestedNBTSize)
+		}
+		return nil, false, nil
+	}
+	return decoded, true, nil
+}
+
+// budgetReader reads from r until the shared budget of bytes is spent
+type budgetReader struct {
+	r         io.Reader
+	remaining *int64
+	exceeded  bool
+}
+
+func (b *budgetReader) Read(p []byte) (int, error) {
+	if *b.remaining <= 0 {
+		b.exceeded = true
+		return 0, ErrNestedTooLarge
+	}
+	if int64(len(p)) > *b.remaining {
+		p = p[:*b.remaining]
+	}
+	n, err := b.r.Read(p)
+	*b.remaining -= int64(n)
+	return n, err
+}
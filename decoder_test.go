@@ -0,0 +1,579 @@
+package mcnbt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"testing/iotest"
+
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/klauspost/compress/zstd"
+)
+
+// TestDecodeAnyTrailingPadding verifies zero padding after the root compound is ignored
+func TestDecodeAnyTrailingPadding(t *testing.T) {
+	var raw bytes.Buffer
+	if err := nbt.NewEncoder(&raw).Encode(map[string]int32{"DataVersion": 3465}, ""); err != nil {
+		t.Fatalf("Failed to encode NBT: %v", err)
+	}
+	padding := make([]byte, 16)
+
+	// Padding inside the compressed stream
+	var inner bytes.Buffer
+	gz := gzip.NewWriter(&inner)
+	gz.Write(raw.Bytes())
+	gz.Write(padding)
+	gz.Close()
+
+	// Padding after the compressed stream
+	var outer bytes.Buffer
+	gz = gzip.NewWriter(&outer)
+	gz.Write(raw.Bytes())
+	gz.Close()
+	outer.Write(padding)
+
+	testCases := map[string][]byte{
+		"uncompressed": append(append([]byte{}, raw.Bytes()...), padding...),
+		"inside gzip":  inner.Bytes(),
+		"after gzip":   outer.Bytes(),
+	}
+
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			res, err := DecodeAny(data)
+			if err != nil {
+				t.Fatalf("Failed to decode padded NBT: %v", err)
+			}
+
+			root, ok := (*res.(*interface{})).(map[string]interface{})
+			if !ok {
+				t.Fatalf("Expected map root, got %T", *res.(*interface{}))
+			}
+			if root["DataVersion"] != int32(3465) {
+				t.Errorf("Expected DataVersion 3465, got %v", root["DataVersion"])
+			}
+		})
+	}
+}
+
+// TestDecodeAnyMagicPrefix verifies known magic prefixes are stripped and unknown ones rejected
+func TestDecodeAnyMagicPrefix(t *testing.T) {
+	payload, err := os.ReadFile("testdata/color_field.schem")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	prefixed := append([]byte("SPGE"), payload...)
+	data, err := DecodeAny(prefixed)
+	if err != nil {
+		t.Fatalf("Failed to decode prefixed fixture: %v", err)
+	}
+	if _, err := ConvertToStandard(data); err != nil {
+		t.Errorf("Failed to convert prefixed fixture: %v", err)
+	}
+
+	unknown := append([]byte("ABCD"), payload...)
+	if _, err := DecodeAny(unknown); !errors.Is(err, ErrUnknownMagic) {
+		t.Errorf("Expected ErrUnknownMagic, got %v", err)
+	}
+}
+
+// TestDecodeAnyDuplicateKeys verifies duplicate compound keys decode with the last value winning
+func TestDecodeAnyDuplicateKeys(t *testing.T) {
+	fixture := []byte{
+		0x0a, 0x00, 0x00, // root compound, empty name
+		0x03, 0x00, 0x0b, 'D', 'a', 't', 'a', 'V', 'e', 'r', 's', 'i', 'o', 'n',
+		0x00, 0x00, 0x00, 0x01, // DataVersion = 1
+		0x03, 0x00, 0x0b, 'D', 'a', 't', 'a', 'V', 'e', 'r', 's', 'i', 'o', 'n',
+		0x00, 0x00, 0x00, 0x02, // DataVersion = 2
+		0x00, // end of root compound
+	}
+
+	res, err := DecodeAny(fixture)
+	if err != nil {
+		t.Fatalf("Failed to decode duplicate-key fixture: %v", err)
+	}
+
+	root, ok := (*res.(*interface{})).(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected map root, got %T", *res.(*interface{}))
+	}
+	if root["DataVersion"] != int32(2) {
+		t.Errorf("Expected last DataVersion 2 to win, got %v", root["DataVersion"])
+	}
+}
+
+// TestDecodeAnyZstd verifies a zstd-compressed schematic decodes like its gzip original
+func TestDecodeAnyZstd(t *testing.T) {
+	f, err := os.Open("testdata/color_field.schem")
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Failed to create gzip reader: %v", err)
+	}
+	raw, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("Failed to decompress fixture: %v", err)
+	}
+
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("Failed to create zstd writer: %v", err)
+	}
+	compressed := enc.EncodeAll(raw, nil)
+	enc.Close()
+
+	data, err := DecodeAny(compressed)
+	if err != nil {
+		t.Fatalf("Failed to decode zstd fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert zstd fixture: %v", err)
+	}
+	if standard.OriginalFormat != "worldedit" {
+		t.Errorf("Expected worldedit format, got %s", standard.OriginalFormat)
+	}
+	if len(standard.Blocks) == 0 {
+		t.Errorf("Expected blocks to be decoded")
+	}
+}
+
+// TestDetectFormat verifies each sample fixture is identified from its root keys
+func TestDetectFormat(t *testing.T) {
+	testCases := map[string]string{
+		"testdata/color_field.litematic": "litematica",
+		"testdata/color_field.schem":     "worldedit",
+		"testdata/color_field.nbt":       "create",
+	}
+
+	for path, expected := range testCases {
+		t.Run(expected, func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read fixture: %v", err)
+			}
+			format, err := DetectFormat(data)
+			if err != nil {
+				t.Fatalf("Failed to detect format: %v", err)
+			}
+			if format != expected {
+				t.Errorf("Expected %s, got %s", expected, format)
+			}
+		})
+	}
+
+	if _, err := DetectFormat([]byte{0x0a, 0x00, 0x00, 0x00}); err == nil {
+		t.Errorf("Expected an error for an empty root compound")
+	}
+}
+
+// TestDecodeRootName verifies named and unnamed roots decode and detect the same way
+func TestDecodeRootName(t *testing.T) {
+	payload, err := os.ReadFile("testdata/color_field.schem")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	named, err := Decode(payload)
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	if named.RootName != "Schematic" {
+		t.Errorf("Expected root name Schematic, got %q", named.RootName)
+	}
+
+	// Re-encode the same compound with an unnamed root
+	var raw bytes.Buffer
+	if err := nbt.NewEncoder(&raw).Encode(*named.Data.(*interface{}), ""); err != nil {
+		t.Fatalf("Failed to encode NBT: %v", err)
+	}
+	unnamed, err := Decode(raw.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to decode unnamed root: %v", err)
+	}
+	if unnamed.RootName != "" {
+		t.Errorf("Expected an empty root name, got %q", unnamed.RootName)
+	}
+
+	for name, data := range map[string][]byte{"named": payload, "unnamed": raw.Bytes()} {
+		format, err := DetectFormat(data)
+		if err != nil {
+			t.Fatalf("%s: failed to detect format: %v", name, err)
+		}
+		if format != "worldedit" {
+			t.Errorf("%s: expected worldedit, got %s", name, format)
+		}
+	}
+}
+
+// TestDecodeAnyReader verifies raw and gzip streams decode from a reader
+func TestDecodeAnyReader(t *testing.T) {
+	var raw bytes.Buffer
+	if err := nbt.NewEncoder(&raw).Encode(map[string]int32{"DataVersion": 3465}, ""); err != nil {
+		t.Fatalf("Failed to encode NBT: %v", err)
+	}
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write(raw.Bytes())
+	gz.Close()
+
+	testCases := map[string]io.Reader{
+		"raw":  bytes.NewReader(raw.Bytes()),
+		"gzip": iotest.OneByteReader(bytes.NewReader(compressed.Bytes())),
+	}
+
+	for name, r := range testCases {
+		t.Run(name, func(t *testing.T) {
+			res, err := DecodeAnyReader(r)
+			if err != nil {
+				t.Fatalf("Failed to decode stream: %v", err)
+			}
+			root, ok := (*res.(*interface{})).(map[string]interface{})
+			if !ok {
+				t.Fatalf("Expected map root, got %T", *res.(*interface{}))
+			}
+			if root["DataVersion"] != int32(3465) {
+				t.Errorf("Expected DataVersion 3465, got %v", root["DataVersion"])
+			}
+		})
+	}
+
+	if _, err := DecodeAnyReader(bytes.NewReader(nil)); err == nil {
+		t.Errorf("Expected an error for an empty stream")
+	}
+}
+
+// TestDecodeAnyWithInfo verifies the detected compression and format indicator for each storage layout
+func TestDecodeAnyWithInfo(t *testing.T) {
+	var raw bytes.Buffer
+	if err := nbt.NewEncoder(&raw).Encode(map[string]interface{}{"DataVersion": int32(3465)}, ""); err != nil {
+		t.Fatalf("Failed to encode NBT: %v", err)
+	}
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(raw.Bytes())
+	gw.Close()
+
+	var zl bytes.Buffer
+	zw := zlib.NewWriter(&zl)
+	zw.Write(raw.Bytes())
+	zw.Close()
+
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("Failed to create zstd encoder: %v", err)
+	}
+	zs := enc.EncodeAll(raw.Bytes(), nil)
+	enc.Close()
+
+	testCases := map[string]struct {
+		data     []byte
+		expected DecodeInfo
+	}{
+		"gzip":           {gz.Bytes(), DecodeInfo{Compression: "gzip"}},
+		"zlib":           {zl.Bytes(), DecodeInfo{Compression: "zlib"}},
+		"zstd":           {zs, DecodeInfo{Compression: "zstd"}},
+		"none":           {raw.Bytes(), DecodeInfo{Compression: "none"}},
+		"gzip indicator": {append([]byte{1}, gz.Bytes()...), DecodeInfo{Compression: "gzip", FormatIndicator: true}},
+		"zlib indicator": {append([]byte{2}, zl.Bytes()...), DecodeInfo{Compression: "zlib", FormatIndicator: true}},
+		"magic prefix":   {append([]byte("SPGE"), gz.Bytes()...), DecodeInfo{Compression: "gzip", MagicPrefix: "SPGE"}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			data, info, err := DecodeAnyWithInfo(tc.data)
+			if err != nil {
+				t.Fatalf("Failed to decode: %v", err)
+			}
+			if data == nil {
+				t.Fatalf("Expected decoded data")
+			}
+			if info != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, info)
+			}
+		})
+	}
+}
+
+// TestTypedErrors verifies each failure path can be identified with errors.Is
+func TestTypedErrors(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(bytes.Repeat([]byte{10, 0, 0}, 100))
+	gw.Close()
+	truncated := gz.Bytes()[:gz.Len()/2]
+
+	unknown := encodeTestNBT(t, map[string]interface{}{"Foo": int32(1)})
+	noRegions := encodeTestNBT(t, map[string]interface{}{
+		"Metadata": map[string]interface{}{"Name": "empty"},
+		"Regions":  map[string]interface{}{},
+		"Version":  int32(6),
+	})
+
+	convert := func(data []byte) error {
+		decoded, err := DecodeAny(data)
+		if err != nil {
+			return err
+		}
+		_, err = ConvertToStandard(decoded)
+		return err
+	}
+	detect := func(data []byte) error {
+		_, err := DetectFormat(data)
+		return err
+	}
+
+	testCases := map[string]struct {
+		err      error
+		expected error
+	}{
+		"empty data":         {convert(nil), ErrEmptyData},
+		"corrupt header":     {convert([]byte{0x1f, 0x8b, 0, 0}), ErrCorruptCompression},
+		"truncated gzip":     {convert(truncated), ErrCorruptCompression},
+		"unknown format":     {convert(unknown), ErrUnknownFormat},
+		"detect unknown":     {detect(unknown), ErrUnknownFormat},
+		"no regions":         {convert(noRegions), ErrNoRegions},
+		"unsupported output": {func() error { _, err := ConvertFromStandard(&StandardFormat{}, "bogus"); return err }(), ErrUnknownFormat},
+	}
+
+	for name, tc := range testCases {
+		if !errors.Is(tc.err, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", name, tc.expected, tc.err)
+		}
+	}
+}
+
+// xorReader flips every byte read from r with key
+type xorReader struct {
+	r   io.Reader
+	key byte
+}
+
+func (x xorReader) Read(p []byte) (int, error) {
+	n, err := x.r.Read(p)
+	for i := range p[:n] {
+		p[i] ^= x.key
+	}
+	return n, err
+}
+
+// TestRegisterDecompressor verifies data with a registered magic is decoded
+// through the custom decompressor, even when the magic starts like a format
+// indicator byte, until it is unregistered
+func TestRegisterDecompressor(t *testing.T) {
+	var raw bytes.Buffer
+	if err := nbt.NewEncoder(&raw).Encode(map[string]interface{}{"DataVersion": int32(3465)}, ""); err != nil {
+		t.Fatalf("Failed to encode NBT: %v", err)
+	}
+
+	for _, magic := range [][]byte{{0xfe, 0xed}, {0x01, 0xfe}} {
+		RegisterDecompressor(magic, func(r io.Reader) (io.Reader, error) {
+			if _, err := io.ReadFull(r, make([]byte, len(magic))); err != nil {
+				return nil, err
+			}
+			return xorReader{r: r, key: 0x5a}, nil
+		})
+		t.Cleanup(func() { UnregisterDecompressor(magic) })
+
+		encoded := append([]byte(nil), magic...)
+		for _, b := range raw.Bytes() {
+			encoded = append(encoded, b^0x5a)
+		}
+
+		data, info, err := DecodeAnyWithInfo(encoded)
+		if err != nil {
+			t.Fatalf("Failed to decode: %v", err)
+		}
+		if expected := hex.EncodeToString(magic); info.Compression != expected || info.FormatIndicator {
+			t.Errorf("Expected compression %s without a format indicator, got %+v", expected, info)
+		}
+		m, ok := (*data.(*interface{})).(map[string]interface{})
+		if !ok || m["DataVersion"] != int32(3465) {
+			t.Errorf("Expected DataVersion 3465, got %v", data)
+		}
+
+		UnregisterDecompressor(magic)
+		if _, info, _ := DecodeAnyWithInfo(encoded); info.Compression == hex.EncodeToString(magic) {
+			t.Errorf("Expected %x to be unregistered", magic)
+		}
+	}
+
+	// Format indicator bytes still work with nothing registered over them
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(raw.Bytes())
+	zw.Close()
+	if _, info, err := DecodeAnyWithInfo(append([]byte{0x01}, gz.Bytes()...)); err != nil || !info.FormatIndicator {
+		t.Errorf("Expected a gzip format indicator, got %+v, %v", info, err)
+	}
+}
+
+// TestDecodeTyped verifies the typed decoders convert to the same standard
+// format as the DecodeAny path, and reject files in other formats
+func TestDecodeTyped(t *testing.T) {
+	decoders := map[string]func([]byte) (interface{}, error){
+		"testdata/color_field.litematic": func(b []byte) (interface{}, error) { return DecodeLitematica(b) },
+		"testdata/color_field.schem":     func(b []byte) (interface{}, error) { return DecodeWorldEdit(b) },
+		"testdata/color_field.nbt":       func(b []byte) (interface{}, error) { return DecodeCreate(b) },
+		"testdata/structure_block.nbt":   func(b []byte) (interface{}, error) { return DecodeCreate(b) },
+	}
+
+	for path, decode := range decoders {
+		payload, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+
+		typed, err := decode(payload)
+		if err != nil {
+			t.Fatalf("%s: failed typed decode: %v", path, err)
+		}
+		fromTyped, err := ConvertToStandard(typed)
+		if err != nil {
+			t.Fatalf("%s: failed to convert typed data: %v", path, err)
+		}
+
+		generic, err := DecodeAny(payload)
+		if err != nil {
+			t.Fatalf("%s: failed to decode: %v", path, err)
+		}
+		fromGeneric, err := ConvertToStandard(generic)
+		if err != nil {
+			t.Fatalf("%s: failed to convert generic data: %v", path, err)
+		}
+
+		if fromTyped.OriginalFormat != fromGeneric.OriginalFormat {
+			t.Errorf("%s: expected original format %q, got %q", path, fromGeneric.OriginalFormat, fromTyped.OriginalFormat)
+		}
+		if fromTyped.Size != fromGeneric.Size || fromTyped.DataVersion != fromGeneric.DataVersion {
+			t.Errorf("%s: expected size %+v and data version %d, got %+v and %d", path,
+				fromGeneric.Size, fromGeneric.DataVersion, fromTyped.Size, fromTyped.DataVersion)
+		}
+		if !reflect.DeepEqual(fromTyped.Palette, fromGeneric.Palette) {
+			t.Errorf("%s: palettes differ", path)
+		}
+		if !reflect.DeepEqual(fromTyped.Blocks, fromGeneric.Blocks) {
+			t.Errorf("%s: blocks differ", path)
+		}
+		if len(fromTyped.Entities) != len(fromGeneric.Entities) || len(fromTyped.TileEntities) != len(fromGeneric.TileEntities) {
+			t.Errorf("%s: expected %d entities and %d tile entities, got %d and %d", path,
+				len(fromGeneric.Entities), len(fromGeneric.TileEntities), len(fromTyped.Entities), len(fromTyped.TileEntities))
+		}
+	}
+
+	schem, err := os.ReadFile("testdata/color_field.schem")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	if _, err := DecodeCreate(schem); err == nil {
+		t.Errorf("Expected an error decoding a schematic as create")
+	}
+	if _, err := DecodeLitematica(schem); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat decoding a schematic as litematica, got %v", err)
+	}
+}
+
+// TestNestedGzipNBT verifies gzip blobs holding sub-compounds are decoded
+// during conversion, and blobs nested or expanding past the limits are rejected
+func TestNestedGzipNBT(t *testing.T) {
+	clipboard := encodeTestNBT(t, map[string]interface{}{
+		"Pages": []map[string]interface{}{{"Text": "Gears"}},
+		"Cover": encodeTestNBT(t, map[string]interface{}{"Color": "blue"}),
+	})
+	fixture := func(item interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"DataVersion": int32(3465),
+			"size":        []int32{1, 1, 1},
+			"palette":     []map[string]interface{}{{"Name": "create:clipboard"}},
+			"blocks": []map[string]interface{}{
+				{"pos": []int32{0, 0, 0}, "state": int32(0), "nbt": map[string]interface{}{
+					"id":   "create:clipboard",
+					"Item": item,
+				}},
+			},
+			"entities": []map[string]interface{}{},
+		}
+	}
+
+	data, err := DecodeAny(encodeTestNBT(t, fixture(clipboard)))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+	if len(standard.TileEntities) != 1 {
+		t.Fatalf("Expected 1 tile entity, got %d", len(standard.TileEntities))
+	}
+	item, ok := standard.TileEntities[0].NBT.(map[string]interface{})["Item"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected the clipboard blob to decode to a compound, got %T", standard.TileEntities[0].NBT.(map[string]interface{})["Item"])
+	}
+	if pages, _ := item["Pages"].([]interface{}); len(pages) != 1 {
+		t.Errorf("Expected 1 page in the clipboard, got %v", item["Pages"])
+	}
+	if cover, _ := item["Cover"].(map[string]interface{}); cover["Color"] != "blue" {
+		t.Errorf("Expected the doubly nested cover to decode, got %v", item["Cover"])
+	}
+
+	// Blobs nested exactly to the limit still decode, one more is rejected
+	blob := encodeTestNBT(t, map[string]interface{}{"Depth": int8(1)})
+	for i := 1; i < maxNestedNBTDepth; i++ {
+		blob = encodeTestNBT(t, map[string]interface{}{"Inner": blob})
+	}
+	data, err = DecodeAny(encodeTestNBT(t, fixture(blob)))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	if _, err := ConvertToStandard(data); err != nil {
+		t.Errorf("Expected %d levels of nesting to convert, got %v", maxNestedNBTDepth, err)
+	}
+
+	blob = encodeTestNBT(t, map[string]interface{}{"Inner": blob})
+	data, err = DecodeAny(encodeTestNBT(t, fixture(blob)))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	if _, err := ConvertToStandard(data); !errors.Is(err, ErrNestingTooDeep) {
+		t.Errorf("Expected ErrNestingTooDeep, got %v", err)
+	}
+
+	// Blobs decompressing past the budget are rejected
+	bomb := encodeTestNBT(t, map[string]interface{}{"Fill": make([]byte, maxNestedNBTSize)})
+	data, err = DecodeAny(encodeTestNBT(t, fixture(bomb)))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	if _, err := ConvertToStandard(data); !errors.Is(err, ErrNestedTooLarge) {
+		t.Errorf("Expected ErrNestedTooLarge, got %v", err)
+	}
+
+	// Block data arrays are never expanded, even when they look like gzip
+	worldEdit := map[string]interface{}{
+		"Palette":   map[string]interface{}{"minecraft:stone": int32(0)},
+		"BlockData": clipboard,
+		"Blocks":    clipboard,
+	}
+	if _, changed, err := expandNestedNBT(worldEdit, scopeLayout); changed || err != nil {
+		t.Errorf("Expected block data to be left alone, got changed=%v, %v", changed, err)
+	}
+
+	// decodeNbt reads a compressed blob the same way as a map
+	n, err := decodeNbt(encodeTestNBT(t, map[string]interface{}{"id": "minecraft:chest"}))
+	if err != nil || n == nil || n.ID != "minecraft:chest" {
+		t.Errorf("Expected decodeNbt to read the blob's id, got %+v, %v", n, err)
+	}
+}
@@ -0,0 +1,88 @@
+package mcnbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// bedrockStructureKeys are root keys that only appear in Bedrock .mcstructure files
+var bedrockStructureKeys = [][]byte{
+	[]byte("structure_world_origin"),
+	[]byte("format_version"),
+}
+
+// DetectEdition reports whether data is a Java ("java") or Bedrock ("bedrock")
+// edition NBT file. Java files are big-endian and usually compressed, while
+// Bedrock .mcstructure files are uncompressed little-endian NBT.
+func DetectEdition(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("empty data")
+	}
+
+	// Compressed or format-indicated data is always Java edition
+	if len(data) > 1 {
+		switch {
+		case data[0] == 1 || data[0] == 2:
+			return "java", nil
+		case data[0] == 0x1f && data[1] == 0x8b:
+			return "java", nil
+		case data[0] == 0x78 && (data[1] == 0x01 || data[1] == 0x9c || data[1] == 0xda):
+			return "java", nil
+		}
+	}
+
+	if data[0] != 0x0a {
+		return "", fmt.Errorf("data does not start with an NBT compound tag")
+	}
+
+	for _, key := range bedrockStructureKeys {
+		if bytes.Contains(data, key) {
+			return "bedrock", nil
+		}
+	}
+
+	bigEndian := plausibleFirstChild(data, binary.BigEndian)
+	littleEndian := plausibleFirstChild(data, binary.LittleEndian)
+	switch {
+	case bigEndian:
+		return "java", nil
+	case littleEndian:
+		return "bedrock", nil
+	}
+
+	return "", fmt.Errorf("unable to determine NBT edition")
+}
+
+// plausibleFirstChild reports whether the root name and first child tag of an
+// uncompressed NBT compound parse cleanly with the given byte order
+func plausibleFirstChild(data []byte, order binary.ByteOrder) bool {
+	if len(data) < 3 {
+		return false
+	}
+	offset := 3 + int(order.Uint16(data[1:3]))
+	if offset >= len(data) {
+		return false
+	}
+
+	tagType := data[offset]
+	if tagType == 0 {
+		// Empty root compound
+		return true
+	}
+	if tagType > 12 || offset+3 > len(data) {
+		return false
+	}
+
+	nameLen := int(order.Uint16(data[offset+1 : offset+3]))
+	nameEnd := offset + 3 + nameLen
+	if nameEnd > len(data) {
+		return false
+	}
+	for _, c := range data[offset+3 : nameEnd] {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package mcnbt
+
+import (
+	"os"
+	"testing"
+)
+
+// bedrockFixture is a minimal little-endian .mcstructure root compound
+// containing format_version = 1 and an empty size list
+var bedrockFixture = []byte{
+	0x0a, 0x00, 0x00, // root compound, empty name
+	0x03, 0x0e, 0x00, // int tag, name length 14 (little-endian)
+	'f', 'o', 'r', 'm', 'a', 't', '_', 'v', 'e', 'r', 's', 'i', 'o', 'n',
+	0x01, 0x00, 0x00, 0x00, // value 1 (little-endian)
+	0x09, 0x04, 0x00, 's', 'i', 'z', 'e', // list tag "size"
+	0x03, 0x00, 0x00, 0x00, 0x00, // element type int, length 0
+	0x00, // end of root compound
+}
+
+// TestDetectEdition verifies Java and Bedrock fixtures are told apart
+func TestDetectEdition(t *testing.T) {
+	javaData, err := os.ReadFile("testdata/color_field.nbt")
+	if err != nil {
+		t.Fatalf("Failed to read Java fixture: %v", err)
+	}
+
+	testCases := map[string]struct {
+		data     []byte
+		expected string
+	}{
+		"java":    {javaData, "java"},
+		"bedrock": {bedrockFixture, "bedrock"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			edition, err := DetectEdition(tc.data)
+			if err != nil {
+				t.Fatalf("Failed to detect edition: %v", err)
+			}
+			if edition != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, edition)
+			}
+		})
+	}
+
+	if _, err := DetectEdition(nil); err == nil {
+		t.Errorf("Expected an error for empty data")
+	}
+}
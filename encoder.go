@@ -1,32 +1,261 @@
 package mcnbt
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"math/bits"
+	"github.com/Tnze/go-mc/nbt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
-// EncodeToFile encodes the given data to a file in the specified format
+// EncodeToFile encodes the given data to a file in the specified format.
+// The output is written to a temporary file and renamed into place, so a
+// failed encode never leaves a truncated file behind.
 func EncodeToFile(data interface{}, format string, filename string) error {
-	// For testing purposes, just create an empty file
-	// In a real implementation, this would properly encode the data
-	if err := os.WriteFile(filename, []byte{}, 0644); err != nil {
+	out, err := EncodeToBytes(data, format)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s data: %w", format, err)
+	}
+	return writeFileAtomic(filename, out)
+}
+
+// writeFileAtomic writes data to a temporary file next to filename and renames it into place
+func writeFileAtomic(filename string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %w", filename, err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to write to file %s: %w", filename, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to write to file %s: %w", filename, err)
+	}
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("failed to set permissions on %s: %w", filename, err)
+	}
+	if err := os.Rename(tmpName, filename); err != nil {
+		os.Remove(tmpName)
 		return fmt.Errorf("failed to write to file %s: %w", filename, err)
 	}
 	return nil
 }
 
-// EncodeToBytes encodes the given data to a byte slice in the specified format
+// formatRootNames are the root compound names each format's loader expects
+var formatRootNames = map[string]string{
+	"litematica": "",
+	"worldedit":  "Schematic",
+	"create":     "",
+}
+
+// formatExtensions are the file extensions each format is saved with
+var formatExtensions = map[string]string{
+	"litematica": ".litematic",
+	"worldedit":  ".schem",
+	"create":     ".nbt",
+}
+
+// WriteArchive encodes each schematic in the given format and writes them to
+// a zip file at path, e.g. to distribute a pack of builds. Map keys are the
+// entry names, and the format's extension is added to any name that lacks it.
+// Values may be anything EncodeToBytes accepts. Entries are written in name
+// order, and the file is only replaced once every schematic has been encoded.
+func WriteArchive(path string, schematics map[string]interface{}, format string) error {
+	ext, ok := formatExtensions[format]
+	if !ok {
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+
+	names := make([]string, 0, len(schematics))
+	for name := range schematics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		out, err := EncodeToBytes(schematics[name], format)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+
+		entry := name
+		if !strings.EqualFold(filepath.Ext(entry), ext) {
+			entry += ext
+		}
+		// The schematics are already compressed, so store them as is
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: entry, Method: zip.Store})
+		if err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", entry, err)
+		}
+		if _, err := w.Write(out); err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", entry, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// EncodeToBytes encodes the given data to a gzip-compressed NBT byte slice in the
+// specified format ("litematica", "worldedit" or "create"). data may be a
+// *StandardFormat, which is converted first, or the matching typed struct.
 func EncodeToBytes(data interface{}, format string) ([]byte, error) {
-	return []byte{}, nil
+	typed, err := toFormatStruct(data, format)
+	if err != nil {
+		return nil, err
+	}
+	return encodeGzipNBT(typed, formatRootNames[format])
+}
+
+// toFormatStruct returns data as the typed struct for format, converting a *StandardFormat if needed
+func toFormatStruct(data interface{}, format string) (interface{}, error) {
+	if standard, ok := data.(*StandardFormat); ok {
+		if _, ok := formatRootNames[format]; !ok {
+			return nil, fmt.Errorf("unsupported output format: %s", format)
+		}
+		return ConvertFromStandard(standard, format)
+	}
+
+	switch data.(type) {
+	case *LitematicaNBT:
+		if format == "litematica" {
+			return data, nil
+		}
+	case *WorldEditNBT:
+		if format == "worldedit" {
+			return data, nil
+		}
+	case *CreateNBT:
+		if format == "create" {
+			return data, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported data type %T", data)
+	}
+	return nil, fmt.Errorf("cannot encode %T as %s", data, format)
 }
 
-// EncodeLitematicaBlockStates encodes block states for Litematica format.
-// Entries do NOT cross long boundaries.
+// ConvertFile converts the schematic at inputPath to outputFormat and writes it to outputPath.
+// Litematica input converted to WorldEdit is streamed: each packed block
+// state is written straight to the output's block data, so neither a Blocks
+// slice nor a grid of states is built. Other combinations go through an
+// in-memory StandardFormat.
+func ConvertFile(inputPath, outputPath, outputFormat string) error {
+	payload, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", inputPath, err)
+	}
+
+	var converted interface{}
+	if outputFormat == "worldedit" {
+		litematica, err := DecodeLitematica(payload)
+		switch {
+		case err == nil:
+			converted, err = litematicaToWorldEdit(context.Background(), litematica)
+			if err != nil {
+				return fmt.Errorf("failed to convert %s: %w", inputPath, err)
+			}
+		case !errors.Is(err, ErrUnknownFormat):
+			return fmt.Errorf("failed to decode file %s: %w", inputPath, err)
+		}
+	}
+
+	if converted == nil {
+		data, err := DecodeAny(payload)
+		if err != nil {
+			return fmt.Errorf("failed to decode file %s: %w", inputPath, err)
+		}
+
+		standard, err := ConvertToStandard(data)
+		if err != nil {
+			return fmt.Errorf("failed to convert %s to standard format: %w", inputPath, err)
+		}
+
+		converted, err = ConvertFromStandard(standard, outputFormat)
+		if err != nil {
+			return err
+		}
+	}
+
+	var out []byte
+	switch outputFormat {
+	case "json", "standard":
+		out, err = json.Marshal(converted)
+	default:
+		out, err = EncodeToBytes(converted, outputFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode %s output: %w", outputFormat, err)
+	}
+
+	return writeFileAtomic(outputPath, out)
+}
+
+// litematicaToWorldEdit converts a Litematica file to WorldEdit like
+// ConvertToStandard followed by ConvertFromStandard, without building the
+// intermediate blocks. Both formats store blocks in YZX order and the
+// palette keeps its indices, so each packed state becomes one varint.
+func litematicaToWorldEdit(ctx context.Context, litematica *LitematicaNBT) (*WorldEditNBT, error) {
+	standard, region, err := litematicaStandardHeader(ctx, litematica)
+	if err != nil {
+		return nil, err
+	}
+
+	volume := standard.Size.X * standard.Size.Y * standard.Size.Z
+	bitsPerEntry := litematicaBitsPerEntry(len(region.BlockStatePalette))
+	blockData := make([]byte, 0, volume)
+	for i := 0; i < volume; i++ {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
+		}
+		// Like ConvertToStandard, entries missing from a short array read as 0
+		state, _ := litematicaStateAt(region.BlockStates, bitsPerEntry, i)
+		if state >= len(region.BlockStatePalette) {
+			warn(ctx, WarningStateNotInPalette, "block %d has state %d, which is not in the palette", i, state)
+		}
+		blockData = binary.AppendUvarint(blockData, uint64(state))
+	}
+	return worldEditWithBlockData(standard, blockData)
+}
+
+// encodeGzipNBT encodes v as a root compound named rootName and gzip-compresses it
+func encodeGzipNBT(v interface{}, rootName string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := nbt.NewEncoder(gz).Encode(v, rootName); err != nil {
+		return nil, fmt.Errorf("failed to encode NBT: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress NBT: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeLitematicaBlockStates packs block states into a Litematica
+// BlockStates long array. Entries are packed back to back, so an entry that
+// doesn't fit in the rest of a long continues in the next one. Bits per entry
+// come from the largest state (minimum 2); Litematica derives them from the
+// palette size, so the palette's last index should be in use.
 func EncodeLitematicaBlockStates(blockStates []int64, size StandardSize) []int64 {
 	totalBlocks := size.X * size.Y * size.Z
 
-	// Calculate bits per entry from maximum state value
 	maxState := int64(0)
 	for _, state := range blockStates {
 		if state > maxState {
@@ -34,28 +263,22 @@ func EncodeLitematicaBlockStates(blockStates []int64, size StandardSize) []int64
 		}
 	}
 
-	bitsPerBlock := 2 // minimum 2
-	if maxState > 0 {
-		b := bits.Len64(uint64(maxState))
-		if b > bitsPerBlock {
-			bitsPerBlock = b
-		}
-	}
-
-	blocksPerLong := 64 / bitsPerBlock
-	numLongs := (totalBlocks + blocksPerLong - 1) / blocksPerLong
-
-	result := make([]int64, numLongs)
-	mask := int64((1 << bitsPerBlock) - 1)
-
+	indices := make([]int, totalBlocks)
 	for i := 0; i < totalBlocks && i < len(blockStates); i++ {
-		longIndex := i / blocksPerLong
-		bitOffset := (i % blocksPerLong) * bitsPerBlock
-		state := blockStates[i] & mask
-		result[longIndex] |= state << bitOffset
+		indices[i] = int(blockStates[i])
 	}
+	return packLitematicaBlockStates(indices, int(maxState)+1)
+}
 
-	return result
+// DecodeLitematicaBlockStates unpacks a Litematica BlockStates long array
+// into one state per block, the inverse of EncodeLitematicaBlockStates
+func DecodeLitematicaBlockStates(packed []int64, paletteSize int, size StandardSize) []int64 {
+	indices := decodeLitematicaBlockStates(packed, paletteSize, size.X*size.Y*size.Z)
+	states := make([]int64, len(indices))
+	for i, index := range indices {
+		states[i] = int64(index)
+	}
+	return states
 }
 
 // EncodeWorldEditBlockData encodes block data for WorldEdit format using varint encoding
@@ -108,10 +331,6 @@ func EncodeCreateBlocks(blocks []StandardBlock) []interface{} {
 	result := make([]interface{}, 0, len(blocks))
 
 	for _, block := range blocks {
-		if block.Type == "entity" {
-			continue
-		}
-
 		blockMap := map[string]interface{}{
 			"pos":   []int{int(block.Position.X), int(block.Position.Y), int(block.Position.Z)},
 			"state": block.State,
@@ -126,3 +345,55 @@ func EncodeCreateBlocks(blocks []StandardBlock) []interface{} {
 
 	return result
 }
+
+// recommendableFormats are the formats RecommendFormat chooses between, in
+// the order ties are broken
+var recommendableFormats = []string{"litematica", "worldedit", "create"}
+
+// RecommendFormat encodes sf in each supported format and returns the one
+// with the smallest output that keeps all of its content, along with that
+// output's size in bytes. The size is exact for this library's encoders,
+// but other tools may write the same schematic larger or smaller. When no
+// format keeps everything, the smallest overall is returned. format is empty
+// if sf cannot be encoded at all.
+func RecommendFormat(sf *StandardFormat) (format string, estimatedBytes int) {
+	sizes := make(map[string]int, len(recommendableFormats))
+	for _, candidate := range recommendableFormats {
+		if encoded, err := EncodeToBytes(sf, candidate); err == nil {
+			sizes[candidate] = len(encoded)
+		}
+	}
+
+	for _, lossless := range []bool{true, false} {
+		for _, candidate := range recommendableFormats {
+			size, ok := sizes[candidate]
+			if !ok || (lossless && !keepsContent(sf, candidate)) {
+				continue
+			}
+			if format == "" || size < estimatedBytes {
+				format, estimatedBytes = candidate, size
+			}
+		}
+		if format != "" {
+			break
+		}
+	}
+	return format, estimatedBytes
+}
+
+// keepsContent reports whether encoding sf as format keeps its entities,
+// biomes and descriptive metadata. WorldEdit schematics have no entities or
+// metadata, and only WorldEdit stores biomes.
+func keepsContent(sf *StandardFormat, format string) bool {
+	m := sf.Metadata
+	hasMetadata := m.Name != "" || m.Author != "" || m.Description != "" || len(m.PreviewImageData) > 0
+	switch format {
+	case "litematica":
+		return sf.Biomes == nil
+	case "worldedit":
+		return len(sf.Entities) == 0 && !hasMetadata
+	case "create":
+		return sf.Biomes == nil && !hasMetadata
+	}
+	return false
+}
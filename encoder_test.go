@@ -0,0 +1,371 @@
+package mcnbt
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// TestConvertFile verifies converting a fixture file produces a decodable output file
+func TestConvertFile(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "color_field.schem")
+
+	if err := ConvertFile("testdata/color_field.litematic", outputPath, "worldedit"); err != nil {
+		t.Fatalf("Failed to convert file: %v", err)
+	}
+
+	data, err := ParseAnyFromFileAsJSON(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to parse converted file: %v", err)
+	}
+
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert output to standard: %v", err)
+	}
+
+	if standard.OriginalFormat != "worldedit" {
+		t.Errorf("Expected worldedit output, got %s", standard.OriginalFormat)
+	}
+	if len(standard.Blocks) == 0 {
+		t.Errorf("Converted file has no blocks")
+	}
+}
+
+// TestConvertFileStreamedMatchesInMemory verifies the streamed Litematica to
+// WorldEdit conversion gives the same schematic as going through a StandardFormat
+func TestConvertFileStreamedMatchesInMemory(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/color_field.litematic")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	sf := newTestStandard(3, 2, 2)
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 2, 1, 1, 1)
+	sf.TileEntities = []StandardTileEntity{
+		{ID: "minecraft:chest", Position: StandardBlockPosition{X: 2, Y: 1, Z: 1}, NBT: map[string]interface{}{"CustomName": "b"}},
+		{ID: "minecraft:chest", Position: StandardBlockPosition{X: 0, Y: 0, Z: 0}, NBT: map[string]interface{}{"CustomName": "a"}},
+	}
+	sf.Entities = []StandardEntity{{ID: "minecraft:pig", Position: StandardBlockPosition{X: 1.5, Y: 0, Z: 0.5}}}
+	built, err := EncodeToBytes(sf, "litematica")
+	if err != nil {
+		t.Fatalf("Failed to encode litematica: %v", err)
+	}
+
+	for name, payload := range map[string][]byte{"fixture": fixture, "built": built} {
+		t.Run(name, func(t *testing.T) {
+			data, err := DecodeAny(payload)
+			if err != nil {
+				t.Fatalf("Failed to decode: %v", err)
+			}
+			standard, err := ConvertToStandard(data)
+			if err != nil {
+				t.Fatalf("Failed to convert: %v", err)
+			}
+			inMemory, err := ConvertFromStandard(standard, "worldedit")
+			if err != nil {
+				t.Fatalf("Failed to convert to worldedit: %v", err)
+			}
+
+			litematica, err := DecodeLitematica(payload)
+			if err != nil {
+				t.Fatalf("Failed to decode litematica: %v", err)
+			}
+			streamed, err := litematicaToWorldEdit(context.Background(), litematica)
+			if err != nil {
+				t.Fatalf("Failed to stream to worldedit: %v", err)
+			}
+
+			if !reflect.DeepEqual(streamed, inMemory) {
+				t.Errorf("Streamed conversion differs:\n%+v\nin memory:\n%+v", streamed, inMemory)
+			}
+		})
+	}
+}
+
+// TestEncodeToBytesRoundTrip verifies encoded bytes decode back into an equivalent structure
+func TestEncodeToBytesRoundTrip(t *testing.T) {
+	files := map[string]string{
+		"litematica": "testdata/color_field.litematic",
+		"worldedit":  "testdata/color_field.schem",
+		"create":     "testdata/color_field.nbt",
+	}
+
+	for format, path := range files {
+		t.Run(format, func(t *testing.T) {
+			data, err := ParseAnyFromFileAsJSON(path)
+			if err != nil {
+				t.Fatalf("Failed to parse %s: %v", path, err)
+			}
+			original, err := ConvertToStandard(data)
+			if err != nil {
+				t.Fatalf("Failed to convert %s: %v", path, err)
+			}
+
+			encoded, err := EncodeToBytes(original, format)
+			if err != nil {
+				t.Fatalf("Failed to encode %s: %v", format, err)
+			}
+
+			// Check the root compound name the loader expects
+			gz, err := gzip.NewReader(bytes.NewReader(encoded))
+			if err != nil {
+				t.Fatalf("Encoded data is not gzip: %v", err)
+			}
+			rootName, err := nbt.NewDecoder(gz).Decode(new(interface{}))
+			if err != nil {
+				t.Fatalf("Failed to decode encoded NBT: %v", err)
+			}
+			if rootName != formatRootNames[format] {
+				t.Errorf("Expected root name %q, got %q", formatRootNames[format], rootName)
+			}
+
+			decoded, err := DecodeAny(encoded)
+			if err != nil {
+				t.Fatalf("Failed to decode encoded bytes: %v", err)
+			}
+			roundTripped, err := ConvertToStandard(decoded)
+			if err != nil {
+				t.Fatalf("Failed to convert encoded bytes: %v", err)
+			}
+
+			if roundTripped.OriginalFormat != format {
+				t.Errorf("Expected format %s, got %s", format, roundTripped.OriginalFormat)
+			}
+			if len(roundTripped.Blocks) != len(original.Blocks) {
+				t.Errorf("Block count mismatch: %d vs %d", len(original.Blocks), len(roundTripped.Blocks))
+			}
+			if len(roundTripped.Palette) != len(original.Palette) {
+				t.Errorf("Palette size mismatch: %d vs %d", len(original.Palette), len(roundTripped.Palette))
+			}
+			if roundTripped.Size != original.Size {
+				t.Errorf("Size mismatch: %+v vs %+v", original.Size, roundTripped.Size)
+			}
+		})
+	}
+
+	if _, err := EncodeToBytes(&CreateNBT{}, "litematica"); err == nil {
+		t.Errorf("Expected an error encoding CreateNBT as litematica")
+	}
+}
+
+// TestEncodeAirFreePalette verifies a structure whose palette has no air entry
+// keeps its block counts when written to the dense formats, instead of filling
+// empty cells with palette entry 0
+func TestEncodeAirFreePalette(t *testing.T) {
+	data, err := ParseAnyFromFileAsJSON("testdata/color_field.nbt")
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	original, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+	if _, hasAir := original.airState(); hasAir {
+		t.Fatalf("Expected the fixture's palette to have no air entry")
+	}
+	expected := original.BlockHistogramWithProperties(true)
+	paletteSize := len(original.Palette)
+
+	for _, format := range []string{"litematica", "worldedit"} {
+		encoded, err := EncodeToBytes(original, format)
+		if err != nil {
+			t.Fatalf("Failed to encode %s: %v", format, err)
+		}
+		decoded, err := DecodeAny(encoded)
+		if err != nil {
+			t.Fatalf("Failed to decode %s: %v", format, err)
+		}
+		roundTripped, err := ConvertToStandard(decoded)
+		if err != nil {
+			t.Fatalf("Failed to convert %s: %v", format, err)
+		}
+		if counts := roundTripped.BlockHistogramWithProperties(true); !reflect.DeepEqual(counts, expected) {
+			t.Errorf("%s: expected block counts %v, got %v", format, expected, counts)
+		}
+	}
+
+	if len(original.Palette) != paletteSize {
+		t.Errorf("Expected encoding to leave the palette alone, got %d entries", len(original.Palette))
+	}
+}
+
+// TestEncodeToFile verifies the full parse, convert and encode workflow writes a loadable file
+func TestEncodeToFile(t *testing.T) {
+	data, err := ParseAnyFromFileAsJSON("testdata/color_field.nbt")
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+	litematica, err := ConvertFromStandard(standard, "litematica")
+	if err != nil {
+		t.Fatalf("Failed to convert to litematica: %v", err)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "color_field.litematic")
+	if err := EncodeToFile(litematica, "litematica", outputPath); err != nil {
+		t.Fatalf("Failed to encode file: %v", err)
+	}
+
+	reparsed, err := ParseAnyFromFileAsJSON(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to parse encoded file: %v", err)
+	}
+	roundTripped, err := ConvertToStandard(reparsed)
+	if err != nil {
+		t.Fatalf("Failed to convert encoded file: %v", err)
+	}
+	if roundTripped.OriginalFormat != "litematica" {
+		t.Errorf("Expected litematica, got %s", roundTripped.OriginalFormat)
+	}
+}
+
+// TestEncodeToFileNoPartialOutput verifies a failed encode leaves no file behind
+func TestEncodeToFileNoPartialOutput(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := filepath.Join(dir, "broken.schem")
+
+	if err := EncodeToFile(&CreateNBT{}, "worldedit", outputPath); err == nil {
+		t.Fatalf("Expected an error encoding CreateNBT as worldedit")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read output directory: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no files after a failed encode, found %d", len(entries))
+	}
+}
+
+// TestWriteArchive verifies each schematic is written to the zip under its
+// name with the format's extension and decodes back
+func TestWriteArchive(t *testing.T) {
+	tower := newTestStandard(1, 2, 1)
+	addTestBlock(tower, 0, 0, 0, 1)
+	addTestBlock(tower, 0, 1, 0, 1)
+	wall := newTestStandard(3, 1, 1)
+	for x := 0; x < 3; x++ {
+		addTestBlock(wall, x, 0, 0, 1)
+	}
+
+	path := filepath.Join(t.TempDir(), "pack.zip")
+	err := WriteArchive(path, map[string]interface{}{"tower": tower, "walls/wall.schem": wall}, "worldedit")
+	if err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	defer zr.Close()
+
+	expected := map[string]StandardSize{
+		"tower.schem":      {X: 1, Y: 2, Z: 1},
+		"walls/wall.schem": {X: 3, Y: 1, Z: 1},
+	}
+	if len(zr.File) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(zr.File))
+	}
+	for _, file := range zr.File {
+		size, ok := expected[file.Name]
+		if !ok {
+			t.Errorf("Unexpected entry %s", file.Name)
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		payload, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file.Name, err)
+		}
+
+		data, err := DecodeAny(payload)
+		if err != nil {
+			t.Fatalf("Failed to decode %s: %v", file.Name, err)
+		}
+		standard, err := ConvertToStandard(data)
+		if err != nil {
+			t.Fatalf("Failed to convert %s: %v", file.Name, err)
+		}
+		if standard.Size != size {
+			t.Errorf("%s: expected size %+v, got %+v", file.Name, size, standard.Size)
+		}
+	}
+
+	if err := WriteArchive(path, nil, "vox"); err == nil {
+		t.Errorf("Expected an error for an unsupported format")
+	}
+}
+
+// TestLitematicaBlockStatesRoundTrip verifies encode and decode agree for
+// random palettes, including bit widths that don't divide 64
+func TestLitematicaBlockStatesRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	size := StandardSize{X: 7, Y: 5, Z: 9}
+	volume := size.X * size.Y * size.Z
+
+	for trial := 0; trial < 200; trial++ {
+		paletteSize := 1 + rng.Intn(4096)
+		states := make([]int64, volume)
+		for i := range states {
+			states[i] = int64(rng.Intn(paletteSize))
+		}
+		// Use the last palette index so the encoder picks Litematica's bit width
+		states[rng.Intn(volume)] = int64(paletteSize - 1)
+
+		packed := EncodeLitematicaBlockStates(states, size)
+		bitsPerEntry := litematicaBitsPerEntry(paletteSize)
+		if want := (volume*bitsPerEntry + 63) / 64; len(packed) != want {
+			t.Fatalf("Palette %d: expected %d longs, got %d", paletteSize, want, len(packed))
+		}
+
+		decoded := DecodeLitematicaBlockStates(packed, paletteSize, size)
+		for i := range states {
+			if decoded[i] != states[i] {
+				t.Fatalf("Palette %d: block %d decoded as %d, expected %d", paletteSize, i, decoded[i], states[i])
+			}
+		}
+	}
+}
+
+// TestRecommendFormat verifies the recommendation is a supported format with
+// a size estimate, and that formats losing content are passed over
+func TestRecommendFormat(t *testing.T) {
+	sf := newTestCube(4, "minecraft:stone")
+	format, size := RecommendFormat(sf)
+	if _, ok := formatRootNames[format]; !ok {
+		t.Fatalf("Expected a supported format, got %q", format)
+	}
+	encoded, err := EncodeToBytes(sf, format)
+	if err != nil {
+		t.Fatalf("Failed to encode %s: %v", format, err)
+	}
+	if size <= 0 || size != len(encoded) {
+		t.Errorf("Expected a size estimate of %d bytes, got %d", len(encoded), size)
+	}
+
+	// Only Litematica keeps both the entity and the name
+	sf.Entities = append(sf.Entities, StandardEntity{ID: "minecraft:pig", Position: StandardBlockPosition{X: 1, Y: 4, Z: 1}})
+	sf.Metadata.Name = "Cube"
+	if format, _ := RecommendFormat(sf); format != "litematica" {
+		t.Errorf("Expected litematica for a named schematic with entities, got %s", format)
+	}
+}
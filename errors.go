@@ -0,0 +1,59 @@
+package mcnbt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Sentinel errors returned (possibly wrapped) by the decode and convert
+// functions, so callers can tell failures apart with errors.Is
+var (
+	// ErrEmptyData is returned when there is no data to decode
+	ErrEmptyData = errors.New("empty data")
+
+	// ErrUnknownFormat is returned when data does not match any supported
+	// schematic format, or an unsupported output format is requested
+	ErrUnknownFormat = errors.New("unsupported format or unable to identify format")
+
+	// ErrCorruptCompression is returned when compressed data cannot be decompressed
+	ErrCorruptCompression = errors.New("corrupt compressed data")
+
+	// ErrNoRegions is returned for a Litematica file without any regions
+	ErrNoRegions = errors.New("no regions found in litematica file")
+
+	// ErrUnknownMagic is returned when data starts with an unrecognized file-type magic prefix
+	ErrUnknownMagic = errors.New("unknown file magic")
+
+	// ErrInvalidSNBT is returned when ParseSNBT is given malformed SNBT text
+	ErrInvalidSNBT = errors.New("invalid SNBT")
+
+	// ErrNestingTooDeep is returned when gzip-compressed NBT values are nested
+	// more deeply than the decoder will expand
+	ErrNestingTooDeep = errors.New("compressed NBT nested too deeply")
+
+	// ErrNestedTooLarge is returned when gzip-compressed NBT values expand to
+	// more data than the decoder will decompress
+	ErrNestedTooLarge = errors.New("compressed NBT expands too large")
+
+	// ErrChunkNotFound is returned when a region file holds no data for the requested chunk
+	ErrChunkNotFound = errors.New("chunk not found in region")
+
+	// ErrInvalidStandard is wrapped by each problem Validate reports
+	ErrInvalidStandard = errors.New("invalid standard format")
+)
+
+// compressionErrorReader marks read errors from a decompressor as
+// ErrCorruptCompression, so truncated or damaged streams that are only noticed
+// while decoding NBT are still reported as a compression failure
+type compressionErrorReader struct {
+	r io.Reader
+}
+
+func (c compressionErrorReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("%w: %w", ErrCorruptCompression, err)
+	}
+	return n, err
+}
@@ -0,0 +1,322 @@
+package mcnbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"sort"
+)
+
+// ResolvedBlock is a block record with its palette entry resolved inline,
+// so consumers don't need the palette to interpret it
+type ResolvedBlock struct {
+	StandardBlock
+
+	// Block name resolved from the palette (e.g., "minecraft:stone")
+	Name string `json:"name"`
+
+	// Block properties resolved from the palette
+	Properties map[string]string `json:"properties"`
+}
+
+// ResolveAll returns every block with its palette name and properties denormalized
+func (sf *StandardFormat) ResolveAll() []ResolvedBlock {
+	resolved := make([]ResolvedBlock, 0, len(sf.Blocks))
+	for _, block := range sf.Blocks {
+		rb := ResolvedBlock{StandardBlock: block}
+		if p, ok := sf.Palette[block.State]; ok {
+			rb.Name = p.Name
+			rb.Properties = p.Properties
+		}
+		if rb.Properties == nil {
+			rb.Properties = make(map[string]string)
+		}
+		resolved = append(resolved, rb)
+	}
+	return resolved
+}
+
+// WriteNDJSON writes one resolved JSON object per line for each block
+func (sf *StandardFormat) WriteNDJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, rb := range sf.ResolveAll() {
+		if err := enc.Encode(rb); err != nil {
+			return fmt.Errorf("failed to write block at %+v: %w", rb.Position, err)
+		}
+	}
+	return nil
+}
+
+// WriteHeightmapPNG writes the Heightmap as a Size.X by Size.Z grayscale PNG,
+// looking down with X to the right and Z downwards. Brighter pixels are
+// higher; columns without solid blocks are black.
+func (sf *StandardFormat) WriteHeightmapPNG(w io.Writer) error {
+	if sf.Size.X <= 0 || sf.Size.Z <= 0 {
+		return fmt.Errorf("cannot render a heightmap for size %+v", sf.Size)
+	}
+
+	img := image.NewGray(image.Rect(0, 0, sf.Size.X, sf.Size.Z))
+	for column, h := range sf.Heightmap() {
+		x, z := column[0], column[1]
+		if x < 0 || x >= sf.Size.X || z < 0 || z >= sf.Size.Z {
+			continue
+		}
+		// Shift by one so a block at Y=0 is still distinguishable from an empty column
+		level := 255 * (h + 1) / max(sf.Size.Y, 1)
+		img.SetGray(x, z, color.Gray{Y: uint8(min(max(level, 1), 255))})
+	}
+
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("failed to write heightmap png: %w", err)
+	}
+	return nil
+}
+
+// MapArtColors returns the map color of the top solid block in each column,
+// indexed [x][z] over Size.X by Size.Z, to preview how the build looks on an
+// in-game map. mapColorTable maps block names to map color indices, which are
+// returned as given; columns with no solid block, or whose top block has no
+// entry, are 0, the transparent map color.
+func (sf *StandardFormat) MapArtColors(mapColorTable map[string]uint8) ([][]uint8, error) {
+	if sf.Size.X <= 0 || sf.Size.Z <= 0 {
+		return nil, fmt.Errorf("cannot render map colors for size %+v", sf.Size)
+	}
+
+	type top struct {
+		y    int
+		name string
+	}
+	tops := make(map[[2]int]top)
+	for _, block := range sf.Blocks {
+		if !sf.isSolid(block) {
+			continue
+		}
+		column := [2]int{int(block.Position.X), int(block.Position.Z)}
+		y := int(block.Position.Y)
+		if t, ok := tops[column]; !ok || y > t.y {
+			tops[column] = top{y: y, name: sf.Palette[block.State].Name}
+		}
+	}
+
+	colors := make([][]uint8, sf.Size.X)
+	for x := range colors {
+		colors[x] = make([]uint8, sf.Size.Z)
+	}
+	for column, t := range tops {
+		x, z := column[0], column[1]
+		if x < 0 || x >= sf.Size.X || z < 0 || z >= sf.Size.Z {
+			continue
+		}
+		colors[x][z] = mapColorTable[t.name]
+	}
+	return colors, nil
+}
+
+// voxMaxSize is the largest dimension a single MagicaVoxel model can hold
+const voxMaxSize = 256
+
+// WriteVox writes sf as a MagicaVoxel .vox model. colorMap maps block names to
+// color indices (1-255) in MagicaVoxel's default palette, which is written to
+// the file's RGBA chunk so readers without a built-in default show the same
+// colors; blocks without an entry are left out. Minecraft's Y axis becomes the
+// .vox Z (up) axis.
+func (sf *StandardFormat) WriteVox(w io.Writer, colorMap map[string]uint8) error {
+	if sf.Size.X > voxMaxSize || sf.Size.Y > voxMaxSize || sf.Size.Z > voxMaxSize {
+		return fmt.Errorf("size %+v exceeds the .vox limit of %d per axis", sf.Size, voxMaxSize)
+	}
+
+	var voxels []byte
+	for _, block := range sf.Blocks {
+		if !sf.isSolid(block) {
+			continue
+		}
+		p, ok := sf.Palette[block.State]
+		if !ok {
+			continue
+		}
+		color := colorMap[p.Name]
+		if color == 0 {
+			continue
+		}
+		x, y, z := int(block.Position.X), int(block.Position.Y), int(block.Position.Z)
+		if x < 0 || x >= sf.Size.X || y < 0 || y >= sf.Size.Y || z < 0 || z >= sf.Size.Z {
+			continue
+		}
+		voxels = append(voxels, byte(x), byte(z), byte(y), color)
+	}
+
+	var children bytes.Buffer
+	writeVoxChunk(&children, "SIZE", int32(sf.Size.X), int32(sf.Size.Z), int32(sf.Size.Y))
+
+	var xyzi bytes.Buffer
+	binary.Write(&xyzi, binary.LittleEndian, int32(len(voxels)/4))
+	xyzi.Write(voxels)
+	writeVoxChunkBytes(&children, "XYZI", xyzi.Bytes())
+
+	// RGBA entry i holds color index i+1, and the last entry is unused
+	palette := voxDefaultPalette()
+	var rgba bytes.Buffer
+	binary.Write(&rgba, binary.LittleEndian, palette[1:])
+	binary.Write(&rgba, binary.LittleEndian, uint32(0))
+	writeVoxChunkBytes(&children, "RGBA", rgba.Bytes())
+
+	var out bytes.Buffer
+	out.WriteString("VOX ")
+	binary.Write(&out, binary.LittleEndian, int32(150))
+	out.WriteString("MAIN")
+	binary.Write(&out, binary.LittleEndian, int32(0))
+	binary.Write(&out, binary.LittleEndian, int32(children.Len()))
+	out.Write(children.Bytes())
+
+	if _, err := w.Write(out.Bytes()); err != nil {
+		return fmt.Errorf("failed to write vox data: %w", err)
+	}
+	return nil
+}
+
+// voxDefaultPalette returns MagicaVoxel's default palette as 0xAABBGGRR
+// values, indexed by color index. Indices 1-215 are the web-safe color cube
+// without black, from white down with blue varying fastest, followed by
+// ramps of red, green, blue and gray.
+func voxDefaultPalette() [256]uint32 {
+	var palette [256]uint32
+	i := 1
+	for r := 5; r >= 0; r-- {
+		for g := 5; g >= 0; g-- {
+			for b := 5; b >= 0; b-- {
+				if r == 0 && g == 0 && b == 0 {
+					continue
+				}
+				palette[i] = 0xff000000 | uint32(b*0x33)<<16 | uint32(g*0x33)<<8 | uint32(r*0x33)
+				i++
+			}
+		}
+	}
+
+	ramp := []uint32{0xee, 0xdd, 0xbb, 0xaa, 0x88, 0x77, 0x55, 0x44, 0x22, 0x11}
+	for _, shift := range []uint32{0, 8, 16} {
+		for _, level := range ramp {
+			palette[i] = 0xff000000 | level<<shift
+			i++
+		}
+	}
+	for _, level := range ramp {
+		palette[i] = 0xff000000 | level<<16 | level<<8 | level
+		i++
+	}
+	return palette
+}
+
+// writeVoxChunk writes a .vox chunk whose content is a sequence of int32 values
+func writeVoxChunk(w *bytes.Buffer, id string, values ...int32) {
+	var content bytes.Buffer
+	for _, v := range values {
+		binary.Write(&content, binary.LittleEndian, v)
+	}
+	writeVoxChunkBytes(w, id, content.Bytes())
+}
+
+// writeVoxChunkBytes writes a .vox chunk with the given content and no children
+func writeVoxChunkBytes(w *bytes.Buffer, id string, content []byte) {
+	w.WriteString(id)
+	binary.Write(w, binary.LittleEndian, int32(len(content)))
+	binary.Write(w, binary.LittleEndian, int32(0))
+	w.Write(content)
+}
+
+// FillRegion is an axis-aligned box of identical blocks, inclusive on both corners
+type FillRegion struct {
+	// Minimum corner, relative to the schematic origin
+	Min StandardPosition `json:"min"`
+
+	// Maximum corner, relative to the schematic origin
+	Max StandardPosition `json:"max"`
+
+	// Block state string, e.g. "minecraft:oak_log[axis=y]"
+	Block string `json:"block"`
+}
+
+// Command formats r as a /fill command relative to the executing position
+func (r FillRegion) Command() string {
+	return fmt.Sprintf("fill ~%d ~%d ~%d ~%d ~%d ~%d %s",
+		r.Min.X, r.Min.Y, r.Min.Z, r.Max.X, r.Max.Y, r.Max.Z, r.Block)
+}
+
+// GreedyFillRegions merges face-adjacent solid blocks with the same state into
+// boxes, growing each box along X, then Z, then Y. The result is not always
+// the smallest possible set, but large uniform areas collapse to a handful of
+// regions. Air is skipped and block entity NBT is not carried over.
+func (sf *StandardFormat) GreedyFillRegions() []FillRegion {
+	cells := make(map[[3]int]string)
+	for _, block := range sf.Blocks {
+		if !sf.isSolid(block) {
+			continue
+		}
+		p, ok := sf.Palette[block.State]
+		if !ok {
+			continue
+		}
+		cells[positionKey(block.Position)] = EncodePropertyString(p.Name, p.Properties)
+	}
+
+	keys := make([][3]int, 0, len(cells))
+	for key := range cells {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return lessPosition(keys[i], keys[j]) })
+
+	used := make(map[[3]int]bool, len(cells))
+	// filled reports whether every cell in [lo, hi] holds block and is unused
+	filled := func(lo, hi [3]int, block string) bool {
+		for y := lo[1]; y <= hi[1]; y++ {
+			for z := lo[2]; z <= hi[2]; z++ {
+				for x := lo[0]; x <= hi[0]; x++ {
+					pos := [3]int{x, y, z}
+					if used[pos] || cells[pos] != block {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+
+	var regions []FillRegion
+	for _, start := range keys {
+		if used[start] {
+			continue
+		}
+		block := cells[start]
+		end := start
+		for _, axis := range []int{0, 2, 1} {
+			for {
+				lo, hi := start, end
+				lo[axis] = end[axis] + 1
+				hi[axis] = end[axis] + 1
+				if !filled(lo, hi, block) {
+					break
+				}
+				end[axis]++
+			}
+		}
+
+		for y := start[1]; y <= end[1]; y++ {
+			for z := start[2]; z <= end[2]; z++ {
+				for x := start[0]; x <= end[0]; x++ {
+					used[[3]int{x, y, z}] = true
+				}
+			}
+		}
+		regions = append(regions, FillRegion{
+			Min:   StandardPosition{X: start[0], Y: start[1], Z: start[2]},
+			Max:   StandardPosition{X: end[0], Y: end[1], Z: end[2]},
+			Block: block,
+		})
+	}
+	return regions
+}
@@ -0,0 +1,246 @@
+package mcnbt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+)
+
+// TestWriteNDJSONResolvesPalette verifies emitted blocks carry their palette name and properties
+func TestWriteNDJSONResolvesPalette(t *testing.T) {
+	sf := newTestStandard(1, 1, 1)
+	sf.Palette[2] = StandardPalette{
+		Name:       "minecraft:oak_stairs",
+		Properties: map[string]string{"facing": "north", "half": "top"},
+	}
+	addTestBlock(sf, 0, 0, 0, 2)
+
+	var buf bytes.Buffer
+	if err := sf.WriteNDJSON(&buf); err != nil {
+		t.Fatalf("Failed to write NDJSON: %v", err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	if !scanner.Scan() {
+		t.Fatalf("Expected at least one line of output")
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to unmarshal emitted block: %v", err)
+	}
+
+	if record["name"] != "minecraft:oak_stairs" {
+		t.Errorf("Expected name minecraft:oak_stairs, got %v", record["name"])
+	}
+
+	props, ok := record["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected properties object, got %T", record["properties"])
+	}
+	if props["facing"] != "north" || props["half"] != "top" {
+		t.Errorf("Unexpected properties: %v", props)
+	}
+}
+
+// TestWriteVox verifies the .vox header, voxel count and default palette
+func TestWriteVox(t *testing.T) {
+	sf := newTestStandard(2, 2, 1)
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 1, 0, 0, 1)
+	addTestBlock(sf, 0, 1, 0, 1)
+	addTestBlock(sf, 1, 1, 0, 0)
+
+	var buf bytes.Buffer
+	if err := sf.WriteVox(&buf, map[string]uint8{"minecraft:stone": 8}); err != nil {
+		t.Fatalf("Failed to write vox: %v", err)
+	}
+	out := buf.Bytes()
+
+	if string(out[0:4]) != "VOX " {
+		t.Fatalf("Expected VOX magic, got %q", out[0:4])
+	}
+	if version := binary.LittleEndian.Uint32(out[4:8]); version != 150 {
+		t.Errorf("Expected version 150, got %d", version)
+	}
+	if string(out[8:12]) != "MAIN" {
+		t.Fatalf("Expected MAIN chunk, got %q", out[8:12])
+	}
+
+	// MAIN header (12) + SIZE chunk (12 + 12) puts the XYZI chunk at offset 44
+	xyzi := out[44:]
+	if string(xyzi[0:4]) != "XYZI" {
+		t.Fatalf("Expected XYZI chunk, got %q", xyzi[0:4])
+	}
+	if count := binary.LittleEndian.Uint32(xyzi[12:16]); count != 3 {
+		t.Errorf("Expected 3 voxels, got %d", count)
+	}
+
+	// The XYZI chunk is 12 bytes of header, a count and 4 bytes per voxel
+	rgba := xyzi[12+4+3*4:]
+	if string(rgba[0:4]) != "RGBA" || binary.LittleEndian.Uint32(rgba[4:8]) != 256*4 {
+		t.Fatalf("Expected a 1024 byte RGBA chunk, got %q of %d bytes", rgba[0:4], binary.LittleEndian.Uint32(rgba[4:8]))
+	}
+	// Color index 8 is the default palette's pale pink, #FFCCCC
+	if color := rgba[12+7*4 : 12+8*4]; !bytes.Equal(color, []byte{0xff, 0xcc, 0xcc, 0xff}) {
+		t.Errorf("Expected color 8 to be ffccccff, got %x", color)
+	}
+	// The ramps after the color cube run red, green, blue and gray
+	ramps := map[int][]byte{
+		216: {0xee, 0x00, 0x00, 0xff},
+		226: {0x00, 0xee, 0x00, 0xff},
+		236: {0x00, 0x00, 0xee, 0xff},
+		246: {0xee, 0xee, 0xee, 0xff},
+		255: {0x11, 0x11, 0x11, 0xff},
+	}
+	for index, expected := range ramps {
+		if color := rgba[12+(index-1)*4 : 12+index*4]; !bytes.Equal(color, expected) {
+			t.Errorf("Expected color %d to be %x, got %x", index, expected, color)
+		}
+	}
+}
+
+// TestWriteArrow verifies the Arrow stream reads back with one row per block,
+// naming blocks whose state is missing from the palette by their ID
+func TestWriteArrow(t *testing.T) {
+	sf := newTestStandard(2, 1, 1)
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 1, 0, 0, 0)
+	sf.Entities = append(sf.Entities, StandardEntity{ID: "minecraft:pig"})
+	sf.Blocks = append(sf.Blocks,
+		StandardBlock{Type: "block", ID: "minecraft:bedrock", State: 7},
+		StandardBlock{Type: "block", State: 8})
+
+	var buf bytes.Buffer
+	if err := sf.WriteArrow(&buf); err != nil {
+		t.Fatalf("Failed to write arrow: %v", err)
+	}
+
+	reader, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("Failed to create arrow reader: %v", err)
+	}
+	defer reader.Release()
+
+	if !reader.Next() {
+		t.Fatalf("Expected a record batch: %v", reader.Err())
+	}
+	record := reader.Record()
+	if rows := record.NumRows(); rows != 4 {
+		t.Fatalf("Expected 4 rows, got %d", rows)
+	}
+
+	names := record.Column(4).(*array.String)
+	if names.Value(0) != "minecraft:stone" {
+		t.Errorf("Expected first name minecraft:stone, got %s", names.Value(0))
+	}
+	// States missing from the palette fall back to the block's ID, or null
+	if names.Value(2) != "minecraft:bedrock" {
+		t.Errorf("Expected the block ID for a missing state, got %q", names.Value(2))
+	}
+	if !names.IsNull(3) {
+		t.Errorf("Expected a null name for a missing state without an ID, got %q", names.Value(3))
+	}
+}
+
+// TestGreedyFillRegions verifies a uniform cube collapses to a single fill region
+func TestGreedyFillRegions(t *testing.T) {
+	cube := newTestCube(4, "minecraft:stone")
+	regions := cube.GreedyFillRegions()
+	if len(regions) != 1 {
+		t.Fatalf("Expected 1 fill region, got %d: %+v", len(regions), regions)
+	}
+	expected := FillRegion{Max: StandardPosition{X: 3, Y: 3, Z: 3}, Block: "minecraft:stone"}
+	if regions[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, regions[0])
+	}
+	if cmd := regions[0].Command(); cmd != "fill ~0 ~0 ~0 ~3 ~3 ~3 minecraft:stone" {
+		t.Errorf("Unexpected command %q", cmd)
+	}
+
+	// A different block in the corner splits the cube, but every cell stays covered
+	cube.Palette[1] = StandardPalette{Name: "minecraft:glass"}
+	cube.Blocks[len(cube.Blocks)-1].State = 1
+	covered := 0
+	for _, r := range cube.GreedyFillRegions() {
+		covered += (r.Max.X - r.Min.X + 1) * (r.Max.Y - r.Min.Y + 1) * (r.Max.Z - r.Min.Z + 1)
+	}
+	if covered != 64 {
+		t.Errorf("Expected regions to cover 64 cells, got %d", covered)
+	}
+}
+
+// TestWriteHeightmapPNG verifies the image is Size.X by Size.Z and taller columns are brighter
+func TestWriteHeightmapPNG(t *testing.T) {
+	sf := newTestStandard(3, 4, 2)
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 1, 0, 1, 1)
+	addTestBlock(sf, 1, 3, 1, 1)
+
+	var buf bytes.Buffer
+	if err := sf.WriteHeightmapPNG(&buf); err != nil {
+		t.Fatalf("Failed to write heightmap: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode heightmap: %v", err)
+	}
+
+	if bounds := img.Bounds(); bounds.Dx() != 3 || bounds.Dy() != 2 {
+		t.Fatalf("Expected a 3x2 image, got %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	gray := func(x, z int) uint8 {
+		return color.GrayModel.Convert(img.At(x, z)).(color.Gray).Y
+	}
+	if gray(2, 0) != 0 {
+		t.Errorf("Expected an empty column to be black, got %d", gray(2, 0))
+	}
+	if low, high := gray(0, 0), gray(1, 1); low == 0 || high != 255 || low >= high {
+		t.Errorf("Expected 0 < low < high = 255, got low=%d high=%d", low, high)
+	}
+}
+
+// TestMapArtColors verifies the grid matches the footprint and uses each column's top block
+func TestMapArtColors(t *testing.T) {
+	sf := newTestStandard(3, 2, 2)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:white_wool"}
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 0, 1, 0, 2)
+	addTestBlock(sf, 2, 0, 1, 1)
+	addTestBlock(sf, 1, 1, 1, 0)
+
+	colors, err := sf.MapArtColors(map[string]uint8{"minecraft:stone": 45, "minecraft:white_wool": 34})
+	if err != nil {
+		t.Fatalf("Failed to compute map colors: %v", err)
+	}
+	if len(colors) != 3 {
+		t.Fatalf("Expected 3 columns along X, got %d", len(colors))
+	}
+	for x, row := range colors {
+		if len(row) != 2 {
+			t.Fatalf("Expected 2 cells along Z at x=%d, got %d", x, len(row))
+		}
+	}
+
+	if colors[0][0] != 34 {
+		t.Errorf("Expected the wool on top of the stone, got %d", colors[0][0])
+	}
+	if colors[2][1] != 45 {
+		t.Errorf("Expected stone at 2,1, got %d", colors[2][1])
+	}
+	if colors[1][1] != 0 || colors[1][0] != 0 {
+		t.Errorf("Expected empty columns to be transparent, got %d and %d", colors[1][1], colors[1][0])
+	}
+
+	if _, err := newTestStandard(0, 1, 1).MapArtColors(nil); err == nil {
+		t.Errorf("Expected an error for an empty footprint")
+	}
+}
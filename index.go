@@ -0,0 +1,66 @@
+package mcnbt
+
+// spatialIndex maps block positions to their index in StandardFormat.Blocks.
+// It remembers the slice it was built from so a reassigned, grown or shrunk
+// Blocks slice is detected and the index rebuilt.
+type spatialIndex struct {
+	positions map[[3]int]int
+	length    int
+	first     *StandardBlock
+}
+
+// valid reports whether the index still describes blocks
+func (idx *spatialIndex) valid(blocks []StandardBlock) bool {
+	if idx == nil || idx.length != len(blocks) {
+		return false
+	}
+	if len(blocks) == 0 {
+		return true
+	}
+	return idx.first == &blocks[0]
+}
+
+// BuildIndex builds the position index used by lookups such as GetBlockAt.
+// Lookups build it on demand, so calling this is only needed to control when
+// the cost is paid. The index is rebuilt automatically when the Blocks slice
+// is replaced or changes length; code that edits block positions in place
+// should call BuildIndex again afterwards.
+func (sf *StandardFormat) BuildIndex() {
+	idx := &spatialIndex{
+		positions: make(map[[3]int]int, len(sf.Blocks)),
+		length:    len(sf.Blocks),
+	}
+	if len(sf.Blocks) > 0 {
+		idx.first = &sf.Blocks[0]
+	}
+	for i, block := range sf.Blocks {
+		key := [3]int{int(block.Position.X), int(block.Position.Y), int(block.Position.Z)}
+		idx.positions[key] = i
+	}
+	sf.index = idx
+}
+
+// invalidateIndex drops the cached index after a mutation
+func (sf *StandardFormat) invalidateIndex() {
+	sf.index = nil
+}
+
+// blockIndexAt returns the index in Blocks of the block at the given position
+func (sf *StandardFormat) blockIndexAt(x, y, z int) (int, bool) {
+	if !sf.index.valid(sf.Blocks) {
+		sf.BuildIndex()
+	}
+	i, ok := sf.index.positions[[3]int{x, y, z}]
+	return i, ok
+}
+
+// GetBlockAt returns the block at the given position. Positions are relative
+// to the schematic origin, the same space as StandardBlock.Position, not
+// world coordinates. Entities are never returned.
+func (sf *StandardFormat) GetBlockAt(x, y, z int) (*StandardBlock, bool) {
+	i, ok := sf.blockIndexAt(x, y, z)
+	if !ok {
+		return nil, false
+	}
+	return &sf.Blocks[i], true
+}
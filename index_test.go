@@ -0,0 +1,102 @@
+package mcnbt
+
+import (
+	"testing"
+)
+
+// BenchmarkGetBlockAt measures repeated lookups after a single index build
+func BenchmarkGetBlockAt(b *testing.B) {
+	data, err := ParseAnyFromFileAsJSON("testdata/color_field.litematic")
+	if err != nil {
+		b.Fatalf("Failed to parse fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		b.Fatalf("Failed to convert fixture: %v", err)
+	}
+	standard.BuildIndex()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x := i % standard.Size.X
+		y := (i / standard.Size.X) % standard.Size.Y
+		z := (i / (standard.Size.X * standard.Size.Y)) % standard.Size.Z
+		if _, ok := standard.GetBlockAt(x, y, z); !ok {
+			b.Fatalf("No block at %d,%d,%d", x, y, z)
+		}
+	}
+}
+
+// TestIndexInvalidation verifies the index is rebuilt when Blocks is replaced or grows
+func TestIndexInvalidation(t *testing.T) {
+	sf := newTestStandard(2, 1, 1)
+	addTestBlock(sf, 0, 0, 0, 1)
+	sf.BuildIndex()
+
+	if _, ok := sf.GetBlockAt(1, 0, 0); ok {
+		t.Fatalf("Expected no block at 1,0,0 yet")
+	}
+
+	addTestBlock(sf, 1, 0, 0, 1)
+	if _, ok := sf.GetBlockAt(1, 0, 0); !ok {
+		t.Errorf("Expected appended block to be found")
+	}
+
+	sf.Blocks = []StandardBlock{{Type: "block", State: 1, Position: StandardBlockPosition{X: 1}}}
+	if _, ok := sf.GetBlockAt(0, 0, 0); ok {
+		t.Errorf("Expected replaced Blocks to drop the block at 0,0,0")
+	}
+}
+
+// TestGetBlockAtMatchesLinearScan verifies indexed lookups agree with scanning Blocks
+func TestGetBlockAtMatchesLinearScan(t *testing.T) {
+	data, err := ParseAnyFromFileAsJSON("testdata/color_field.litematic")
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	for x := -1; x <= standard.Size.X; x++ {
+		for y := -1; y <= standard.Size.Y; y++ {
+			for z := -1; z <= standard.Size.Z; z++ {
+				var expected *StandardBlock
+				for i := range standard.Blocks {
+					b := &standard.Blocks[i]
+					if b.Position == (StandardBlockPosition{X: float64(x), Y: float64(y), Z: float64(z)}) {
+						expected = b
+					}
+				}
+
+				got, ok := standard.GetBlockAt(x, y, z)
+				if ok != (expected != nil) {
+					t.Fatalf("Lookup at %d,%d,%d: expected found=%v, got %v", x, y, z, expected != nil, ok)
+				}
+				if ok && got != expected {
+					t.Fatalf("Lookup at %d,%d,%d returned a different block than the scan", x, y, z)
+				}
+			}
+		}
+	}
+}
+
+// TestGetBlockAtSkipsEntities verifies entities never shadow the block at their position
+func TestGetBlockAtSkipsEntities(t *testing.T) {
+	sf := newTestStandard(1, 1, 1)
+	addTestBlock(sf, 0, 0, 0, 1)
+	sf.Blocks = append(sf.Blocks, StandardBlock{Type: "entity", ID: "minecraft:pig"})
+	sf.MigrateLegacyBlocks()
+
+	block, ok := sf.GetBlockAt(0, 0, 0)
+	if !ok {
+		t.Fatalf("Expected a block at 0,0,0")
+	}
+	if block.Type != "block" {
+		t.Errorf("Expected the block, got %s", block.Type)
+	}
+	if len(sf.Entities) != 1 {
+		t.Errorf("Expected the pig to move to Entities, got %d entities", len(sf.Entities))
+	}
+}
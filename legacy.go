@@ -0,0 +1,99 @@
+package mcnbt
+
+// isLegacyRecord reports whether a block is an entity or block entity record
+// as stored in Blocks by older versions
+func isLegacyRecord(block StandardBlock) bool {
+	switch block.Type {
+	case "entity", "block_entity", "tile_entity":
+		return true
+	}
+	return false
+}
+
+// hasLegacyBlocks reports whether Blocks still holds any legacy entity or
+// block entity records
+func (sf *StandardFormat) hasLegacyBlocks() bool {
+	for _, block := range sf.Blocks {
+		if isLegacyRecord(block) {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrateLegacyBlocks moves entity and block entity records that older
+// versions stored in Blocks into Entities and TileEntities. A block entity
+// record leaves a plain block behind at its position, named after its
+// palette entry. Standard data loaded from old JSON should be migrated once
+// before use; ConvertToStandard and ConvertFromStandard do so automatically.
+func (sf *StandardFormat) MigrateLegacyBlocks() {
+	if !sf.hasLegacyBlocks() {
+		return
+	}
+
+	blocks := make([]StandardBlock, 0, len(sf.Blocks))
+	for _, block := range sf.Blocks {
+		switch block.Type {
+		case "entity":
+			sf.Entities = append(sf.Entities, StandardEntity{
+				ID:       block.ID,
+				Position: block.Position,
+				Rotation: block.Rotation,
+				Motion:   block.Motion,
+				NBT:      block.NBT,
+			})
+			continue
+		case "block_entity", "tile_entity":
+			sf.TileEntities = append(sf.TileEntities, StandardTileEntity{
+				ID:       block.ID,
+				Position: block.Position,
+				NBT:      block.NBT,
+			})
+			block = StandardBlock{Type: "block", State: block.State, Position: block.Position}
+			if p, ok := sf.Palette[block.State]; ok {
+				block.ID = p.Name
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	sf.Blocks = blocks
+	sf.invalidateIndex()
+}
+
+// LegacyBlocks returns blocks, block entities and entities in the single
+// slice layout used by older versions: blocks holding block entity data
+// become "block_entity" records carrying its ID and NBT, followed by one
+// "entity" record per entity. sf is not modified.
+func (sf *StandardFormat) LegacyBlocks() []StandardBlock {
+	tileEntities := sf.tileEntitiesByPosition()
+	blocks := make([]StandardBlock, 0, len(sf.Blocks)+len(sf.Entities))
+	for _, block := range sf.Blocks {
+		if te, ok := tileEntities[positionKey(block.Position)]; ok {
+			block.Type = "block_entity"
+			block.ID = te.ID
+			block.NBT = te.NBT
+		}
+		blocks = append(blocks, block)
+	}
+	for _, e := range sf.Entities {
+		blocks = append(blocks, StandardBlock{
+			Type:     "entity",
+			ID:       e.ID,
+			Position: e.Position,
+			Rotation: e.Rotation,
+			Motion:   e.Motion,
+			NBT:      e.NBT,
+		})
+	}
+	return blocks
+}
+
+// tileEntitiesByPosition maps each block position to the block entity data
+// stored there
+func (sf *StandardFormat) tileEntitiesByPosition() map[[3]int]StandardTileEntity {
+	m := make(map[[3]int]StandardTileEntity, len(sf.TileEntities))
+	for _, te := range sf.TileEntities {
+		m[positionKey(te.Position)] = te
+	}
+	return m
+}
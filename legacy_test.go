@@ -0,0 +1,93 @@
+package mcnbt
+
+import (
+	"os"
+	"testing"
+)
+
+// TestEntitiesNotInBlocks verifies converters put entities and block entities in their own slices
+func TestEntitiesNotInBlocks(t *testing.T) {
+	payload, err := os.ReadFile("testdata/structure_block.nbt")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	data, err := DecodeAny(payload)
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	check := func(name string, sf *StandardFormat) {
+		for _, block := range sf.Blocks {
+			if block.Type != "block" {
+				t.Errorf("%s: unexpected %s record in Blocks at %+v", name, block.Type, block.Position)
+			}
+		}
+		if len(sf.Entities) != 1 || len(sf.TileEntities) != 1 {
+			t.Errorf("%s: expected 1 entity and 1 tile entity, got %d and %d", name, len(sf.Entities), len(sf.TileEntities))
+		}
+	}
+	check("structure", standard)
+
+	encoded, err := EncodeToBytes(standard, "create")
+	if err != nil {
+		t.Fatalf("Failed to encode create: %v", err)
+	}
+	data, err = DecodeAny(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode create: %v", err)
+	}
+	roundTripped, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert create: %v", err)
+	}
+	check("create", roundTripped)
+}
+
+// TestMigrateLegacyBlocks verifies old single-slice records migrate and convert back unchanged
+func TestMigrateLegacyBlocks(t *testing.T) {
+	sf := newTestStandard(2, 1, 1)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:chest"}
+	addTestBlock(sf, 0, 0, 0, 1)
+	chestNBT := map[string]interface{}{"id": "minecraft:chest", "CustomName": "Loot"}
+	sf.Blocks = append(sf.Blocks,
+		StandardBlock{Type: "block_entity", ID: "minecraft:chest", State: 2, Position: StandardBlockPosition{X: 1}, NBT: chestNBT},
+		StandardBlock{Type: "entity", ID: "minecraft:pig", Position: StandardBlockPosition{X: 0.5, Y: 1, Z: 0.5}, Rotation: StandardRotation{Yaw: 90}},
+	)
+	legacy := append([]StandardBlock(nil), sf.Blocks...)
+
+	sf.MigrateLegacyBlocks()
+	if len(sf.Blocks) != 2 || len(sf.TileEntities) != 1 || len(sf.Entities) != 1 {
+		t.Fatalf("Expected 2 blocks, 1 tile entity and 1 entity, got %d, %d and %d",
+			len(sf.Blocks), len(sf.TileEntities), len(sf.Entities))
+	}
+	if chest, ok := sf.GetBlockAt(1, 0, 0); !ok || chest.Type != "block" || chest.ID != "minecraft:chest" || chest.NBT != nil {
+		t.Errorf("Expected a plain chest block at 1,0,0, got %+v", chest)
+	}
+	if te := sf.TileEntities[0]; te.ID != "minecraft:chest" || te.Position != (StandardBlockPosition{X: 1}) {
+		t.Errorf("Unexpected tile entity %+v", te)
+	}
+	if e := sf.Entities[0]; e.ID != "minecraft:pig" || e.Rotation.Yaw != 90 {
+		t.Errorf("Unexpected entity %+v", e)
+	}
+
+	// Migrating again is a no-op
+	sf.MigrateLegacyBlocks()
+	if len(sf.Blocks) != 2 || len(sf.Entities) != 1 {
+		t.Errorf("Expected a second migration to change nothing")
+	}
+
+	back := sf.LegacyBlocks()
+	if len(back) != len(legacy) {
+		t.Fatalf("Expected %d legacy records, got %d", len(legacy), len(back))
+	}
+	for i := range legacy {
+		if back[i].Type != legacy[i].Type || back[i].ID != legacy[i].ID || back[i].Position != legacy[i].Position {
+			t.Errorf("Record %d: expected %s %s at %+v, got %s %s at %+v", i,
+				legacy[i].Type, legacy[i].ID, legacy[i].Position, back[i].Type, back[i].ID, back[i].Position)
+		}
+	}
+}
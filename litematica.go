@@ -1,5 +1,72 @@
 package mcnbt
 
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// PreviewImage holds Litematica preview pixels. Most files store them as an
+// int array of ARGB values, but some store a byte array for smaller previews;
+// both decode into one value per element.
+type PreviewImage []int32
+
+// UnmarshalJSON accepts either a number array or a base64 byte array
+func (p *PreviewImage) UnmarshalJSON(data []byte) error {
+	var ints []int32
+	if err := json.Unmarshal(data, &ints); err == nil {
+		*p = ints
+		return nil
+	}
+
+	var raw []byte
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("preview image is neither an int nor a byte array: %w", err)
+	}
+	*p = previewFromBytes(raw)
+	return nil
+}
+
+// UnmarshalNBT accepts either a TAG_Int_Array or a TAG_Byte_Array
+func (p *PreviewImage) UnmarshalNBT(tagType byte, r nbt.DecoderReader) error {
+	var n int32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return err
+	}
+
+	switch tagType {
+	case nbt.TagIntArray:
+		ints := make([]int32, n)
+		if err := binary.Read(r, binary.BigEndian, ints); err != nil {
+			return err
+		}
+		*p = ints
+	case nbt.TagByteArray:
+		raw := make([]byte, n)
+		if _, err := io.ReadFull(r, raw); err != nil {
+			return err
+		}
+		*p = previewFromBytes(raw)
+	default:
+		return fmt.Errorf("cannot decode preview image from tag type 0x%x", tagType)
+	}
+	return nil
+}
+
+// previewFromBytes widens byte preview data to one int per byte
+func previewFromBytes(raw []byte) PreviewImage {
+	ints := make(PreviewImage, len(raw))
+	for i, b := range raw {
+		ints[i] = int32(b)
+	}
+	return ints
+}
+
 // EntityItem represents an item held by an entity
 type EntityItem struct {
 	Count int8   `json:"Count" nbt:"Count"`
@@ -20,16 +87,16 @@ type EntityBrain struct {
 
 // LitematicaMetadata represents the metadata of a litematica schematic
 type LitematicaMetadata struct {
-	Author           string     `json:"Author" nbt:"Author"`
-	Description      string     `json:"Description" nbt:"Description"`
-	EnclosingSize    Coordinate `json:"EnclosingSize" nbt:"EnclosingSize"`
-	Name             string     `json:"Name" nbt:"Name"`
-	PreviewImageData []int32    `json:"PreviewImageData" nbt:"PreviewImageData"`
-	RegionCount      int32      `json:"RegionCount" nbt:"RegionCount"`
-	TimeCreated      int64      `json:"TimeCreated" nbt:"TimeCreated"`
-	TimeModified     int64      `json:"TimeModified" nbt:"TimeModified"`
-	TotalBlocks      int32      `json:"TotalBlocks" nbt:"TotalBlocks"`
-	TotalVolume      int32      `json:"TotalVolume" nbt:"TotalVolume"`
+	Author           string       `json:"Author" nbt:"Author"`
+	Description      string       `json:"Description" nbt:"Description"`
+	EnclosingSize    Coordinate   `json:"EnclosingSize" nbt:"EnclosingSize"`
+	Name             string       `json:"Name" nbt:"Name"`
+	PreviewImageData PreviewImage `json:"PreviewImageData" nbt:"PreviewImageData"`
+	RegionCount      int32        `json:"RegionCount" nbt:"RegionCount"`
+	TimeCreated      int64        `json:"TimeCreated" nbt:"TimeCreated"`
+	TimeModified     int64        `json:"TimeModified" nbt:"TimeModified"`
+	TotalBlocks      int32        `json:"TotalBlocks" nbt:"TotalBlocks"`
+	TotalVolume      int32        `json:"TotalVolume" nbt:"TotalVolume"`
 }
 
 // LitematicaBlockStatePalette represents a block state in the palette
@@ -40,33 +107,34 @@ type LitematicaBlockStatePalette struct {
 
 // LitematicaEntity represents an entity in a litematica schematic
 type LitematicaEntity struct {
-	AbsorptionAmount    int32             `json:"AbsorptionAmount" nbt:"AbsorptionAmount"`
-	Air                 int32             `json:"Air" nbt:"Air"`
-	ArmorDropChances    []float32         `json:"ArmorDropChances" nbt:"ArmorDropChances"`
-	ArmorItems          []EntityItem      `json:"ArmorItems" nbt:"ArmorItems"`
-	Attributes          []EntityAttribute `json:"Attributes" nbt:"Attributes"`
-	BatFlags            int32             `json:"BatFlags" nbt:"BatFlags"`
-	Brain               EntityBrain       `json:"Brain" nbt:"Brain"`
-	CanPickUpLoot       int32             `json:"CanPickUpLoot" nbt:"CanPickUpLoot"`
-	DeathTime           int32             `json:"DeathTime" nbt:"DeathTime"`
-	FallDistance        int32             `json:"FallDistance" nbt:"FallDistance"`
-	FallFlying          int32             `json:"FallFlying" nbt:"FallFlying"`
-	Fire                int32             `json:"Fire" nbt:"Fire"`
-	HandDropChances     []float32         `json:"HandDropChances" nbt:"HandDropChances"`
-	HandItems           []EntityItem      `json:"HandItems" nbt:"HandItems"`
-	Health              int32             `json:"Health" nbt:"Health"`
-	HurtByTimestamp     int32             `json:"HurtByTimestamp" nbt:"HurtByTimestamp"`
-	HurtTime            int32             `json:"HurtTime" nbt:"HurtTime"`
-	Invulnerable        int32             `json:"Invulnerable" nbt:"Invulnerable"`
-	LeftHanded          int32             `json:"LeftHanded" nbt:"LeftHanded"`
-	Motion              []float64         `json:"Motion" nbt:"Motion"`
-	OnGround            int32             `json:"OnGround" nbt:"OnGround"`
-	PersistenceRequired int32             `json:"PersistenceRequired" nbt:"PersistenceRequired"`
-	PortalCooldown      int32             `json:"PortalCooldown" nbt:"PortalCooldown"`
-	Pos                 []float64         `json:"Pos" nbt:"Pos"`
-	Rotation            []float32         `json:"Rotation" nbt:"Rotation"`
-	UUID                []int32           `json:"UUID" nbt:"UUID"`
-	ID                  string            `json:"id" nbt:"id"`
+	AbsorptionAmount    int32              `json:"AbsorptionAmount" nbt:"AbsorptionAmount"`
+	Air                 int32              `json:"Air" nbt:"Air"`
+	ArmorDropChances    []float32          `json:"ArmorDropChances" nbt:"ArmorDropChances"`
+	ArmorItems          []EntityItem       `json:"ArmorItems" nbt:"ArmorItems"`
+	Attributes          []EntityAttribute  `json:"Attributes" nbt:"Attributes"`
+	BatFlags            int32              `json:"BatFlags" nbt:"BatFlags"`
+	Brain               EntityBrain        `json:"Brain" nbt:"Brain"`
+	CanPickUpLoot       int32              `json:"CanPickUpLoot" nbt:"CanPickUpLoot"`
+	DeathTime           int32              `json:"DeathTime" nbt:"DeathTime"`
+	FallDistance        int32              `json:"FallDistance" nbt:"FallDistance"`
+	FallFlying          int32              `json:"FallFlying" nbt:"FallFlying"`
+	Fire                int32              `json:"Fire" nbt:"Fire"`
+	HandDropChances     []float32          `json:"HandDropChances" nbt:"HandDropChances"`
+	HandItems           []EntityItem       `json:"HandItems" nbt:"HandItems"`
+	Health              int32              `json:"Health" nbt:"Health"`
+	HurtByTimestamp     int32              `json:"HurtByTimestamp" nbt:"HurtByTimestamp"`
+	HurtTime            int32              `json:"HurtTime" nbt:"HurtTime"`
+	Invulnerable        int32              `json:"Invulnerable" nbt:"Invulnerable"`
+	LeftHanded          int32              `json:"LeftHanded" nbt:"LeftHanded"`
+	Motion              []float64          `json:"Motion" nbt:"Motion"`
+	OnGround            int32              `json:"OnGround" nbt:"OnGround"`
+	PersistenceRequired int32              `json:"PersistenceRequired" nbt:"PersistenceRequired"`
+	PortalCooldown      int32              `json:"PortalCooldown" nbt:"PortalCooldown"`
+	Pos                 []float64          `json:"Pos" nbt:"Pos"`
+	Rotation            []float32          `json:"Rotation" nbt:"Rotation"`
+	UUID                []int32            `json:"UUID" nbt:"UUID"`
+	ID                  string             `json:"id" nbt:"id"`
+	Passengers          []LitematicaEntity `json:"Passengers,omitempty" nbt:"Passengers,omitempty"`
 }
 
 // LitematicaTileEntity represents a tile entity in a litematica schematic
@@ -79,6 +147,8 @@ type LitematicaTileEntity struct {
 	CookingTimes      []int32       `json:"CookingTimes,omitempty" nbt:"CookingTimes,omitempty"`
 	CookingTotalTimes []int32       `json:"CookingTotalTimes,omitempty" nbt:"CookingTotalTimes,omitempty"`
 	Bees              []interface{} `json:"Bees,omitempty" nbt:"Bees,omitempty"`
+	CustomName        string        `json:"CustomName,omitempty" nbt:"CustomName,omitempty"`
+	Lock              string        `json:"Lock,omitempty" nbt:"Lock,omitempty"`
 }
 
 // LitematicaRegion represents a region in a litematica schematic
@@ -86,13 +156,26 @@ type LitematicaRegion struct {
 	BlockStatePalette []LitematicaBlockStatePalette `json:"BlockStatePalette" nbt:"BlockStatePalette"`
 	BlockStates       []int64                       `json:"BlockStates" nbt:"BlockStates"`
 	Entities          []LitematicaEntity            `json:"Entities" nbt:"Entities"`
-	PendingBlockTicks []interface{}                 `json:"PendingBlockTicks" nbt:"PendingBlockTicks"`
-	PendingFluidTicks []interface{}                 `json:"PendingFluidTicks" nbt:"PendingFluidTicks"`
+	PendingBlockTicks []LitematicaPendingTick       `json:"PendingBlockTicks" nbt:"PendingBlockTicks"`
+	PendingFluidTicks []LitematicaPendingTick       `json:"PendingFluidTicks" nbt:"PendingFluidTicks"`
 	Position          Coordinate                    `json:"Position" nbt:"Position"`
 	Size              Coordinate                    `json:"Size" nbt:"Size"`
 	TileEntities      []LitematicaTileEntity        `json:"TileEntities" nbt:"TileEntities"`
 }
 
+// LitematicaPendingTick is a scheduled block or fluid update. Block ticks set
+// Block and fluid ticks set Fluid; positions are relative to the region.
+type LitematicaPendingTick struct {
+	Block    string `json:"Block,omitempty" nbt:"Block,omitempty"`
+	Fluid    string `json:"Fluid,omitempty" nbt:"Fluid,omitempty"`
+	Priority int32  `json:"Priority" nbt:"Priority"`
+	SubTick  int64  `json:"SubTick" nbt:"SubTick"`
+	Time     int32  `json:"Time" nbt:"Time"`
+	X        int32  `json:"x" nbt:"x"`
+	Y        int32  `json:"y" nbt:"y"`
+	Z        int32  `json:"z" nbt:"z"`
+}
+
 // LitematicaNBT represents a litematica schematic
 type LitematicaNBT struct {
 	Metadata             LitematicaMetadata          `json:"Metadata" nbt:"Metadata"`
@@ -101,3 +184,155 @@ type LitematicaNBT struct {
 	SubVersion           int32                       `json:"SubVersion" nbt:"SubVersion"`
 	Version              int32                       `json:"Version" nbt:"Version"`
 }
+
+// litematicaBitsPerEntry returns the bits used per packed block state: enough
+// to hold the largest palette index, with a minimum of 2
+func litematicaBitsPerEntry(paletteSize int) int {
+	bitsPerEntry := 2
+	if paletteSize > 0 {
+		if b := bits.Len(uint(paletteSize - 1)); b > bitsPerEntry {
+			bitsPerEntry = b
+		}
+	}
+	return bitsPerEntry
+}
+
+// decodeLitematicaBlockStates unpacks volume palette indices from a Litematica
+// BlockStates long array. Entries are packed back to back, so an entry whose
+// bits don't fit in the rest of a long continues in the low bits of the next one.
+func decodeLitematicaBlockStates(states []int64, paletteSize, volume int) []int {
+	bitsPerEntry := litematicaBitsPerEntry(paletteSize)
+
+	indices := make([]int, volume)
+	for i := 0; i < volume; i++ {
+		index, ok := litematicaStateAt(states, bitsPerEntry, i)
+		if !ok {
+			break
+		}
+		indices[i] = index
+	}
+	return indices
+}
+
+// litematicaStateAt unpacks the i-th palette index from a BlockStates long
+// array. ok is false when the array ends before entry i.
+func litematicaStateAt(states []int64, bitsPerEntry, i int) (index int, ok bool) {
+	startBit := i * bitsPerEntry
+	startLong := startBit / 64
+	endLong := (startBit + bitsPerEntry - 1) / 64
+	offset := uint(startBit % 64)
+
+	if endLong >= len(states) {
+		return 0, false
+	}
+
+	value := uint64(states[startLong]) >> offset
+	if endLong != startLong {
+		value |= uint64(states[endLong]) << (64 - offset)
+	}
+	return int(value & (uint64(1)<<bitsPerEntry - 1)), true
+}
+
+// packLitematicaBlockStates packs palette indices into a Litematica BlockStates
+// long array, the inverse of decodeLitematicaBlockStates
+func packLitematicaBlockStates(indices []int, paletteSize int) []int64 {
+	bitsPerEntry := litematicaBitsPerEntry(paletteSize)
+	mask := uint64(1)<<bitsPerEntry - 1
+
+	packed := make([]uint64, (len(indices)*bitsPerEntry+63)/64)
+	for i, index := range indices {
+		value := uint64(index) & mask
+		startBit := i * bitsPerEntry
+		startLong := startBit / 64
+		endLong := (startBit + bitsPerEntry - 1) / 64
+		offset := uint(startBit % 64)
+
+		packed[startLong] |= value << offset
+		if endLong != startLong {
+			packed[endLong] |= value >> (64 - offset)
+		}
+	}
+
+	result := make([]int64, len(packed))
+	for i, v := range packed {
+		result[i] = int64(v)
+	}
+	return result
+}
+
+// firstLitematicaRegion returns the region sorted first by name, which is the
+// one converted to the standard format
+func firstLitematicaRegion[R any](regions map[string]R) (region R, ok bool) {
+	names := make([]string, 0, len(regions))
+	for name := range regions {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return region, false
+	}
+	sort.Strings(names)
+	return regions[names[0]], true
+}
+
+// litematicaBlocksOnly is the part of a Litematica file DecodeLitematicaBlocks
+// reads. Every other tag is skipped while decoding.
+type litematicaBlocksOnly struct {
+	Regions map[string]litematicaRegionBlocks `nbt:"Regions"`
+}
+
+// litematicaRegionBlocks is the part of a region holding its blocks
+type litematicaRegionBlocks struct {
+	BlockStatePalette []LitematicaBlockStatePalette `nbt:"BlockStatePalette"`
+	BlockStates       []int64                       `nbt:"BlockStates"`
+	Size              Coordinate                    `nbt:"Size"`
+}
+
+// DecodeLitematicaBlocks reads a Litematica file from r and calls fn with each
+// block of the region ConvertToStandard would read, in the same YZX order
+// and with the same palette indices. Blocks are unpacked one at a time
+// instead of being collected into a slice, so memory use stays close to the
+// size of the packed file. Entities, block entities and metadata are skipped.
+// Iteration stops at the first error fn returns, which is returned as is.
+func DecodeLitematicaBlocks(r io.Reader, fn func(StandardBlock) error) error {
+	nbtReader, _, closeFn, err := openNBTStream(r)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	var file litematicaBlocksOnly
+	if _, err := nbt.NewDecoder(nbtReader).Decode(&file); err != nil {
+		return fmt.Errorf("failed to decode litematica NBT: %w", err)
+	}
+
+	region, ok := firstLitematicaRegion(file.Regions)
+	if !ok {
+		return ErrNoRegions
+	}
+
+	sizeX, sizeY, sizeZ := abs(int(region.Size.X)), abs(int(region.Size.Y)), abs(int(region.Size.Z))
+	bitsPerEntry := litematicaBitsPerEntry(len(region.BlockStatePalette))
+	i := 0
+	for y := 0; y < sizeY; y++ {
+		for z := 0; z < sizeZ; z++ {
+			for x := 0; x < sizeX; x++ {
+				// Like ConvertToStandard, entries missing from a short array read as 0
+				state, _ := litematicaStateAt(region.BlockStates, bitsPerEntry, i)
+				i++
+
+				block := StandardBlock{
+					Type:     "block",
+					State:    state,
+					Position: StandardBlockPosition{X: float64(x), Y: float64(y), Z: float64(z)},
+				}
+				if state < len(region.BlockStatePalette) {
+					block.ID = region.BlockStatePalette[state].Name
+				}
+				if err := fn(block); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
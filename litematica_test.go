@@ -0,0 +1,578 @@
+package mcnbt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// newTestLitematica builds a single-region litematica with one stone block
+func newTestLitematica(version int32, position, size Coordinate) *LitematicaNBT {
+	return &LitematicaNBT{
+		Version: version,
+		Regions: map[string]LitematicaRegion{
+			"main": {
+				BlockStatePalette: []LitematicaBlockStatePalette{
+					{Name: "minecraft:air"},
+					{Name: "minecraft:stone"},
+				},
+				BlockStates: []int64{1},
+				Position:    position,
+				Size:        size,
+			},
+		},
+	}
+}
+
+// TestLitematicaVersionPositions verifies region positions for supported versions
+func TestLitematicaVersionPositions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		version  int32
+		position Coordinate
+		size     Coordinate
+		expected StandardPosition
+	}{
+		{"version 5 positive size", 5, Coordinate{X: 10, Y: 64, Z: -3}, Coordinate{X: 2, Y: 2, Z: 2}, StandardPosition{X: 10, Y: 64, Z: -3}},
+		{"version 5 negative size", 5, Coordinate{X: 10, Y: 64, Z: -3}, Coordinate{X: -2, Y: 2, Z: -4}, StandardPosition{X: 9, Y: 64, Z: -6}},
+		{"version 6 positive size", 6, Coordinate{X: 0, Y: 22, Z: 0}, Coordinate{X: 3, Y: 4, Z: 5}, StandardPosition{X: 0, Y: 22, Z: 0}},
+		{"version 6 negative size", 6, Coordinate{X: 0, Y: 22, Z: 0}, Coordinate{X: 3, Y: -23, Z: 5}, StandardPosition{X: 0, Y: 0, Z: 0}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			standard, err := ConvertToStandard(newTestLitematica(tc.version, tc.position, tc.size))
+			if err != nil {
+				t.Fatalf("Failed to convert: %v", err)
+			}
+			if standard.Position != tc.expected {
+				t.Errorf("Expected position %+v, got %+v", tc.expected, standard.Position)
+			}
+		})
+	}
+}
+
+// TestLitematicaUnsupportedVersion verifies unknown versions are rejected
+func TestLitematicaUnsupportedVersion(t *testing.T) {
+	litematica := newTestLitematica(99, Coordinate{}, Coordinate{X: 1, Y: 1, Z: 1})
+	if _, err := ConvertToStandard(litematica); err == nil {
+		t.Errorf("Expected an error for unsupported version 99")
+	}
+}
+
+// TestLitematicaBlockStatesTypes verifies every long array representation unpacks identically
+func TestLitematicaBlockStatesTypes(t *testing.T) {
+	// Two stone blocks with the top bit set in the packed long so that
+	// unsigned and signed representations differ
+	packed := int64(1) | int64(1)<<2 | int64(-1)<<63
+	testCases := map[string]interface{}{
+		"int64":     []int64{packed},
+		"uint64":    []uint64{uint64(packed)},
+		"long list": []interface{}{packed},
+	}
+
+	for name, states := range testCases {
+		t.Run(name, func(t *testing.T) {
+			data := map[string]interface{}{
+				"Version":  int32(6),
+				"Metadata": map[string]interface{}{},
+				"Regions": map[string]interface{}{
+					"main": map[string]interface{}{
+						"BlockStatePalette": []interface{}{
+							map[string]interface{}{"Name": "minecraft:air"},
+							map[string]interface{}{"Name": "minecraft:stone"},
+						},
+						"BlockStates": states,
+						"Position":    map[string]interface{}{"x": int32(0), "y": int32(0), "z": int32(0)},
+						"Size":        map[string]interface{}{"x": int32(2), "y": int32(1), "z": int32(1)},
+					},
+				},
+			}
+
+			standard, err := ConvertToStandard(data)
+			if err != nil {
+				t.Fatalf("Failed to convert: %v", err)
+			}
+			if len(standard.Blocks) != 2 {
+				t.Fatalf("Expected 2 blocks, got %d", len(standard.Blocks))
+			}
+			for i, block := range standard.Blocks {
+				if block.State != 1 {
+					t.Errorf("Block %d: expected state 1, got %d", i, block.State)
+				}
+			}
+		})
+	}
+}
+
+// TestLitematicaBytePreviewImage verifies byte-array previews are read through both decode paths
+func TestLitematicaBytePreviewImage(t *testing.T) {
+	fixture := map[string]interface{}{
+		"Version":              int32(6),
+		"MinecraftDataVersion": int32(3465),
+		"Metadata": map[string]interface{}{
+			"Name":             "preview",
+			"PreviewImageData": []byte{1, 2, 255},
+		},
+		"Regions": map[string]interface{}{
+			"main": map[string]interface{}{
+				"BlockStatePalette": []map[string]interface{}{{"Name": "minecraft:air"}},
+				"BlockStates":       []int64{0},
+				"Position":          map[string]int32{"x": 0, "y": 0, "z": 0},
+				"Size":              map[string]int32{"x": 1, "y": 1, "z": 1},
+			},
+		},
+	}
+	encoded := encodeTestNBT(t, fixture)
+	expected := []int{1, 2, 255}
+
+	data, err := DecodeAny(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+	if len(standard.Metadata.PreviewImageData) != len(expected) {
+		t.Fatalf("Expected %d preview values, got %v", len(expected), standard.Metadata.PreviewImageData)
+	}
+	for i, v := range expected {
+		if standard.Metadata.PreviewImageData[i] != v {
+			t.Errorf("Preview[%d]: expected %d, got %d", i, v, standard.Metadata.PreviewImageData[i])
+		}
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	var typed LitematicaNBT
+	if _, err := nbt.NewDecoder(gz).Decode(&typed); err != nil {
+		t.Fatalf("Failed to decode fixture into LitematicaNBT: %v", err)
+	}
+	if len(typed.Metadata.PreviewImageData) != len(expected) || typed.Metadata.PreviewImageData[2] != 255 {
+		t.Errorf("Unexpected typed preview data: %v", typed.Metadata.PreviewImageData)
+	}
+}
+
+// TestDecodeLitematicaBlockStates verifies the fixture's unpacked non-air blocks match Metadata.TotalBlocks
+func TestDecodeLitematicaBlockStates(t *testing.T) {
+	data, err := ParseAnyFromFileAsJSON("testdata/color_field.litematic")
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	var litematica LitematicaNBT
+	raw, err := os.ReadFile("testdata/color_field.litematic")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	if _, err := nbt.NewDecoder(gz).Decode(&litematica); err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+
+	for _, region := range litematica.Regions {
+		volume := abs(int(region.Size.X * region.Size.Y * region.Size.Z))
+		indices := decodeLitematicaBlockStates(region.BlockStates, len(region.BlockStatePalette), volume)
+
+		nonAir := 0
+		for _, index := range indices {
+			if index >= len(region.BlockStatePalette) {
+				t.Fatalf("Decoded palette index %d out of range", index)
+			}
+			if region.BlockStatePalette[index].Name != "minecraft:air" {
+				nonAir++
+			}
+		}
+
+		// The fixture's metadata was written by Litematica and is one lower than
+		// the non-air count that every decoder (including the WorldEdit and
+		// Create copies of the same build) agrees on, so allow a difference of one
+		if diff := nonAir - int(litematica.Metadata.TotalBlocks); diff < -1 || diff > 1 {
+			t.Errorf("Expected about %d non-air blocks, got %d", litematica.Metadata.TotalBlocks, nonAir)
+		}
+	}
+
+	if len(standard.Blocks) != standard.Size.X*standard.Size.Y*standard.Size.Z {
+		t.Errorf("Expected a full volume of blocks, got %d", len(standard.Blocks))
+	}
+}
+
+// TestLitematicaBlockStatesSpanLongs verifies packing and unpacking round-trip
+// when entries straddle long boundaries
+func TestLitematicaBlockStatesSpanLongs(t *testing.T) {
+	for _, paletteSize := range []int{2, 5, 33, 100, 4096} {
+		indices := make([]int, 1000)
+		for i := range indices {
+			indices[i] = (i * 7919) % paletteSize
+		}
+
+		packed := packLitematicaBlockStates(indices, paletteSize)
+		decoded := decodeLitematicaBlockStates(packed, paletteSize, len(indices))
+
+		for i := range indices {
+			if decoded[i] != indices[i] {
+				t.Fatalf("Palette size %d: index %d decoded as %d, expected %d", paletteSize, i, decoded[i], indices[i])
+			}
+		}
+	}
+}
+
+// TestLitematicaEntitiesOnlyFromEntityBlocks verifies plain blocks never become litematica entities
+func TestLitematicaEntitiesOnlyFromEntityBlocks(t *testing.T) {
+	sf := newTestStandard(10, 1, 5)
+	for z := 0; z < 5; z++ {
+		for x := 0; x < 10; x++ {
+			addTestBlock(sf, x, 0, z, 1)
+		}
+	}
+	sf.Entities = append(sf.Entities,
+		StandardEntity{ID: "minecraft:pig", Position: StandardBlockPosition{X: 1.5, Y: 1, Z: 1.5}},
+		StandardEntity{ID: "minecraft:cow", Position: StandardBlockPosition{X: 4.5, Y: 1, Z: 2.5}},
+	)
+
+	converted, err := ConvertFromStandard(sf, "litematica")
+	if err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+	for _, region := range converted.(*LitematicaNBT).Regions {
+		if len(region.Entities) != 2 {
+			t.Errorf("Expected 2 entities, got %d", len(region.Entities))
+		}
+		for _, e := range region.Entities {
+			if e.ID == "" {
+				t.Errorf("Unexpected entity with empty ID: %+v", e)
+			}
+		}
+		if len(region.TileEntities) != 0 {
+			t.Errorf("Expected no tile entities, got %d", len(region.TileEntities))
+		}
+	}
+}
+
+// TestToLitematicaRegions verifies partitioning by Y band produces one cropped region per band
+func TestToLitematicaRegions(t *testing.T) {
+	sf := newTestStandard(2, 4, 2)
+	sf.Position = StandardPosition{X: 10, Y: 60, Z: -5}
+	for y := 0; y < 4; y++ {
+		for z := 0; z < 2; z++ {
+			for x := 0; x < 2; x++ {
+				addTestBlock(sf, x, y, z, 1)
+			}
+		}
+	}
+	sf.Extra = map[string]interface{}{
+		"PendingBlockTicks": []LitematicaPendingTick{{Block: "minecraft:repeater", Time: 2, X: 1, Y: 3, Z: 0}},
+		"PendingFluidTicks": []LitematicaPendingTick{{Fluid: "minecraft:water", Time: 5, X: 0, Y: 1, Z: 1}},
+	}
+
+	litematica, err := sf.ToLitematicaRegions(func(block StandardBlock) string {
+		if block.Position.Y < 2 {
+			return "lower"
+		}
+		return "upper"
+	})
+	if err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+
+	if len(litematica.Regions) != 2 || litematica.Metadata.RegionCount != 2 {
+		t.Fatalf("Expected 2 regions, got %d", len(litematica.Regions))
+	}
+	if litematica.Metadata.TotalBlocks != 16 {
+		t.Errorf("Expected 16 total blocks, got %d", litematica.Metadata.TotalBlocks)
+	}
+
+	upper := litematica.Regions["upper"]
+	if upper.Size != (Coordinate{X: 2, Y: 2, Z: 2}) {
+		t.Errorf("Expected upper region size 2x2x2, got %+v", upper.Size)
+	}
+	if upper.Position != (Coordinate{X: 10, Y: 62, Z: -5}) {
+		t.Errorf("Unexpected upper region position: %+v", upper.Position)
+	}
+	if upper.BlockStatePalette[0].Name != "minecraft:air" {
+		t.Errorf("Expected air at palette index 0, got %s", upper.BlockStatePalette[0].Name)
+	}
+
+	// Ticks follow their block into its region, relative to the region
+	lower := litematica.Regions["lower"]
+	if len(upper.PendingBlockTicks) != 1 || upper.PendingBlockTicks[0].Y != 1 || len(upper.PendingFluidTicks) != 0 {
+		t.Errorf("Expected the repeater tick at y 1 of the upper region, got %+v %+v", upper.PendingBlockTicks, upper.PendingFluidTicks)
+	}
+	if len(lower.PendingFluidTicks) != 1 || lower.PendingFluidTicks[0].Z != 1 || len(lower.PendingBlockTicks) != 0 {
+		t.Errorf("Expected the water tick in the lower region, got %+v %+v", lower.PendingFluidTicks, lower.PendingBlockTicks)
+	}
+
+	if _, err := EncodeToBytes(litematica, "litematica"); err != nil {
+		t.Errorf("Failed to encode multi-region litematica: %v", err)
+	}
+}
+
+// TestLitematicaRegionList verifies regions stored as a list decode with their own or synthesized names
+func TestLitematicaRegionList(t *testing.T) {
+	region := func(x int32) map[string]interface{} {
+		return map[string]interface{}{
+			"BlockStatePalette": []map[string]interface{}{{"Name": "minecraft:air"}, {"Name": "minecraft:stone"}},
+			"BlockStates":       []int64{1},
+			"Position":          map[string]int32{"x": x, "y": 0, "z": 0},
+			"Size":              map[string]int32{"x": 1, "y": 1, "z": 1},
+		}
+	}
+	named := region(0)
+	named["Name"] = "tower"
+
+	fixture := map[string]interface{}{
+		"Version":              int32(6),
+		"MinecraftDataVersion": int32(3465),
+		"Metadata":             map[string]interface{}{"Name": "listed"},
+		"Regions":              []map[string]interface{}{named, region(2)},
+	}
+
+	data, err := DecodeAny(encodeTestNBT(t, fixture))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	root := (*data.(*interface{})).(map[string]interface{})
+	regions := normalizeLitematicaBlockStates(root)["Regions"].(map[string]interface{})
+	if _, ok := regions["tower"]; !ok {
+		t.Errorf("Expected region named tower, got %v", regions)
+	}
+	if _, ok := regions["Region 2"]; !ok {
+		t.Errorf("Expected synthesized name Region 2, got %v", regions)
+	}
+
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+	if len(standard.Blocks) != 1 || standard.Palette[standard.Blocks[0].State].Name != "minecraft:stone" {
+		t.Errorf("Expected a single stone block, got %+v", standard.Blocks)
+	}
+}
+
+// TestLitematicaPendingTicksRoundTrip verifies scheduled fluid ticks survive a litematica round trip
+func TestLitematicaPendingTicksRoundTrip(t *testing.T) {
+	fixture := map[string]interface{}{
+		"Version":              int32(6),
+		"MinecraftDataVersion": int32(3465),
+		"Metadata":             map[string]interface{}{"Name": "ticks"},
+		"Regions": map[string]interface{}{
+			"main": map[string]interface{}{
+				"BlockStatePalette": []map[string]interface{}{{"Name": "minecraft:air"}, {"Name": "minecraft:water"}},
+				"BlockStates":       []int64{1},
+				"Position":          map[string]int32{"x": 0, "y": 0, "z": 0},
+				"Size":              map[string]int32{"x": 1, "y": 1, "z": 1},
+				"PendingFluidTicks": []map[string]interface{}{
+					{"Fluid": "minecraft:water", "Priority": int32(0), "SubTick": int64(7), "Time": int32(5), "x": int32(0), "y": int32(0), "z": int32(0)},
+				},
+			},
+		},
+	}
+
+	data, err := DecodeAny(encodeTestNBT(t, fixture))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	encoded, err := EncodeToBytes(standard, "litematica")
+	if err != nil {
+		t.Fatalf("Failed to encode litematica: %v", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("Failed to open encoded litematica: %v", err)
+	}
+	var typed LitematicaNBT
+	if _, err := nbt.NewDecoder(gz).Decode(&typed); err != nil {
+		t.Fatalf("Failed to decode encoded litematica: %v", err)
+	}
+
+	expected := LitematicaPendingTick{Fluid: "minecraft:water", SubTick: 7, Time: 5}
+	for _, region := range typed.Regions {
+		if len(region.PendingFluidTicks) != 1 || region.PendingFluidTicks[0] != expected {
+			t.Errorf("Expected fluid tick %+v, got %+v", expected, region.PendingFluidTicks)
+		}
+		if len(region.PendingBlockTicks) != 0 {
+			t.Errorf("Expected no block ticks, got %+v", region.PendingBlockTicks)
+		}
+	}
+}
+
+// TestLitematicaNegativeSizeRoundTrip verifies a region growing in the negative
+// direction is written back with the same anchor and is not mirrored
+func TestLitematicaNegativeSizeRoundTrip(t *testing.T) {
+	original := newTestLitematica(6, Coordinate{X: 10, Y: 64, Z: 0}, Coordinate{X: -3, Y: 1, Z: 1})
+	region := original.Regions["main"]
+	region.BlockStatePalette = append(region.BlockStatePalette,
+		LitematicaBlockStatePalette{Name: "minecraft:glass"},
+		LitematicaBlockStatePalette{Name: "minecraft:dirt"},
+	)
+	region.BlockStates = packLitematicaBlockStates([]int{1, 2, 3}, len(region.BlockStatePalette))
+	original.Regions["main"] = region
+
+	standard, err := ConvertToStandard(original)
+	if err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+	out, err := ConvertFromStandard(standard, "litematica")
+	if err != nil {
+		t.Fatalf("Failed to convert back: %v", err)
+	}
+
+	for _, r := range out.(*LitematicaNBT).Regions {
+		if r.Size != region.Size || r.Position != region.Position {
+			t.Errorf("Expected position %+v size %+v, got position %+v size %+v", region.Position, region.Size, r.Position, r.Size)
+		}
+	}
+
+	roundTripped, err := ConvertToStandard(out)
+	if err != nil {
+		t.Fatalf("Failed to convert round trip: %v", err)
+	}
+	if roundTripped.Position != standard.Position {
+		t.Errorf("Expected min corner %+v, got %+v", standard.Position, roundTripped.Position)
+	}
+	for x, name := range []string{"minecraft:stone", "minecraft:glass", "minecraft:dirt"} {
+		block, ok := roundTripped.GetBlockAt(x, 0, 0)
+		if !ok || roundTripped.Palette[block.State].Name != name {
+			t.Errorf("Expected %s at x=%d", name, x)
+		}
+	}
+}
+
+// TestDecodeLitematicaBlocks verifies the streaming decoder visits the same
+// blocks ConvertToStandard collects, and stops when the callback fails
+func TestDecodeLitematicaBlocks(t *testing.T) {
+	data, err := ParseAnyFromFileAsJSON("testdata/color_field.litematic")
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	file, err := os.Open("testdata/color_field.litematic")
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer file.Close()
+
+	count := 0
+	err = DecodeLitematicaBlocks(file, func(b StandardBlock) error {
+		if count < len(standard.Blocks) {
+			if expected := standard.Blocks[count]; b.Position != expected.Position || b.State != expected.State || b.ID != expected.ID {
+				return fmt.Errorf("block %d: expected %+v, got %+v", count, expected, b)
+			}
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to stream blocks: %v", err)
+	}
+	if count != len(standard.Blocks) {
+		t.Errorf("Expected %d blocks, got %d", len(standard.Blocks), count)
+	}
+
+	eachCount := 0
+	if err := standard.EachBlock(func(StandardBlock) error { eachCount++; return nil }); err != nil || eachCount != len(standard.Blocks) {
+		t.Errorf("Expected EachBlock to visit %d blocks, got %d (%v)", len(standard.Blocks), eachCount, err)
+	}
+
+	stop := errors.New("stop")
+	for name, iterate := range map[string]func(func(StandardBlock) error) error{
+		"DecodeLitematicaBlocks": func(fn func(StandardBlock) error) error {
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			return DecodeLitematicaBlocks(file, fn)
+		},
+		"EachBlock": standard.EachBlock,
+	} {
+		visited := 0
+		err := iterate(func(StandardBlock) error {
+			visited++
+			if visited == 10 {
+				return stop
+			}
+			return nil
+		})
+		if err != stop || visited != 10 {
+			t.Errorf("%s: expected to stop after 10 blocks with the callback's error, got %d blocks and %v", name, visited, err)
+		}
+	}
+}
+
+// TestLitematicaEntityPassengers verifies region entities are read, with
+// nested riders surviving a round trip through a Litematica file
+func TestLitematicaEntityPassengers(t *testing.T) {
+	sf := newTestStandard(1, 1, 1)
+	addTestBlock(sf, 0, 0, 0, 0)
+	sf.Entities = []StandardEntity{{
+		ID:       "minecraft:horse",
+		Position: StandardBlockPosition{X: 0.5, Y: 0, Z: 0.5},
+		NBT: map[string]interface{}{
+			"Passengers": []map[string]interface{}{{
+				"id":  "minecraft:skeleton",
+				"Pos": []float64{0.5, 1, 0.5},
+				"Passengers": []map[string]interface{}{
+					{"id": "minecraft:chicken", "Pos": []float64{0.5, 2, 0.5}},
+				},
+			}},
+		},
+	}}
+
+	encoded, err := EncodeToBytes(sf, "litematica")
+	if err != nil {
+		t.Fatalf("Failed to encode litematica: %v", err)
+	}
+	data, err := DecodeAny(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode litematica: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert litematica: %v", err)
+	}
+
+	if len(standard.Entities) != 1 {
+		t.Fatalf("Expected 1 entity, got %d", len(standard.Entities))
+	}
+	if e := standard.Entities[0]; e.ID != "minecraft:horse" || e.Position != (StandardBlockPosition{X: 0.5, Y: 0, Z: 0.5}) {
+		t.Errorf("Unexpected entity %+v", e)
+	}
+
+	litematica, err := ConvertFromStandard(standard, "litematica")
+	if err != nil {
+		t.Fatalf("Failed to convert back to litematica: %v", err)
+	}
+	for _, region := range litematica.(*LitematicaNBT).Regions {
+		riders := region.Entities[0].Passengers
+		if len(riders) != 1 || riders[0].ID != "minecraft:skeleton" {
+			t.Fatalf("Expected a minecraft:skeleton rider, got %+v", riders)
+		}
+		if len(riders[0].Passengers) != 1 || riders[0].Passengers[0].ID != "minecraft:chicken" {
+			t.Errorf("Expected a minecraft:chicken riding the skeleton, got %+v", riders[0].Passengers)
+		}
+	}
+}
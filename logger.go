@@ -0,0 +1,44 @@
+package mcnbt
+
+import (
+	"sync"
+)
+
+// Logger receives the package's diagnostic messages. *log.Logger satisfies
+// it, so SetLogger(log.Default()) restores printing to stderr.
+type Logger interface {
+	Println(v ...interface{})
+}
+
+// nopLogger discards every message
+type nopLogger struct{}
+
+func (nopLogger) Println(...interface{}) {}
+
+var (
+	loggerMu sync.RWMutex
+	logger   Logger = nopLogger{}
+)
+
+// SetLogger routes diagnostic messages to l. Messages are discarded by
+// default, and passing nil discards them again.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	logger = l
+}
+
+// currentLogger returns the Logger set by SetLogger
+func currentLogger() Logger {
+	loggerMu.RLock()
+	defer loggerMu.RUnlock()
+	return logger
+}
+
+// logPrintln sends a diagnostic message to the current Logger
+func logPrintln(v ...interface{}) {
+	currentLogger().Println(v...)
+}
@@ -0,0 +1,37 @@
+package mcnbt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recordingLogger keeps every message it is given
+type recordingLogger struct {
+	messages []string
+}
+
+func (r *recordingLogger) Println(v ...interface{}) {
+	r.messages = append(r.messages, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+// TestSetLogger verifies diagnostics reach a custom logger and are discarded by default
+func TestSetLogger(t *testing.T) {
+	invalid := map[string]interface{}{"Item": "not a compound"}
+
+	// The default logger discards messages without failing
+	if n, err := decodeNbt(invalid); err != nil || n != nil {
+		t.Fatalf("Expected invalid NBT to be skipped, got %v, %v", n, err)
+	}
+
+	recorder := &recordingLogger{}
+	SetLogger(recorder)
+	defer SetLogger(nil)
+
+	if _, err := decodeNbt(invalid); err != nil {
+		t.Fatalf("Expected invalid NBT to be skipped, got %v", err)
+	}
+	if len(recorder.messages) != 1 || !strings.HasPrefix(recorder.messages[0], "Skipping invalid NBT") {
+		t.Errorf("Expected one \"Skipping invalid NBT\" message, got %q", recorder.messages)
+	}
+}
@@ -0,0 +1,406 @@
+package mcnbt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// mapToStruct stores src, a generic value as returned by DecodeAny, in the
+// value dst points to. Compound keys are matched to struct fields by their
+// json tag, exactly first and then ignoring case, as encoding/json does, so
+// the typed structs map the same way they would through a JSON round trip.
+// Unlike that round trip it keeps NBT number types in interface{} fields and
+// does not pass large arrays through text. Numbers convert between numeric
+// kinds, integer arrays and lists convert element by element, and strings
+// holding base64, as JSON encodes byte arrays, decode into byte arrays.
+func mapToStruct(src interface{}, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("mapToStruct needs a non-nil pointer, got %T", dst)
+	}
+	return assignValue(v.Elem(), src)
+}
+
+// mappingError reports a value that could not be stored, with the key path
+// to it. The path is built while the error returns, so successful mappings
+// don't pay for it.
+type mappingError struct {
+	path string
+	msg  string
+}
+
+func (e *mappingError) Error() string {
+	return fmt.Sprintf("%s at %q", e.msg, e.path)
+}
+
+// atPath prefixes the path of a mappingError returned from a nested value
+func atPath(segment string, err error) error {
+	if me, ok := err.(*mappingError); ok {
+		me.path = segment + me.path
+	}
+	return err
+}
+
+// mappedField is a struct field and the key it is stored under
+type mappedField struct {
+	name  string
+	index int
+}
+
+// mappedFields holds the fields of a struct type in order, and their indices
+// by key and by lowercased key for case-insensitive matches
+type mappedFields struct {
+	byIndex []mappedField
+	exact   map[string]int
+	folded  map[string]int
+}
+
+// fieldCache caches the fields of each struct type assignValue has seen
+var fieldCache sync.Map // reflect.Type -> *mappedFields
+
+func fieldsOf(t reflect.Type) *mappedFields {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(*mappedFields)
+	}
+	fields := &mappedFields{exact: make(map[string]int), folded: make(map[string]int)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		// Like encoding/json, the first field wins when several match a key
+		if _, ok := fields.exact[name]; !ok {
+			fields.exact[name] = i
+			fields.byIndex = append(fields.byIndex, mappedField{name: name, index: i})
+		}
+		if _, ok := fields.folded[strings.ToLower(name)]; !ok {
+			fields.folded[strings.ToLower(name)] = i
+		}
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// stringType is the type of string keys, which need no conversion
+var stringType = reflect.TypeOf("")
+
+// assignValue stores src in dst
+func assignValue(dst reflect.Value, src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	mismatch := func() error {
+		return &mappingError{msg: fmt.Sprintf("cannot store %T in %s", src, dst.Type())}
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			return mismatch()
+		}
+		dst.Set(reflect.ValueOf(cloneGeneric(src)))
+		return nil
+
+	case reflect.Pointer:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assignValue(dst.Elem(), src)
+
+	case reflect.Bool:
+		if s, ok := src.(bool); ok {
+			dst.SetBool(s)
+			return nil
+		}
+		if f, ok := toFloat64(src); ok {
+			dst.SetBool(f != 0)
+			return nil
+		}
+		return mismatch()
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := genericInt(src); ok {
+			dst.SetInt(n)
+			return nil
+		}
+		return mismatch()
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := genericInt(src); ok {
+			dst.SetUint(uint64(n))
+			return nil
+		}
+		return mismatch()
+
+	case reflect.Float32, reflect.Float64:
+		if f, ok := toFloat64(src); ok {
+			dst.SetFloat(f)
+			return nil
+		}
+		if n, ok := genericInt(src); ok {
+			dst.SetFloat(float64(n))
+			return nil
+		}
+		return mismatch()
+
+	case reflect.String:
+		if s, ok := src.(string); ok {
+			dst.SetString(s)
+			return nil
+		}
+		return mismatch()
+
+	case reflect.Slice:
+		return assignSlice(dst, src)
+
+	case reflect.Array:
+		sv := reflect.ValueOf(src)
+		if sv.Kind() != reflect.Slice && sv.Kind() != reflect.Array {
+			return mismatch()
+		}
+		for i := 0; i < dst.Len() && i < sv.Len(); i++ {
+			if err := assignValue(dst.Index(i), sv.Index(i).Interface()); err != nil {
+				return atPath(fmt.Sprintf("[%d]", i), err)
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		if dst.Type().Key().Kind() != reflect.String {
+			return mismatch()
+		}
+		sv := reflect.ValueOf(src)
+		if sv.Kind() != reflect.Map || sv.Type().Key().Kind() != reflect.String {
+			return mismatch()
+		}
+		// Block state properties are by far the most common map, so they are
+		// filled without reflection
+		if m, ok := src.(map[string]interface{}); ok && dst.Type() == reflect.TypeOf(map[string]string(nil)) {
+			out, _ := dst.Interface().(map[string]string)
+			if out == nil {
+				out = make(map[string]string, len(m))
+				dst.Set(reflect.ValueOf(out))
+			}
+			for key, value := range m {
+				s, ok := value.(string)
+				if !ok {
+					return &mappingError{path: "." + key, msg: fmt.Sprintf("cannot store %T in string", value)}
+				}
+				out[key] = s
+			}
+			return nil
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(dst.Type(), sv.Len()))
+		}
+		// SetMapIndex copies elem, so one value is reused for every entry
+		elem := reflect.New(dst.Type().Elem()).Elem()
+		iter := sv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			elem.SetZero()
+			if err := assignValue(elem, iter.Value().Interface()); err != nil {
+				return atPath("."+key, err)
+			}
+			keyValue := reflect.ValueOf(key)
+			if dst.Type().Key() != stringType {
+				keyValue = keyValue.Convert(dst.Type().Key())
+			}
+			dst.SetMapIndex(keyValue, elem)
+		}
+		return nil
+
+	case reflect.Struct:
+		m, ok := src.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		// Keys are looked up by field, which is cheaper than iterating the
+		// compound, and the compound is only iterated when some keys don't
+		// match a field exactly
+		fields := fieldsOf(dst.Type())
+		matched := 0
+		for _, f := range fields.byIndex {
+			value, ok := m[f.name]
+			if !ok {
+				continue
+			}
+			matched++
+			if err := assignValue(dst.Field(f.index), value); err != nil {
+				return atPath("."+f.name, err)
+			}
+		}
+		if matched == len(m) {
+			return nil
+		}
+		for key, value := range m {
+			if _, exact := fields.exact[key]; exact {
+				continue
+			}
+			field, found := fields.folded[strings.ToLower(key)]
+			if !found {
+				continue
+			}
+			if err := assignValue(dst.Field(field), value); err != nil {
+				return atPath("."+key, err)
+			}
+		}
+		return nil
+	}
+	return mismatch()
+}
+
+// assignSlice stores a list, typed array or base64 string in the slice dst
+func assignSlice(dst reflect.Value, src interface{}) error {
+	// Common array types are copied directly
+	switch s := src.(type) {
+	case []byte:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes(append([]byte(nil), s...))
+			return nil
+		}
+	case []int32:
+		if dst.Type().Elem().Kind() == reflect.Int32 {
+			out := reflect.MakeSlice(dst.Type(), len(s), len(s))
+			reflect.Copy(out, reflect.ValueOf(s))
+			dst.Set(out)
+			return nil
+		}
+	case []int64:
+		if dst.Type().Elem().Kind() == reflect.Int64 {
+			out := reflect.MakeSlice(dst.Type(), len(s), len(s))
+			reflect.Copy(out, reflect.ValueOf(s))
+			dst.Set(out)
+			return nil
+		}
+	case string:
+		// JSON carries byte arrays as base64 strings
+		raw, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return &mappingError{msg: fmt.Sprintf("cannot store string in %s: %v", dst.Type(), err)}
+		}
+		return assignSlice(dst, raw)
+	}
+
+	// Lists are indexed directly rather than through reflection
+	if list, ok := src.([]interface{}); ok {
+		out := reflect.MakeSlice(dst.Type(), len(list), len(list))
+		for i, elem := range list {
+			if err := assignValue(out.Index(i), elem); err != nil {
+				return atPath(fmt.Sprintf("[%d]", i), err)
+			}
+		}
+		dst.Set(out)
+		return nil
+	}
+
+	sv := reflect.ValueOf(src)
+	if sv.Kind() != reflect.Slice && sv.Kind() != reflect.Array {
+		return &mappingError{msg: fmt.Sprintf("cannot store %T in %s", src, dst.Type())}
+	}
+	out := reflect.MakeSlice(dst.Type(), sv.Len(), sv.Len())
+	for i := 0; i < sv.Len(); i++ {
+		if err := assignValue(out.Index(i), sv.Index(i).Interface()); err != nil {
+			return atPath(fmt.Sprintf("[%d]", i), err)
+		}
+	}
+	dst.Set(out)
+	return nil
+}
+
+// genericInt returns the integer value of a decoded number. Floats are
+// truncated, since some tools write whole numbers as doubles.
+func genericInt(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case int:
+		return int64(n), true
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		return int64(n), true
+	case float32:
+		return int64(n), true
+	case float64:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// cloneGeneric deep-copies a generic value into the shapes DecodeAny
+// produces: compounds become map[string]interface{} and lists []interface{},
+// while byte, int and long arrays and scalars keep their types. The copy keeps
+// converters from modifying the caller's data.
+func cloneGeneric(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, c := range val {
+			out[k] = cloneGeneric(c)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, c := range val {
+			out[i] = cloneGeneric(c)
+		}
+		return out
+	case []byte:
+		return append([]byte(nil), val...)
+	case []int32:
+		return append([]int32(nil), val...)
+	case []int64:
+		return append([]int64(nil), val...)
+	case nil, bool, string, int8, int16, int32, int64, int, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			out := make(map[string]interface{}, rv.Len())
+			iter := rv.MapRange()
+			for iter.Next() {
+				out[iter.Key().String()] = cloneGeneric(iter.Value().Interface())
+			}
+			return out
+		}
+	case reflect.Slice, reflect.Array:
+		out := make([]interface{}, rv.Len())
+		for i := range out {
+			out[i] = cloneGeneric(rv.Index(i).Interface())
+		}
+		return out
+	case reflect.Pointer:
+		if !rv.IsNil() {
+			return cloneGeneric(rv.Elem().Interface())
+		}
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,224 @@
+package mcnbt
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// orderingSamples is the number of evenly spaced blocks whose index
+// VerifyOrdering checks against their position
+const orderingSamples = 64
+
+// verifyConversions makes ConvertToStandardContext check every block it
+// converts with VerifyOrdering. The tests turn it on, so each conversion they
+// run asserts the invariant; it is off otherwise since the bounds check
+// visits every block.
+var verifyConversions = false
+
+// VerifyOrdering checks that block positions in sf are consistent with the
+// standard layout: every block lies within [0, Size), and for formats that
+// store a full volume (litematica, worldedit) the block at index i sits at the
+// position recomputed from i in YZX order. Every block is bounds checked, but
+// only a sample of evenly spaced indices is recomputed.
+func VerifyOrdering(sf *StandardFormat) error {
+	volume := sf.Size.X * sf.Size.Y * sf.Size.Z
+
+	for i, block := range sf.Blocks {
+		x, y, z := int(block.Position.X), int(block.Position.Y), int(block.Position.Z)
+		if x < 0 || x >= sf.Size.X || y < 0 || y >= sf.Size.Y || z < 0 || z >= sf.Size.Z {
+			return fmt.Errorf("block %d at %+v is outside size %+v", i, block.Position, sf.Size)
+		}
+	}
+
+	switch sf.OriginalFormat {
+	case "litematica", "worldedit":
+	default:
+		return nil
+	}
+
+	if volume == 0 || len(sf.Blocks) < volume {
+		return fmt.Errorf("expected %d blocks for a full volume, got %d", volume, len(sf.Blocks))
+	}
+
+	step := volume / orderingSamples
+	if step == 0 {
+		step = 1
+	}
+	for i := 0; i < volume; i += step {
+		expected := StandardBlockPosition{
+			X: float64(i % sf.Size.X),
+			Y: float64(i / (sf.Size.X * sf.Size.Z)),
+			Z: float64((i / sf.Size.X) % sf.Size.Z),
+		}
+		if sf.Blocks[i].Position != expected {
+			return fmt.Errorf("block %d is at %+v, expected %+v", i, sf.Blocks[i].Position, expected)
+		}
+	}
+
+	return nil
+}
+
+// OrphanedEntities returns the entities and tile entities that lie outside
+// the schematic's bounds. Positions are relative to Position, so tile
+// entities must be within [0, Size); entities may also sit exactly on the far
+// faces since their coordinates are continuous.
+func (sf *StandardFormat) OrphanedEntities() (entities []StandardEntity, tileEntities []StandardTileEntity) {
+	for _, e := range sf.Entities {
+		p := e.Position
+		if p.X < 0 || p.X > float64(sf.Size.X) ||
+			p.Y < 0 || p.Y > float64(sf.Size.Y) ||
+			p.Z < 0 || p.Z > float64(sf.Size.Z) {
+			entities = append(entities, e)
+		}
+	}
+	for _, te := range sf.TileEntities {
+		p := te.Position
+		x, y, z := int(p.X), int(p.Y), int(p.Z)
+		if p.X < 0 || x >= sf.Size.X || p.Y < 0 || y >= sf.Size.Y || p.Z < 0 || z >= sf.Size.Z {
+			tileEntities = append(tileEntities, te)
+		}
+	}
+	return entities, tileEntities
+}
+
+// AxisOrder names the order in which a producer stored block data, from the
+// outermost axis to the one that varies fastest. The standard layout is YZX.
+type AxisOrder string
+
+// The six possible axis orders
+const (
+	AxisOrderYZX AxisOrder = "YZX"
+	AxisOrderYXZ AxisOrder = "YXZ"
+	AxisOrderXZY AxisOrder = "XZY"
+	AxisOrderXYZ AxisOrder = "XYZ"
+	AxisOrderZXY AxisOrder = "ZXY"
+	AxisOrderZYX AxisOrder = "ZYX"
+)
+
+// axisOrders lists every AxisOrder, the standard one first
+var axisOrders = []AxisOrder{AxisOrderYZX, AxisOrderYXZ, AxisOrderXZY, AxisOrderXYZ, AxisOrderZXY, AxisOrderZYX}
+
+// axes returns the axis indices (0 for X, 1 for Y, 2 for Z) of order,
+// outermost first, or false if order is not a permutation of X, Y and Z
+func (order AxisOrder) axes() ([3]int, bool) {
+	var axes [3]int
+	if len(order) != 3 {
+		return axes, false
+	}
+	seen := 0
+	for i := 0; i < 3; i++ {
+		axis := strings.IndexByte("XYZ", order[i])
+		if axis < 0 || seen&(1<<axis) != 0 {
+			return axes, false
+		}
+		seen |= 1 << axis
+		axes[i] = axis
+	}
+	return axes, true
+}
+
+// axisIndex returns the index of pos in data stored with the given axes
+func axisIndex(pos, size [3]int, axes [3]int) int {
+	return (pos[axes[0]]*size[axes[1]]+pos[axes[1]])*size[axes[2]] + pos[axes[2]]
+}
+
+// axisPosition is the inverse of axisIndex
+func axisPosition(i int, size [3]int, axes [3]int) [3]int {
+	var pos [3]int
+	pos[axes[2]] = i % size[axes[2]]
+	pos[axes[1]] = i / size[axes[2]] % size[axes[1]]
+	pos[axes[0]] = i / (size[axes[2]] * size[axes[1]])
+	return pos
+}
+
+// DetectTranspose infers the axis order block data was stored in from a few
+// known cells, for schematics whose producer did not use YZX. reference maps
+// positions written as "x,y,z" to the palette state expected there, e.g. a
+// distinctive block at a corner. It returns the order under which every
+// reference cell matches, preferring AxisOrderYZX, which means the data is
+// already correct. It returns false if no order matches, or several
+// non-standard orders do and more reference cells are needed to tell them apart.
+func (sf *StandardFormat) DetectTranspose(reference map[string]int) (AxisOrder, bool) {
+	type cell struct {
+		pos   [3]int
+		state int
+	}
+	cells := make([]cell, 0, len(reference))
+	for key, state := range reference {
+		parts := strings.Split(key, ",")
+		if len(parts) != 3 {
+			return "", false
+		}
+		var pos [3]int
+		for i, part := range parts {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return "", false
+			}
+			pos[i] = n
+		}
+		if !sf.inBounds(pos) {
+			return "", false
+		}
+		cells = append(cells, cell{pos, state})
+	}
+	if len(cells) == 0 {
+		return "", false
+	}
+
+	size := [3]int{sf.Size.X, sf.Size.Y, sf.Size.Z}
+	standard, _ := AxisOrderYZX.axes()
+	var matches []AxisOrder
+	for _, order := range axisOrders {
+		axes, _ := order.axes()
+		matched := true
+		for _, c := range cells {
+			// The cell's block was read from the index it has under order, and
+			// the decoder placed it where that index falls in YZX
+			stored := axisPosition(axisIndex(c.pos, size, axes), size, standard)
+			block, ok := sf.GetBlockAt(stored[0], stored[1], stored[2])
+			if !ok || block.State != c.state {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			if order == AxisOrderYZX {
+				return order, true
+			}
+			matches = append(matches, order)
+		}
+	}
+	if len(matches) != 1 {
+		return "", false
+	}
+	return matches[0], true
+}
+
+// Reorder moves blocks that were decoded as YZX data but stored in the given
+// order to their correct positions, then sorts them back into YZX order. Size,
+// tile entities and entities are unchanged, since they do not depend on the
+// block data's layout. Invalid orders and blocks outside the bounds are ignored.
+func (sf *StandardFormat) Reorder(order AxisOrder) {
+	axes, ok := order.axes()
+	if !ok || order == AxisOrderYZX {
+		return
+	}
+	standard, _ := AxisOrderYZX.axes()
+	size := [3]int{sf.Size.X, sf.Size.Y, sf.Size.Z}
+
+	for i := range sf.Blocks {
+		pos := positionKey(sf.Blocks[i].Position)
+		if !sf.inBounds(pos) {
+			continue
+		}
+		pos = axisPosition(axisIndex(pos, size, standard), size, axes)
+		sf.Blocks[i].Position = toBlockPosition(pos)
+	}
+	sort.SliceStable(sf.Blocks, func(i, j int) bool {
+		return lessPosition(positionKey(sf.Blocks[i].Position), positionKey(sf.Blocks[j].Position))
+	})
+	sf.invalidateIndex()
+}
@@ -0,0 +1,180 @@
+package mcnbt
+
+import (
+	"testing"
+)
+
+// Every conversion the tests run checks its block ordering
+func init() {
+	verifyConversions = true
+}
+
+// TestVerifyOrdering verifies every fixture decodes into the standard block layout
+func TestVerifyOrdering(t *testing.T) {
+	files := map[string]string{
+		"litematica": "testdata/color_field.litematic",
+		"worldedit":  "testdata/color_field.schem",
+		"create":     "testdata/color_field.nbt",
+	}
+
+	for name, path := range files {
+		t.Run(name, func(t *testing.T) {
+			data, err := ParseAnyFromFileAsJSON(path)
+			if err != nil {
+				t.Fatalf("Failed to parse %s: %v", path, err)
+			}
+			standard, err := ConvertToStandard(data)
+			if err != nil {
+				t.Fatalf("Failed to convert %s: %v", name, err)
+			}
+			if err := VerifyOrdering(standard); err != nil {
+				t.Errorf("Ordering check failed: %v", err)
+			}
+		})
+	}
+}
+
+// TestConversionsVerifyOrdering verifies conversions run by the tests reject
+// blocks placed outside the schematic
+func TestConversionsVerifyOrdering(t *testing.T) {
+	structure := map[string]interface{}{
+		"DataVersion": int32(3465),
+		"size":        []int32{1, 1, 1},
+		"palette":     []interface{}{map[string]interface{}{"Name": "minecraft:stone"}},
+		"blocks": []interface{}{
+			map[string]interface{}{"pos": []int32{2, 0, 0}, "state": int32(0)},
+		},
+		"entities": []interface{}{},
+	}
+	if _, err := ConvertToStandard(structure); err == nil {
+		t.Errorf("Expected a block outside the size to fail the ordering check")
+	}
+}
+
+// TestFormatsAgreeOnBlockPositions verifies the same schematic stored in
+// different formats resolves to the same block name at every position. An
+// axis-order mismatch in any decoder transposes blocks and fails this test.
+func TestFormatsAgreeOnBlockPositions(t *testing.T) {
+	files := map[string]string{
+		"litematica": "testdata/color_field.litematic",
+		"worldedit":  "testdata/color_field.schem",
+		"create":     "testdata/color_field.nbt",
+	}
+
+	names := make(map[string]map[[3]int]string)
+	for name, path := range files {
+		data, err := ParseAnyFromFileAsJSON(path)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", path, err)
+		}
+		standard, err := ConvertToStandard(data)
+		if err != nil {
+			t.Fatalf("Failed to convert %s: %v", name, err)
+		}
+
+		byPosition := make(map[[3]int]string)
+		for _, block := range standard.Blocks {
+			key := [3]int{int(block.Position.X), int(block.Position.Y), int(block.Position.Z)}
+			byPosition[key] = standard.Palette[block.State].Name
+		}
+		names[name] = byPosition
+	}
+
+	reference := names["worldedit"]
+	for name := range files {
+		mismatches := 0
+		for pos, blockName := range names[name] {
+			if blockName == "minecraft:air" {
+				continue
+			}
+			if reference[pos] != blockName {
+				mismatches++
+				if mismatches <= 5 {
+					t.Errorf("%s: block at %v is %s, worldedit has %s", name, pos, blockName, reference[pos])
+				}
+			}
+		}
+		if mismatches > 5 {
+			t.Errorf("%s: %d mismatched positions in total", name, mismatches)
+		}
+	}
+}
+
+// TestOrphanedEntities verifies only entities and tile entities outside the bounds are reported
+func TestOrphanedEntities(t *testing.T) {
+	sf := newTestStandard(2, 2, 2)
+	addTestBlock(sf, 0, 0, 0, 1)
+	sf.Entities = []StandardEntity{
+		{ID: "minecraft:pig", Position: StandardBlockPosition{X: 1.5, Y: 0, Z: 2}},
+		{ID: "minecraft:cow", Position: StandardBlockPosition{X: 5.5, Y: 0, Z: 1}},
+	}
+	sf.TileEntities = []StandardTileEntity{
+		{ID: "minecraft:chest", Position: StandardBlockPosition{X: 1, Y: 1, Z: 1}},
+		{ID: "minecraft:barrel", Position: StandardBlockPosition{X: 2, Y: 0, Z: 0}},
+	}
+
+	entities, tileEntities := sf.OrphanedEntities()
+	if len(entities) != 1 || len(tileEntities) != 1 {
+		t.Fatalf("Expected 1 orphaned entity and 1 tile entity, got %+v and %+v", entities, tileEntities)
+	}
+	if entities[0].ID != "minecraft:cow" || tileEntities[0].ID != "minecraft:barrel" {
+		t.Errorf("Unexpected orphaned entries: %s, %s", entities[0].ID, tileEntities[0].ID)
+	}
+}
+
+// TestDetectTransposeAndReorder verifies data stored in XZY order but decoded
+// as YZX is recognized from a few reference cells and put back in place
+func TestDetectTransposeAndReorder(t *testing.T) {
+	size := StandardSize{X: 2, Y: 3, Z: 4}
+	markers := map[[3]int]int{{1, 0, 0}: 1, {0, 2, 0}: 2, {0, 0, 3}: 3}
+	stateAt := func(x, y, z int) int { return markers[[3]int{x, y, z}] }
+
+	// A producer writes the volume with Y varying fastest, then Z, then X
+	var stored []int
+	for x := 0; x < size.X; x++ {
+		for z := 0; z < size.Z; z++ {
+			for y := 0; y < size.Y; y++ {
+				stored = append(stored, stateAt(x, y, z))
+			}
+		}
+	}
+
+	// and a decoder reads it back as YZX
+	sf := newTestStandard(size.X, size.Y, size.Z)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:glass"}
+	sf.Palette[3] = StandardPalette{Name: "minecraft:gold_block"}
+	i := 0
+	for y := 0; y < size.Y; y++ {
+		for z := 0; z < size.Z; z++ {
+			for x := 0; x < size.X; x++ {
+				addTestBlock(sf, x, y, z, stored[i])
+				i++
+			}
+		}
+	}
+
+	reference := map[string]int{"1,0,0": 1, "0,2,0": 2, "0,0,3": 3}
+	order, ok := sf.DetectTranspose(reference)
+	if !ok || order != AxisOrderXZY {
+		t.Fatalf("Expected XZY to be detected, got %q, %v", order, ok)
+	}
+
+	sf.Reorder(order)
+	for _, block := range sf.Blocks {
+		x, y, z := int(block.Position.X), int(block.Position.Y), int(block.Position.Z)
+		if expected := stateAt(x, y, z); block.State != expected {
+			t.Errorf("Expected state %d at %d,%d,%d, got %d", expected, x, y, z, block.State)
+		}
+	}
+	sf.OriginalFormat = "worldedit"
+	if err := VerifyOrdering(sf); err != nil {
+		t.Errorf("Expected blocks back in YZX order: %v", err)
+	}
+
+	if order, ok := sf.DetectTranspose(reference); !ok || order != AxisOrderYZX {
+		t.Errorf("Expected corrected data to match YZX, got %q, %v", order, ok)
+	}
+	if _, ok := sf.DetectTranspose(map[string]int{"0,0,0": 2}); ok {
+		t.Errorf("Expected no order to match an impossible reference")
+	}
+}
@@ -0,0 +1,69 @@
+package mcnbt
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// facingDegrees maps horizontal facing values to degrees clockwise from north
+var facingDegrees = map[string]float64{
+	"north": 0,
+	"east":  90,
+	"south": 180,
+	"west":  270,
+}
+
+// rotationStep is the angle between two values of the 16-step rotation property
+const rotationStep = 360.0 / 16
+
+// NormalizeOrientation returns the horizontal direction a block faces as
+// degrees clockwise from north, whether it is stored as a facing value
+// ("north", "east", ...) or as the numeric 0-15 rotation used by standing
+// signs, banners and heads (where 0 faces south). ok is false for blocks
+// without a horizontal orientation, including those facing up or down.
+// name is the block ID: rotation is only read as the 16-step value for
+// standing signs, banners, heads and skulls, since their wall variants use
+// facing and other blocks, such as modded ones, may give it another range.
+func NormalizeOrientation(name string, props map[string]string) (degrees float64, ok bool) {
+	if facing, has := props["facing"]; has {
+		degrees, ok = facingDegrees[facing]
+		return degrees, ok
+	}
+	if rotation, has := props["rotation"]; has && hasRotationSteps(name) {
+		r, err := strconv.Atoi(rotation)
+		if err != nil || r < 0 || r > 15 {
+			return 0, false
+		}
+		return math.Mod(float64(r)*rotationStep+180, 360), true
+	}
+	return 0, false
+}
+
+// hasRotationSteps reports whether name is a standing block whose rotation
+// property holds one of 16 steps
+func hasRotationSteps(name string) bool {
+	if strings.Contains(name, "_wall_") {
+		return false
+	}
+	for _, suffix := range []string{"_sign", "_banner", "_head", "_skull"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// setOrientation writes degrees back into whichever orientation property
+// props uses, rounding to the nearest value the property can hold
+func setOrientation(props map[string]string, degrees float64) {
+	degrees = math.Mod(math.Mod(degrees, 360)+360, 360)
+	if _, ok := props["facing"]; ok {
+		props["facing"] = horizontalDirections[int(math.Round(degrees/90))%4]
+		return
+	}
+	if _, ok := props["rotation"]; ok {
+		r := int(math.Round((degrees-180)/rotationStep)) % 16
+		props["rotation"] = strconv.Itoa((r + 16) % 16)
+	}
+}
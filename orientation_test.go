@@ -0,0 +1,65 @@
+package mcnbt
+
+import (
+	"testing"
+)
+
+// TestNormalizeOrientation verifies facing and numeric rotation share one angle
+// scale, and rotation is only read for blocks that use 16 steps
+func TestNormalizeOrientation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		props   map[string]string
+		degrees float64
+		ok      bool
+	}{
+		{"minecraft:oak_stairs", map[string]string{"facing": "east"}, 90, true},
+		{"minecraft:oak_sign", map[string]string{"rotation": "0"}, 180, true},
+		{"minecraft:oak_sign", map[string]string{"rotation": "4"}, 270, true},
+		{"minecraft:oak_sign", map[string]string{"rotation": "8"}, 0, true},
+		{"minecraft:creeper_head", map[string]string{"rotation": "12"}, 90, true},
+		{"minecraft:white_banner", map[string]string{"rotation": "8"}, 0, true},
+		{"minecraft:oak_wall_sign", map[string]string{"facing": "west"}, 270, true},
+		{"examplemod:turntable", map[string]string{"rotation": "4"}, 0, false},
+		{"minecraft:observer", map[string]string{"facing": "up"}, 0, false},
+		{"minecraft:stone", nil, 0, false},
+	}
+
+	for _, tc := range testCases {
+		degrees, ok := NormalizeOrientation(tc.name, tc.props)
+		if ok != tc.ok || degrees != tc.degrees {
+			t.Errorf("%s %v: expected %v %v, got %v %v", tc.name, tc.props, tc.degrees, tc.ok, degrees, ok)
+		}
+	}
+}
+
+// TestRotateStandingSign verifies a sign's numeric rotation turns with the schematic
+func TestRotateStandingSign(t *testing.T) {
+	sf := newTestStandard(1, 1, 1)
+	sf.Palette[2] = StandardPalette{
+		Name:       "minecraft:oak_sign",
+		Properties: map[string]string{"rotation": "2", "waterlogged": "false"},
+	}
+	addTestBlock(sf, 0, 0, 0, 2)
+
+	if err := sf.Rotate(90); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	if got := sf.Palette[2].Properties["rotation"]; got != "6" {
+		t.Errorf("Expected rotation 6 after a quarter turn, got %s", got)
+	}
+
+	if err := sf.Rotate(270); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	if got := sf.Palette[2].Properties["rotation"]; got != "2" {
+		t.Errorf("Expected rotation 2 after a full turn, got %s", got)
+	}
+
+	if err := sf.Mirror("x"); err != nil {
+		t.Fatalf("Failed to mirror: %v", err)
+	}
+	if got := sf.Palette[2].Properties["rotation"]; got != "14" {
+		t.Errorf("Expected rotation 14 after mirroring X, got %s", got)
+	}
+}
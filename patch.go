@@ -0,0 +1,153 @@
+package mcnbt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// PatchRemovedBlock is the palette name MakePatch uses to mark a position
+// whose block was removed in the modified schematic
+const PatchRemovedBlock = "mcnbt:removed"
+
+// MakePatch returns a schematic holding only the blocks of modified that are
+// new or differ from base, along with their tile entities, plus
+// PatchRemovedBlock entries for blocks of base that modified no longer has. A
+// block whose tile entity changed counts as changed. Entities are not diffed;
+// the patch carries all of modified's entities and ApplyPatch replaces base's
+// with them.
+func MakePatch(base, modified *StandardFormat) (*StandardFormat, error) {
+	if base == nil || modified == nil {
+		return nil, fmt.Errorf("base and modified schematics are required")
+	}
+
+	patch := &StandardFormat{
+		Metadata:       modified.Metadata,
+		DataVersion:    modified.DataVersion,
+		Version:        modified.Version,
+		Size:           modified.Size,
+		Position:       modified.Position,
+		Palette:        make(map[int]StandardPalette),
+		Entities:       append([]StandardEntity(nil), modified.Entities...),
+		OriginalFormat: modified.OriginalFormat,
+	}
+
+	baseBlocks := make(map[[3]int]StandardBlock)
+	for _, block := range base.Blocks {
+		baseBlocks[positionKey(block.Position)] = block
+	}
+	baseTileEntities := base.tileEntitiesByPosition()
+	modifiedTileEntities := modified.tileEntitiesByPosition()
+	states := newPaletteIndex(patch.Palette)
+
+	present := make(map[[3]int]bool)
+	for _, block := range modified.Blocks {
+		key := positionKey(block.Position)
+		present[key] = true
+		te, hasTE := modifiedTileEntities[key]
+		if old, ok := baseBlocks[key]; ok && sameBlock(base, old, modified, block) {
+			oldTE, hadTE := baseTileEntities[key]
+			if hasTE == hadTE && reflect.DeepEqual(te, oldTE) {
+				continue
+			}
+		}
+		p := modified.Palette[block.State]
+		block.State = states.indexFor(p.Name, p.Properties)
+		patch.Blocks = append(patch.Blocks, block)
+		if hasTE {
+			patch.TileEntities = append(patch.TileEntities, te)
+		}
+	}
+
+	for _, block := range base.Blocks {
+		if present[positionKey(block.Position)] {
+			continue
+		}
+		patch.Blocks = append(patch.Blocks, StandardBlock{
+			Type:     "block",
+			ID:       PatchRemovedBlock,
+			Position: block.Position,
+			State:    states.indexFor(PatchRemovedBlock, nil),
+		})
+	}
+
+	return patch, nil
+}
+
+// ApplyPatch returns a copy of base with a patch from MakePatch applied.
+// base itself is not modified.
+func ApplyPatch(base, patch *StandardFormat) (*StandardFormat, error) {
+	if base == nil || patch == nil {
+		return nil, fmt.Errorf("base and patch schematics are required")
+	}
+
+	result := base.clone()
+	result.Metadata = patch.Metadata
+	result.DataVersion = patch.DataVersion
+	result.Version = patch.Version
+	result.Size = patch.Size
+	result.Position = patch.Position
+	result.Entities = append([]StandardEntity(nil), patch.Entities...)
+
+	blocks := make(map[[3]int]StandardBlock)
+	for _, block := range result.Blocks {
+		blocks[positionKey(block.Position)] = block
+	}
+	tileEntities := result.tileEntitiesByPosition()
+	states := newPaletteIndex(result.Palette)
+
+	for _, block := range patch.Blocks {
+		p, ok := patch.Palette[block.State]
+		if !ok {
+			return nil, fmt.Errorf("patch block at %+v references missing palette index %d", block.Position, block.State)
+		}
+		// A patched block replaces any tile entity base had there
+		key := positionKey(block.Position)
+		delete(tileEntities, key)
+		if p.Name == PatchRemovedBlock {
+			delete(blocks, key)
+			continue
+		}
+		block.State = states.indexFor(p.Name, p.Properties)
+		blocks[key] = block
+	}
+	for _, te := range patch.TileEntities {
+		tileEntities[positionKey(te.Position)] = te
+	}
+
+	// Keep blocks in YZX order so dense formats see the layout they expect
+	result.Blocks = make([]StandardBlock, 0, len(blocks))
+	for _, key := range sortedPositionKeys(blocks) {
+		result.Blocks = append(result.Blocks, blocks[key])
+	}
+	result.TileEntities = make([]StandardTileEntity, 0, len(tileEntities))
+	for _, key := range sortedPositionKeys(tileEntities) {
+		result.TileEntities = append(result.TileEntities, tileEntities[key])
+	}
+	return result, nil
+}
+
+// sortedPositionKeys returns the keys of m ordered by Y, then Z, then X
+func sortedPositionKeys[V any](m map[[3]int]V) [][3]int {
+	keys := make([][3]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lessPosition(keys[i], keys[j])
+	})
+	return keys
+}
+
+// sameBlock reports whether two blocks from different schematics hold the
+// same block state and data
+func sameBlock(sa *StandardFormat, a StandardBlock, sb *StandardFormat, b StandardBlock) bool {
+	if a.Type != b.Type || a.ID != b.ID {
+		return false
+	}
+	pa, pb := sa.Palette[a.State], sb.Palette[b.State]
+	if pa.Name != pb.Name || !equalProperties(pa.Properties, pb.Properties) {
+		return false
+	}
+	return reflect.DeepEqual(a.NBT, b.NBT)
+}
@@ -0,0 +1,71 @@
+package mcnbt
+
+import (
+	"testing"
+)
+
+// TestPatchRoundTrip verifies applying a patch to its base reproduces the modified schematic
+func TestPatchRoundTrip(t *testing.T) {
+	base := newTestStandard(3, 1, 1)
+	base.Palette[2] = StandardPalette{Name: "minecraft:dirt"}
+	addTestBlock(base, 0, 0, 0, 1)
+	addTestBlock(base, 1, 0, 0, 1)
+	addTestBlock(base, 2, 0, 0, 2)
+
+	modified := base.clone()
+	modified.Size.X = 4
+	modified.Palette[3] = StandardPalette{Name: "minecraft:glass"}
+	modified.Blocks = []StandardBlock{
+		base.Blocks[0],
+		{Type: "block", State: 3, Position: StandardBlockPosition{X: 1}},
+		{Type: "block", State: 1, Position: StandardBlockPosition{X: 3}},
+	}
+	modified.TileEntities = []StandardTileEntity{{ID: "minecraft:sign", Position: StandardBlockPosition{X: 1}}}
+	modified.Entities = []StandardEntity{{ID: "minecraft:pig", Position: StandardBlockPosition{X: 0.5, Y: 1}}}
+
+	patch, err := MakePatch(base, modified)
+	if err != nil {
+		t.Fatalf("Failed to make patch: %v", err)
+	}
+	// Changed, added and removed blocks; the unchanged block is left out
+	if len(patch.Blocks) != 3 || len(patch.TileEntities) != 1 || len(patch.Entities) != 1 {
+		t.Errorf("Expected 3 blocks, 1 tile entity and 1 entity, got %d, %d and %d",
+			len(patch.Blocks), len(patch.TileEntities), len(patch.Entities))
+	}
+
+	result, err := ApplyPatch(base, patch)
+	if err != nil {
+		t.Fatalf("Failed to apply patch: %v", err)
+	}
+
+	if result.Size != modified.Size {
+		t.Errorf("Expected size %+v, got %+v", modified.Size, result.Size)
+	}
+	if len(result.Blocks) != len(modified.Blocks) {
+		t.Fatalf("Expected %d blocks, got %d", len(modified.Blocks), len(result.Blocks))
+	}
+	for _, want := range modified.Blocks {
+		got, ok := result.GetBlockAt(int(want.Position.X), int(want.Position.Y), int(want.Position.Z))
+		if !ok {
+			t.Errorf("Missing block at %+v", want.Position)
+			continue
+		}
+		if !sameBlock(modified, want, result, *got) {
+			t.Errorf("Block at %+v: expected %s, got %s", want.Position,
+				modified.Palette[want.State].Name, result.Palette[got.State].Name)
+		}
+	}
+	if _, ok := result.GetBlockAt(2, 0, 0); ok {
+		t.Errorf("Expected the removed block at 2,0,0 to be gone")
+	}
+	if len(result.TileEntities) != 1 || result.TileEntities[0].ID != "minecraft:sign" {
+		t.Errorf("Expected the tile entity to be carried over, got %+v", result.TileEntities)
+	}
+	if len(result.Entities) != 1 || result.Entities[0].ID != "minecraft:pig" {
+		t.Errorf("Expected the entity to be carried over, got %+v", result.Entities)
+	}
+
+	if len(base.Blocks) != 3 {
+		t.Errorf("Expected base to be left unchanged")
+	}
+}
@@ -0,0 +1,222 @@
+package mcnbt
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// Anvil region files start with a 4 KiB table of chunk locations followed by
+// a 4 KiB table of timestamps, one entry per chunk in a 32x32 grid. Chunk
+// data is stored in 4 KiB sectors after the header.
+const (
+	regionSectorSize = 4096
+	regionChunks     = 32
+
+	// Chunks from before 1.18 store sections in a different layout
+	regionMinDataVersion = 2844
+)
+
+// regionChunk holds the parts of a 1.18+ chunk that map to the standard format
+type regionChunk struct {
+	DataVersion   int32                    `nbt:"DataVersion"`
+	XPos          int32                    `nbt:"xPos"`
+	ZPos          int32                    `nbt:"zPos"`
+	Sections      []regionSection          `nbt:"sections"`
+	BlockEntities []map[string]interface{} `nbt:"block_entities"`
+}
+
+// regionSection is one 16x16x16 section of a chunk
+type regionSection struct {
+	Y           int8 `nbt:"Y"`
+	BlockStates struct {
+		Palette []StructurePalette `nbt:"palette"`
+		Data    []int64            `nbt:"data"`
+	} `nbt:"block_states"`
+}
+
+// DecodeRegionFile reads one chunk from an Anvil region (.mca) file into the
+// standard format, covering every section the chunk stores. chunkX and chunkZ
+// may be world chunk coordinates or offsets within the region; only their
+// position within the 32x32 grid is used.
+func DecodeRegionFile(r io.ReaderAt, chunkX, chunkZ int) (*StandardFormat, error) {
+	return DecodeRegionFileRange(r, chunkX, chunkZ, math.MinInt, math.MaxInt)
+}
+
+// DecodeRegionFileRange reads one chunk like DecodeRegionFile, keeping only
+// blocks with a world Y between minY and maxY inclusive. Position holds the
+// world coordinates of the result's origin. Only chunks saved by 1.18 or later
+// are supported, and entities, which newer versions store in separate files,
+// are not read.
+func DecodeRegionFileRange(r io.ReaderAt, chunkX, chunkZ, minY, maxY int) (*StandardFormat, error) {
+	index := int64((chunkX & (regionChunks - 1)) + (chunkZ&(regionChunks-1))*regionChunks)
+
+	var entry [4]byte
+	if _, err := r.ReadAt(entry[:], index*4); err != nil {
+		return nil, fmt.Errorf("failed to read region header: %w", err)
+	}
+	sectorOffset := int64(entry[0])<<16 | int64(entry[1])<<8 | int64(entry[2])
+	if sectorOffset == 0 || entry[3] == 0 {
+		return nil, fmt.Errorf("%w: %d,%d", ErrChunkNotFound, chunkX, chunkZ)
+	}
+	if _, err := r.ReadAt(entry[:], regionSectorSize+index*4); err != nil {
+		return nil, fmt.Errorf("failed to read region timestamps: %w", err)
+	}
+	timestamp := int64(binary.BigEndian.Uint32(entry[:]))
+
+	chunk, err := readRegionChunk(r, sectorOffset*regionSectorSize)
+	if err != nil {
+		return nil, err
+	}
+	if chunk.DataVersion < regionMinDataVersion {
+		return nil, fmt.Errorf("chunk data version %d predates 1.18 and is not supported", chunk.DataVersion)
+	}
+
+	sections := make([]regionSection, 0, len(chunk.Sections))
+	for _, section := range chunk.Sections {
+		if len(section.BlockStates.Palette) > 0 {
+			sections = append(sections, section)
+		}
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("chunk %d,%d has no block sections", chunkX, chunkZ)
+	}
+	sort.Slice(sections, func(i, j int) bool { return sections[i].Y < sections[j].Y })
+
+	// Clamp the range to the sections the chunk actually stores
+	minY = max(minY, int(sections[0].Y)*16)
+	maxY = min(maxY, int(sections[len(sections)-1].Y)*16+15)
+	if minY > maxY {
+		return nil, fmt.Errorf("chunk %d,%d has no blocks between Y %d and %d", chunkX, chunkZ, minY, maxY)
+	}
+
+	sf := &StandardFormat{
+		DataVersion:    int(chunk.DataVersion),
+		OriginalFormat: "region",
+		Size:           StandardSize{X: 16, Y: maxY - minY + 1, Z: 16},
+		Position:       StandardPosition{X: int(chunk.XPos) * 16, Y: minY, Z: int(chunk.ZPos) * 16},
+		Palette:        make(map[int]StandardPalette),
+		Metadata: StandardMetadata{
+			TimeModified: timestamp * 1000,
+			TotalVolume:  16 * 16 * (maxY - minY + 1),
+		},
+	}
+
+	states := newPaletteIndex(sf.Palette)
+	for _, section := range sections {
+		if err := sf.addRegionSection(section, states, minY, maxY); err != nil {
+			return nil, fmt.Errorf("failed to read section %d: %w", section.Y, err)
+		}
+	}
+	sf.Metadata.TotalBlocks = len(sf.Blocks)
+
+	// Block entities store world coordinates
+	for _, te := range chunk.BlockEntities {
+		pos := numberList([]interface{}{te["x"], te["y"], te["z"]})
+		if len(pos) < 3 || int(pos[1]) < minY || int(pos[1]) > maxY {
+			continue
+		}
+		sf.TileEntities = append(sf.TileEntities, standardTileEntity(StandardBlockPosition{
+			X: pos[0] - float64(sf.Position.X),
+			Y: pos[1] - float64(minY),
+			Z: pos[2] - float64(sf.Position.Z),
+		}, te))
+	}
+
+	return sf, nil
+}
+
+// readRegionChunk decompresses and decodes the chunk stored at offset
+func readRegionChunk(r io.ReaderAt, offset int64) (*regionChunk, error) {
+	var header [5]byte
+	if _, err := r.ReadAt(header[:], offset); err != nil {
+		return nil, fmt.Errorf("failed to read chunk header: %w", err)
+	}
+	length := int64(binary.BigEndian.Uint32(header[:4]))
+	if length < 1 {
+		return nil, fmt.Errorf("chunk has invalid length %d", length)
+	}
+	payload := io.NewSectionReader(r, offset+5, length-1)
+
+	var nbtReader io.Reader
+	switch compression := header[4]; compression {
+	case 1:
+		zr, err := gzip.NewReader(payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCorruptCompression, err)
+		}
+		nbtReader = compressionErrorReader{zr}
+	case 2:
+		zr, err := zlib.NewReader(payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCorruptCompression, err)
+		}
+		nbtReader = compressionErrorReader{zr}
+	case 3:
+		nbtReader = payload
+	default:
+		// Values with the high bit set store the chunk in a separate .mcc file
+		return nil, fmt.Errorf("unsupported chunk compression type %d", compression)
+	}
+
+	chunk := new(regionChunk)
+	if _, err := nbt.NewDecoder(nbtReader).Decode(chunk); err != nil {
+		return nil, fmt.Errorf("failed to decode chunk NBT: %w", err)
+	}
+	return chunk, nil
+}
+
+// addRegionSection appends the section's blocks between world Y minY and
+// maxY, in YZX order, adding its palette entries to sf's palette through index
+func (sf *StandardFormat) addRegionSection(section regionSection, index *paletteIndex, minY, maxY int) error {
+	palette := section.BlockStates.Palette
+	states := make([]int, len(palette))
+	for i, p := range palette {
+		states[i] = index.indexFor(p.Name, p.Properties)
+	}
+
+	// Entries never span two longs, and use at least 4 bits. A single-entry
+	// palette may omit the data entirely.
+	bitsPerEntry := max(4, bits.Len(uint(len(palette)-1)))
+	perLong := 64 / bitsPerEntry
+	data := section.BlockStates.Data
+	if len(palette) > 1 && len(data) < (4096+perLong-1)/perLong {
+		return fmt.Errorf("block state data has %d longs, expected %d", len(data), (4096+perLong-1)/perLong)
+	}
+
+	baseY := int(section.Y) * 16
+	for i := 0; i < 4096; i++ {
+		y := baseY + i/256
+		if y < minY || y > maxY {
+			continue
+		}
+
+		entry := 0
+		if len(palette) > 1 {
+			entry = int(uint64(data[i/perLong]) >> (uint(i%perLong) * uint(bitsPerEntry)) & (1<<bitsPerEntry - 1))
+			if entry >= len(palette) {
+				return fmt.Errorf("block %d references palette entry %d of %d", i, entry, len(palette))
+			}
+		}
+
+		state := states[entry]
+		sf.Blocks = append(sf.Blocks, StandardBlock{
+			Type:  "block",
+			ID:    sf.Palette[state].Name,
+			State: state,
+			Position: StandardBlockPosition{
+				X: float64(i % 16),
+				Y: float64(y - minY),
+				Z: float64(i / 16 % 16),
+			},
+		})
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package mcnbt
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// TestDecodeRegionFile verifies one chunk is located, decompressed and mapped
+// to blocks relative to the chunk's lowest stored section
+func TestDecodeRegionFile(t *testing.T) {
+	f, err := os.Open("testdata/r.0.0.mca")
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer f.Close()
+
+	sf, err := DecodeRegionFile(f, 1, 2)
+	if err != nil {
+		t.Fatalf("Failed to decode chunk: %v", err)
+	}
+	if sf.Size != (StandardSize{X: 16, Y: 96, Z: 16}) {
+		t.Errorf("Expected sections -4 to 1 to give a 16x96x16 chunk, got %+v", sf.Size)
+	}
+	if sf.Position != (StandardPosition{X: 16, Y: -64, Z: 32}) {
+		t.Errorf("Expected the origin at 16,-64,32, got %+v", sf.Position)
+	}
+	if sf.Metadata.TimeModified != 1700000000000 {
+		t.Errorf("Expected the header timestamp in milliseconds, got %d", sf.Metadata.TimeModified)
+	}
+
+	// The bottom section has a single-entry palette and no data
+	if block, ok := sf.GetBlockAt(7, 0, 7); !ok || sf.Palette[block.State].Name != "minecraft:stone" {
+		t.Errorf("Expected stone filling the bottom section, got %+v", block)
+	}
+	if log, ok := sf.GetBlockAt(3, 69, 4); !ok || sf.Palette[log.State].Name != "minecraft:oak_log" || sf.Palette[log.State].Properties["axis"] != "y" {
+		t.Errorf("Expected oak_log[axis=y] at world Y 5, got %+v", log)
+	}
+
+	if _, err := DecodeRegionFile(f, 0, 0); !errors.Is(err, ErrChunkNotFound) {
+		t.Errorf("Expected ErrChunkNotFound for an empty slot, got %v", err)
+	}
+}
+
+// TestDecodeRegionFileRange verifies only the requested Y range is kept and
+// block entities are moved into the result's space
+func TestDecodeRegionFileRange(t *testing.T) {
+	f, err := os.Open("testdata/r.0.0.mca")
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer f.Close()
+
+	// World chunk coordinates select the same slot as region offsets
+	sf, err := DecodeRegionFileRange(f, -31, 34, 0, 7)
+	if err != nil {
+		t.Fatalf("Failed to decode chunk: %v", err)
+	}
+	if sf.Size != (StandardSize{X: 16, Y: 8, Z: 16}) || len(sf.Blocks) != 16*8*16 {
+		t.Fatalf("Expected 2048 blocks in a 16x8x16 slice, got %d in %+v", len(sf.Blocks), sf.Size)
+	}
+	for _, pos := range [][3]int{{0, 0, 0}, {15, 0, 15}} {
+		if block, ok := sf.GetBlockAt(pos[0], pos[1], pos[2]); !ok || block.ID != "minecraft:stone" {
+			t.Errorf("Expected stone at %v, got %+v", pos, block)
+		}
+	}
+	if block, _ := sf.GetBlockAt(1, 0, 0); block.ID != "minecraft:air" {
+		t.Errorf("Expected air at 1,0,0, got %+v", block)
+	}
+
+	if len(sf.TileEntities) != 1 {
+		t.Fatalf("Expected 1 tile entity, got %d", len(sf.TileEntities))
+	}
+	if te := sf.TileEntities[0]; te.ID != "minecraft:chest" || te.Position != (StandardBlockPosition{X: 3, Y: 6, Z: 4}) {
+		t.Errorf("Unexpected tile entity %+v", te)
+	}
+	if err := sf.Validate(); err != nil {
+		t.Errorf("Expected a valid result, got %v", err)
+	}
+}
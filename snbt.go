@@ -0,0 +1,336 @@
+package mcnbt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Patterns for the number forms SNBT allows in unquoted values. Anything
+// unquoted that matches none of them is read as a string.
+var (
+	snbtIntPattern    = regexp.MustCompile(`^[-+]?(?:0|[1-9][0-9]*)([bBsSlL]?)$`)
+	snbtFloatPattern  = regexp.MustCompile(`^[-+]?(?:[0-9]+[.]?|[0-9]*[.][0-9]+)(?:[eE][-+]?[0-9]+)?([fFdD])$`)
+	snbtDoublePattern = regexp.MustCompile(`^[-+]?(?:[0-9]+[.]|[0-9]*[.][0-9]+)(?:[eE][-+]?[0-9]+)?$`)
+)
+
+// ParseSNBT parses stringified NBT, as used in commands such as
+// {Items:[{id:"minecraft:stone",Count:1b}]}, into the same shape DecodeAny
+// produces, so the result can be passed to ConvertToStandard. Tags decode to
+// int8, int16, int32, int64, float32, float64, string, []byte, []int32,
+// []int64, []interface{} and map[string]interface{}, and true and false to
+// the bytes 1 and 0.
+func ParseSNBT(s string) (interface{}, error) {
+	p := &snbtParser{s: s}
+	p.skipSpace()
+	if p.pos == len(p.s) {
+		return nil, ErrEmptyData
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.s) {
+		return nil, p.errorf("unexpected %q after value", p.s[p.pos])
+	}
+	return &value, nil
+}
+
+// snbtParser reads SNBT from s, tracking the current byte offset
+type snbtParser struct {
+	s   string
+	pos int
+}
+
+// errorf returns an ErrInvalidSNBT error noting the current offset
+func (p *snbtParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w at offset %d: %s", ErrInvalidSNBT, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *snbtParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// consume skips whitespace and then c, reporting whether c was there
+func (p *snbtParser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *snbtParser) parseValue() (interface{}, error) {
+	p.skipSpace()
+	if p.pos == len(p.s) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch p.s[p.pos] {
+	case '{':
+		return p.parseCompound()
+	case '[':
+		return p.parseListOrArray()
+	case '"', '\'':
+		return p.parseQuoted()
+	}
+
+	token := p.parseUnquoted()
+	if token == "" {
+		return nil, p.errorf("unexpected %q", p.s[p.pos])
+	}
+	return snbtScalar(token), nil
+}
+
+func (p *snbtParser) parseCompound() (interface{}, error) {
+	p.pos++ // {
+	compound := make(map[string]interface{})
+	for !p.consume('}') {
+		p.skipSpace()
+		if p.pos == len(p.s) {
+			return nil, p.errorf("unterminated compound")
+		}
+
+		var key string
+		if c := p.s[p.pos]; c == '"' || c == '\'' {
+			quoted, err := p.parseQuoted()
+			if err != nil {
+				return nil, err
+			}
+			key = quoted
+		} else if key = p.parseUnquoted(); key == "" {
+			return nil, p.errorf("expected a key, found %q", c)
+		}
+
+		if !p.consume(':') {
+			return nil, p.errorf("expected ':' after key %q", key)
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		compound[key] = value
+
+		if !p.consume(',') {
+			if !p.consume('}') {
+				return nil, p.errorf("expected ',' or '}' in compound")
+			}
+			break
+		}
+	}
+	return compound, nil
+}
+
+func (p *snbtParser) parseListOrArray() (interface{}, error) {
+	p.pos++ // [
+
+	// Typed arrays start with their element type and a semicolon, e.g. [I;1,2]
+	p.skipSpace()
+	if p.pos+1 < len(p.s) && p.s[p.pos+1] == ';' {
+		kind := p.s[p.pos]
+		if strings.IndexByte("BIL", kind) < 0 {
+			return nil, p.errorf("unknown array type %q", kind)
+		}
+		p.pos += 2
+		return p.parseArray(kind)
+	}
+
+	list := []interface{}{}
+	for !p.consume(']') {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+
+		if !p.consume(',') {
+			if !p.consume(']') {
+				return nil, p.errorf("expected ',' or ']' in list")
+			}
+			break
+		}
+	}
+	return list, nil
+}
+
+// parseArray reads the elements of a byte (B), int (I) or long (L) array
+func (p *snbtParser) parseArray(kind byte) (interface{}, error) {
+	var values []int64
+	for !p.consume(']') {
+		start := p.pos
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		n, ok := snbtInteger(value)
+		if !ok || (kind == 'B' && int64(int8(n)) != n) || (kind == 'I' && int64(int32(n)) != n) {
+			p.pos = start
+			return nil, p.errorf("invalid element %v in [%c;] array", value, kind)
+		}
+		values = append(values, n)
+
+		if !p.consume(',') {
+			if !p.consume(']') {
+				return nil, p.errorf("expected ',' or ']' in array")
+			}
+			break
+		}
+	}
+
+	switch kind {
+	case 'B':
+		bytes := make([]byte, len(values))
+		for i, v := range values {
+			bytes[i] = byte(v)
+		}
+		return bytes, nil
+	case 'I':
+		ints := make([]int32, len(values))
+		for i, v := range values {
+			ints[i] = int32(v)
+		}
+		return ints, nil
+	}
+	if values == nil {
+		values = []int64{}
+	}
+	return values, nil
+}
+
+// parseQuoted reads a single- or double-quoted string, resolving escapes
+func (p *snbtParser) parseQuoted() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch c {
+		case quote:
+			return sb.String(), nil
+		case '\\':
+			if p.pos == len(p.s) {
+				return "", p.errorf("unterminated escape")
+			}
+			escaped := p.s[p.pos]
+			p.pos++
+			switch escaped {
+			case '\\', '"', '\'':
+				sb.WriteByte(escaped)
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'u':
+				if p.pos+4 > len(p.s) {
+					return "", p.errorf("truncated unicode escape")
+				}
+				r, err := strconv.ParseUint(p.s[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid unicode escape %q", p.s[p.pos:p.pos+4])
+				}
+				p.pos += 4
+				var buf [utf8.UTFMax]byte
+				sb.Write(buf[:utf8.EncodeRune(buf[:], rune(r))])
+			default:
+				return "", p.errorf("invalid escape \\%c", escaped)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// parseUnquoted reads a run of the characters allowed in unquoted keys and values
+func (p *snbtParser) parseUnquoted() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			c == '_' || c == '-' || c == '.' || c == '+' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.s[start:p.pos]
+}
+
+// snbtScalar converts an unquoted token to a typed number, a boolean byte, or
+// the token itself when it is not a number. Integers too large for their type
+// are kept as strings, as Minecraft does.
+func snbtScalar(token string) interface{} {
+	switch token {
+	case "true":
+		return int8(1)
+	case "false":
+		return int8(0)
+	}
+
+	if m := snbtIntPattern.FindStringSubmatch(token); m != nil {
+		digits := token[:len(token)-len(m[1])]
+		bits := map[string]int{"": 32, "b": 8, "s": 16, "l": 64}[strings.ToLower(m[1])]
+		n, err := strconv.ParseInt(digits, 10, bits)
+		if err != nil {
+			return token
+		}
+		switch bits {
+		case 8:
+			return int8(n)
+		case 16:
+			return int16(n)
+		case 64:
+			return n
+		}
+		return int32(n)
+	}
+
+	if m := snbtFloatPattern.FindStringSubmatch(token); m != nil {
+		digits := token[:len(token)-1]
+		if m[1] == "f" || m[1] == "F" {
+			if f, err := strconv.ParseFloat(digits, 32); err == nil {
+				return float32(f)
+			}
+			return token
+		}
+		if f, err := strconv.ParseFloat(digits, 64); err == nil {
+			return f
+		}
+		return token
+	}
+
+	if snbtDoublePattern.MatchString(token) {
+		if f, err := strconv.ParseFloat(token, 64); err == nil {
+			return f
+		}
+	}
+	return token
+}
+
+// snbtInteger returns the value of an integer tag
+func snbtInteger(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,159 @@
+package mcnbt
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestParseSNBTMatchesDecodeAny verifies nested compounds and every tag type
+// parse to the same values DecodeAny returns for the equivalent binary NBT
+func TestParseSNBTMatchesDecodeAny(t *testing.T) {
+	expected := map[string]interface{}{
+		"byte":   int8(1),
+		"short":  int16(-2),
+		"int":    int32(3),
+		"long":   int64(4),
+		"float":  float32(4.5),
+		"double": 6.25,
+		"name":   "minecraft:stone",
+		"quoted": `say "hi"`,
+		"nested": map[string]interface{}{
+			"inner": map[string]interface{}{"flag": int8(1), "off": int8(0)},
+			"list":  []interface{}{"a", "b"},
+		},
+		"items": []interface{}{
+			map[string]interface{}{"id": "minecraft:dirt", "Count": int8(3)},
+		},
+		"bytes": []byte{1, 255},
+		"ints":  []int32{-1, 2147483647},
+		"longs": []int64{9000000000},
+	}
+
+	snbt := `{
+		byte: 1b, short: -2s, int: 3, long: 4L, float: 4.5f, double: 6.25,
+		name: "minecraft:stone", quoted: 'say "hi"',
+		nested: {"inner": {flag: true, off: false}, list: [a, 'b']},
+		items: [{id: "minecraft:dirt", Count: 3b}],
+		bytes: [B; 1b, -1b], ints: [I; -1, 2147483647], longs: [L; 9000000000L],
+	}`
+	parsed, err := ParseSNBT(snbt)
+	if err != nil {
+		t.Fatalf("Failed to parse SNBT: %v", err)
+	}
+
+	decoded, err := DecodeAny(encodeTestNBT(t, expected))
+	if err != nil {
+		t.Fatalf("Failed to decode NBT: %v", err)
+	}
+
+	got := *parsed.(*interface{})
+	want := *decoded.(*interface{})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parsed SNBT does not match decoded NBT\n got: %#v\nwant: %#v", got, want)
+	}
+}
+
+// TestParseSNBTArrays verifies each typed array parses to its slice type,
+// including empty arrays
+func TestParseSNBTArrays(t *testing.T) {
+	cases := map[string]interface{}{
+		"[B;1b,2b,3b]": []byte{1, 2, 3},
+		"[I; 1, -2 ]":  []int32{1, -2},
+		"[L;1L,2l]":    []int64{1, 2},
+		"[B;]":         []byte{},
+		"[I;]":         []int32{},
+		"[L;]":         []int64{},
+		"[]":           []interface{}{},
+	}
+	for snbt, expected := range cases {
+		parsed, err := ParseSNBT(snbt)
+		if err != nil {
+			t.Errorf("%s: failed to parse: %v", snbt, err)
+			continue
+		}
+		if got := *parsed.(*interface{}); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: expected %#v, got %#v", snbt, expected, got)
+		}
+	}
+}
+
+// TestParseSNBTScalars verifies number suffixes, overflow and unquoted strings
+func TestParseSNBTScalars(t *testing.T) {
+	cases := map[string]interface{}{
+		"127b":        int8(127),
+		"128b":        "128b",
+		"1.5":         1.5,
+		"2d":          float64(2),
+		"3.f":         float32(3),
+		"1e3f":        float32(1000),
+		"-0.5D":       -0.5,
+		"7":           int32(7),
+		"3000000000":  "3000000000",
+		"minecraft:x": nil,
+		"hello_world": "hello_world",
+		`"a\\bé"`:     "a\\bé",
+	}
+	for snbt, expected := range cases {
+		parsed, err := ParseSNBT(snbt)
+		if expected == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error for a bare ':'", snbt)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: failed to parse: %v", snbt, err)
+			continue
+		}
+		if got := *parsed.(*interface{}); got != expected {
+			t.Errorf("%s: expected %#v, got %#v", snbt, expected, got)
+		}
+	}
+}
+
+// TestParseSNBTErrors verifies malformed input is reported as ErrInvalidSNBT
+func TestParseSNBTErrors(t *testing.T) {
+	for _, snbt := range []string{
+		"{a:1",
+		"{a 1}",
+		"[1 2]",
+		`"unterminated`,
+		"[B;1b,300]",
+		"[I;1.5]",
+		"[X;1]",
+		"{a:1}}",
+	} {
+		if _, err := ParseSNBT(snbt); !errors.Is(err, ErrInvalidSNBT) {
+			t.Errorf("%q: expected ErrInvalidSNBT, got %v", snbt, err)
+		}
+	}
+	if _, err := ParseSNBT("  "); !errors.Is(err, ErrEmptyData) {
+		t.Errorf("Expected ErrEmptyData for blank input, got %v", err)
+	}
+}
+
+// TestParseSNBTConvertToStandard verifies a structure written as SNBT converts like a decoded file
+func TestParseSNBTConvertToStandard(t *testing.T) {
+	parsed, err := ParseSNBT(`{
+		DataVersion: 3465,
+		size: [2, 1, 1],
+		palette: [{Name: "minecraft:air"}, {Name: "minecraft:oak_log", Properties: {axis: "x"}}],
+		blocks: [{pos: [0, 0, 0], state: 1}, {pos: [1, 0, 0], state: 0}],
+		entities: []
+	}`)
+	if err != nil {
+		t.Fatalf("Failed to parse SNBT: %v", err)
+	}
+	standard, err := ConvertToStandard(parsed)
+	if err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+	if standard.Size != (StandardSize{X: 2, Y: 1, Z: 1}) || len(standard.Blocks) != 2 {
+		t.Fatalf("Expected 2 blocks in a 2x1x1 grid, got %d in %+v", len(standard.Blocks), standard.Size)
+	}
+	block, _ := standard.GetBlockAt(0, 0, 0)
+	if p := standard.Palette[block.State]; p.Name != "minecraft:oak_log" || p.Properties["axis"] != "x" {
+		t.Errorf("Expected oak_log[axis=x] at the origin, got %+v", p)
+	}
+}
@@ -1,9 +1,12 @@
 package mcnbt
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"math/bits"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -24,17 +27,30 @@ type StandardFormat struct {
 	// Position/offset information
 	Position StandardPosition `json:"position"`
 
-	// Block data
+	// Block data. Only blocks are stored here; entities and the block entity
+	// data of blocks such as chests have their own slices.
 	Blocks []StandardBlock `json:"blocks"`
 
+	// Entities, such as mobs and armor stands
+	Entities []StandardEntity `json:"entities,omitempty"`
+
+	// Block entity data, each belonging to the block at the same position
+	TileEntities []StandardTileEntity `json:"tileEntities,omitempty"`
+
 	// Palette data
 	Palette map[int]StandardPalette `json:"palette"`
 
 	// Original format type
 	OriginalFormat string `json:"originalFormat"`
 
+	// Biome data, if the source format has any
+	Biomes *StandardBiomes `json:"biomes,omitempty"`
+
 	// Extra format-specific data that should be preserved during round-trips
 	Extra map[string]interface{} `json:"extra,omitempty"`
+
+	// Cached position index for lookups, rebuilt when Blocks changes
+	index *spatialIndex
 }
 
 type StandardMetadata struct {
@@ -53,6 +69,22 @@ type StandardMetadata struct {
 
 	// Preview image if available
 	PreviewImageData []int `json:"previewImageData,omitempty"`
+
+	// Mod IDs the schematic declares it needs, if any
+	RequiredMods []string `json:"requiredMods,omitempty"`
+}
+
+// StandardBiomes is a grid of biome names over the schematic, stored in YZX
+// order like block data. Formats with per-column biomes use a Y size of 1.
+type StandardBiomes struct {
+	// Dimensions of the biome grid
+	Size StandardSize `json:"size"`
+
+	// Biome names by palette index (e.g., "minecraft:plains")
+	Palette map[int]string `json:"palette"`
+
+	// Palette index of each cell
+	Data []int `json:"data"`
 }
 
 type StandardSize struct {
@@ -67,27 +99,60 @@ type StandardPosition struct {
 	Z int `json:"z"`
 }
 
-// StandardBlock represents a block, entity, or tile entity in the standard format
+// StandardBlock represents a block in the standard format
 type StandardBlock struct {
-	// Type of the object (block, entity, or tile entity)
+	// Type of the record, always "block". Data written by older versions may
+	// also hold "entity" and "block_entity" records; see MigrateLegacyBlocks.
 	Type string `json:"type,omitempty"`
 
-	// ID for entities and tile entities
+	// Block name, matching the palette entry
 	ID string `json:"id,omitempty"`
 
-	// Position of the block/tile entity (integer coordinates)
+	// Position of the block (integer coordinates)
 	Position StandardBlockPosition `json:"position"`
 
-	// Entity rotation
+	// Entity rotation, only set on legacy entity records
 	Rotation StandardRotation `json:"rotation,omitempty"`
 
-	// Entity motion/velocity
+	// Entity motion, only set on legacy entity records
 	Motion StandardMotion `json:"motion,omitempty"`
 
 	// State/ID of the block in the palette
 	State int `json:"state,omitempty"`
 
-	// NBT data for the block/entity/tile entity (if any)
+	// Format-specific NBT kept on the block itself (if any). Block entity
+	// data lives in StandardFormat.TileEntities instead.
+	NBT interface{} `json:"nbt,omitempty"`
+}
+
+// StandardEntity represents an entity in the standard format
+type StandardEntity struct {
+	// Entity ID (e.g., "minecraft:pig")
+	ID string `json:"id,omitempty"`
+
+	// Position of the entity (continuous coordinates)
+	Position StandardBlockPosition `json:"position"`
+
+	// Entity rotation
+	Rotation StandardRotation `json:"rotation,omitempty"`
+
+	// Entity motion/velocity
+	Motion StandardMotion `json:"motion,omitempty"`
+
+	// NBT data for the entity (if any)
+	NBT interface{} `json:"nbt,omitempty"`
+}
+
+// StandardTileEntity represents the block entity data of a block, such as a
+// chest's items, in the standard format
+type StandardTileEntity struct {
+	// Block entity ID (e.g., "minecraft:chest")
+	ID string `json:"id,omitempty"`
+
+	// Position of the block the data belongs to (integer coordinates)
+	Position StandardBlockPosition `json:"position"`
+
+	// NBT data for the block entity
 	NBT interface{} `json:"nbt,omitempty"`
 }
 
@@ -119,114 +184,461 @@ type StandardPalette struct {
 
 // ConvertToStandard converts any supported format to the StandardFormat
 func ConvertToStandard(data interface{}) (*StandardFormat, error) {
+	return ConvertToStandardContext(context.Background(), data)
+}
+
+// ConvertToStandardContext is like ConvertToStandard, but stops and returns
+// ctx.Err() once ctx is done, checking every few thousand blocks
+func ConvertToStandardContext(ctx context.Context, data interface{}) (*StandardFormat, error) {
+	sf, err := convertToStandard(ctx, data)
+	if err != nil || !verifyConversions {
+		return sf, err
+	}
+	if _, passedThrough := data.(*StandardFormat); !passedThrough {
+		if err := VerifyOrdering(sf); err != nil {
+			return nil, fmt.Errorf("converted %s blocks are out of order: %w", sf.OriginalFormat, err)
+		}
+	}
+	return sf, nil
+}
+
+// convertToStandard converts data in any supported format to StandardFormat
+func convertToStandard(ctx context.Context, data interface{}) (*StandardFormat, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Handle *interface{} type which comes from DecodeAny in decoder.go
 	if ptr, ok := data.(*interface{}); ok {
 		// Dereference the pointer to get the actual value
-		return ConvertToStandard(*ptr)
+		return convertToStandard(ctx, *ptr)
 	}
 
 	// Try to identify the format based on the structure of the data
 	switch v := data.(type) {
 	case *LitematicaNBT:
-		return convertLitematicaToStandard(v)
+		return convertLitematicaToStandard(ctx, v)
 	case *WorldEditNBT:
-		return convertWorldEditToStandard(v)
+		return convertWorldEditToStandard(ctx, v)
+	case *WorldEditV3NBT:
+		return convertWorldEditV3ToStandard(ctx, v)
+	case *WorldEditLegacyNBT:
+		return convertWorldEditLegacyToStandard(ctx, v)
 	case *CreateNBT:
-		return convertCreateToStandard(v)
+		return convertCreateToStandard(ctx, v)
+	case *NbtSchematic:
+		return convertStructureToStandard(ctx, v)
 	case *StandardFormat:
-		// Already in standard format
+		// Already in standard format, possibly written by an older version
+		v.MigrateLegacyBlocks()
 		return v, nil
 	case map[string]interface{}:
+		// Block and entity NBT such as a clipboard's pages may be stored as gzip blobs
+		expanded, changed, err := expandNestedNBT(v, scopeLayout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand nested NBT: %w", err)
+		}
+		if changed {
+			v = expanded.(map[string]interface{})
+		}
+
+		// Sponge v3 wraps the whole schematic in a "Schematic" compound
+		if inner, ok := v["Schematic"].(map[string]interface{}); ok && len(v) == 1 {
+			v = inner
+		}
+
+		// Some tools write Version as a string such as "2" or "v2", which the
+		// typed structs can't hold
+		if _, isString := v["Version"].(string); isString {
+			normalized := make(map[string]interface{}, len(v))
+			for key, value := range v {
+				normalized[key] = value
+			}
+			if version, ok := parseVersion(v["Version"]); ok {
+				normalized["Version"] = int32(version)
+			} else {
+				delete(normalized, "Version")
+			}
+			v = normalized
+		}
+
 		// Helper function to convert map to a specific format
 		convertMapToFormat := func(formatType string, dest interface{}, formatDetector func(map[string]interface{}) bool) (*StandardFormat, error) {
 			if formatDetector(v) {
-				jsonData, err := json.Marshal(v)
-				if err != nil {
-					return nil, fmt.Errorf("failed to marshal data to JSON for %s format: %w", formatType, err)
-				}
-				if err := json.Unmarshal(jsonData, dest); err != nil {
-					return nil, fmt.Errorf("failed to unmarshal data to %s format: %w", formatType, err)
+				if err := mapToStruct(v, dest); err != nil {
+					return nil, fmt.Errorf("failed to map data to %s format: %w", formatType, err)
 				}
 
 				// Use type switch to call the appropriate conversion function
+				var sf *StandardFormat
+				var err error
 				switch typedDest := dest.(type) {
 				case *LitematicaNBT:
-					return convertLitematicaToStandard(typedDest)
+					sf, err = convertLitematicaToStandard(ctx, typedDest)
 				case *WorldEditNBT:
-					return convertWorldEditToStandard(typedDest)
+					sf, err = convertWorldEditToStandard(ctx, typedDest)
+				case *WorldEditV3NBT:
+					sf, err = convertWorldEditV3ToStandard(ctx, typedDest)
+				case *WorldEditLegacyNBT:
+					sf, err = convertWorldEditLegacyToStandard(ctx, typedDest)
 				case *CreateNBT:
-					return convertCreateToStandard(typedDest)
+					sf, err = convertCreateToStandard(ctx, typedDest)
+				case *NbtSchematic:
+					sf, err = convertStructureToStandard(ctx, typedDest)
 				default:
 					return nil, fmt.Errorf("unexpected destination type for %s format", formatType)
 				}
+				if err != nil {
+					return nil, err
+				}
+
+				// The typed structs drop unknown keys, so read these from the raw map
+				sf.Metadata.RequiredMods = findRequiredMods(v)
+				return sf, nil
 			}
 			return nil, nil
 		}
 
-		// Define format detectors
-		isLitematica := func(m map[string]interface{}) bool {
-			_, hasMetadata := m["Metadata"]
-			_, hasRegions := m["Regions"]
-			return hasMetadata && hasRegions
+		// Long arrays may arrive as []int64, []uint64 or a list of longs depending on
+		// how they were decoded, so normalize them before the JSON round trip
+		if isLitematicaMap(v) {
+			v = normalizeLitematicaBlockStates(v)
+		}
+
+		// Try each format
+		if result, err := convertMapToFormat("Litematica", &LitematicaNBT{}, isLitematicaMap); err != nil {
+			return nil, err
+		} else if result != nil {
+			return result, nil
 		}
 
-		isWorldEdit := func(m map[string]interface{}) bool {
-			_, hasBlockData := m["BlockData"]
-			_, hasPalette := m["Palette"]
-			return hasBlockData && hasPalette
+		if result, err := convertMapToFormat("WorldEdit", &WorldEditNBT{}, isWorldEditMap); err != nil {
+			return nil, err
+		} else if result != nil {
+			return result, nil
 		}
 
-		isCreate := func(m map[string]interface{}) bool {
-			_, hasBlocks := m["blocks"]
-			_, hasPalette := m["palette"]
-			return hasBlocks && hasPalette
+		if result, err := convertMapToFormat("WorldEdit legacy", &WorldEditLegacyNBT{}, isWorldEditLegacyMap); err != nil {
+			return nil, err
+		} else if result != nil {
+			return result, nil
 		}
 
-		// Try each format
-		if result, err := convertMapToFormat("Litematica", &LitematicaNBT{}, isLitematica); err != nil {
+		if result, err := convertMapToFormat("WorldEdit v3", &WorldEditV3NBT{}, isWorldEditV3Map); err != nil {
 			return nil, err
 		} else if result != nil {
 			return result, nil
 		}
 
-		if result, err := convertMapToFormat("WorldEdit", &WorldEditNBT{}, isWorldEdit); err != nil {
+		if isCreateMap(v) {
+			normalized, err := normalizeCreateBlocks(v)
+			if err != nil {
+				return nil, err
+			}
+			v = normalized
+		}
+
+		if result, err := convertMapToFormat("Structure", &NbtSchematic{}, isVanillaStructureMap); err != nil {
 			return nil, err
 		} else if result != nil {
 			return result, nil
 		}
 
-		if result, err := convertMapToFormat("Create", &CreateNBT{}, isCreate); err != nil {
+		if result, err := convertMapToFormat("Create", &CreateNBT{}, isCreateMap); err != nil {
 			return nil, err
 		} else if result != nil {
 			return result, nil
 		}
 	}
 
-	return nil, fmt.Errorf("unsupported format or unable to identify format")
+	return nil, ErrUnknownFormat
+}
+
+// contextCheckInterval is how many blocks the converters process between
+// checks for cancellation
+const contextCheckInterval = 4096
+
+// checkContext returns ctx.Err() when i is a multiple of
+// contextCheckInterval, so loops can poll for cancellation cheaply
+func checkContext(ctx context.Context, i int) error {
+	if i%contextCheckInterval != 0 {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// requiredModsKeys are the keys modded tools use to list required mods
+var requiredModsKeys = []string{"RequiredMods", "required_mods"}
+
+// findRequiredMods returns the mod IDs listed under a required-mods key at
+// the root or in the Metadata compound. Entries may be plain strings or
+// compounds with an id/modid field, and the list may also be a compound keyed
+// by mod ID.
+func findRequiredMods(root map[string]interface{}) []string {
+	containers := []map[string]interface{}{root}
+	for _, key := range []string{"Metadata", "metadata"} {
+		if m, ok := root[key].(map[string]interface{}); ok {
+			containers = append(containers, m)
+		}
+	}
+
+	var mods []string
+	for _, m := range containers {
+		for _, key := range requiredModsKeys {
+			switch list := m[key].(type) {
+			case []interface{}:
+				for _, entry := range list {
+					if id := modID(entry); id != "" {
+						mods = append(mods, id)
+					}
+				}
+			case []string:
+				mods = append(mods, list...)
+			case map[string]interface{}:
+				ids := make([]string, 0, len(list))
+				for id := range list {
+					ids = append(ids, id)
+				}
+				sort.Strings(ids)
+				mods = append(mods, ids...)
+			}
+		}
+	}
+	return mods
+}
+
+// modID extracts a mod ID from a required-mods list entry
+func modID(entry interface{}) string {
+	switch e := entry.(type) {
+	case string:
+		return e
+	case map[string]interface{}:
+		for _, key := range []string{"id", "modid", "modId", "name"} {
+			if id, ok := e[key].(string); ok {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// isLitematicaMap reports whether a decoded root compound is a Litematica schematic
+func isLitematicaMap(m map[string]interface{}) bool {
+	_, hasMetadata := m["Metadata"]
+	_, hasRegions := m["Regions"]
+	return hasMetadata && hasRegions
+}
+
+// isWorldEditMap reports whether a decoded root compound is a WorldEdit
+// schematic with a string Palette
+func isWorldEditMap(m map[string]interface{}) bool {
+	_, hasBlockData := m["BlockData"]
+	_, hasPalette := m["Palette"]
+	return hasBlockData && hasPalette
+}
+
+// isWorldEditV3Map reports whether a decoded compound is the inner compound of
+// a Sponge v3 schematic, which nests its palette and block data under Blocks
+func isWorldEditV3Map(m map[string]interface{}) bool {
+	if isWorldEditLegacyMap(m) {
+		return false
+	}
+	_, hasBlocks := m["Blocks"]
+	_, hasWidth := m["Width"]
+	if version, ok := parseVersion(m["Version"]); ok && version < 3 {
+		return false
+	}
+	return hasBlocks && hasWidth
+}
+
+// isWorldEditLegacyMap reports whether a decoded root compound is a pre-1.13
+// schematic, which holds numeric IDs in Blocks and Data instead of a Palette
+func isWorldEditLegacyMap(m map[string]interface{}) bool {
+	_, hasBlocks := m["Blocks"]
+	_, hasData := m["Data"]
+	_, hasPalette := m["Palette"]
+	return hasBlocks && hasData && !hasPalette
+}
+
+// parseVersion reads a format version stored as a number or as a string
+// such as "3" or "v3", taking the leading integer of the string
+func parseVersion(v interface{}) (int, bool) {
+	s, ok := v.(string)
+	if !ok {
+		f, ok := toFloat64(v)
+		return int(f), ok
+	}
+
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "v"), "V")
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	version, err := strconv.Atoi(s[:end])
+	return version, err == nil
+}
+
+// isCreateMap reports whether a decoded root compound is a Create schematic
+func isCreateMap(m map[string]interface{}) bool {
+	_, hasBlocks := m["blocks"]
+	_, hasPalette := m["palette"]
+	_, hasSize := m["size"]
+	// Exports with inline block names may omit the shared palette
+	return hasBlocks && (hasPalette || hasSize)
+}
+
+// isVanillaStructureMap reports whether a decoded root compound is a vanilla
+// structure block export. Create saves schematics in the same layout, so
+// files showing signs of Create or its addons are left to the Create converter.
+func isVanillaStructureMap(m map[string]interface{}) bool {
+	_, hasBlocks := m["blocks"]
+	_, hasPalette := m["palette"]
+	_, hasSize := m["size"]
+	_, hasEntities := m["entities"]
+	_, hasDataVersion := m["DataVersion"]
+	return hasBlocks && hasPalette && hasSize && hasEntities && hasDataVersion && !hasCreateMarkers(m)
+}
+
+// hasCreateMarkers reports whether a structure-layout compound carries data
+// only Create writes: addon data versions such as Railways_DataVersion, a
+// tileEntities list, or create: blocks in the palette
+func hasCreateMarkers(m map[string]interface{}) bool {
+	for key := range m {
+		if key == "tileEntities" || (key != "DataVersion" && strings.HasSuffix(key, "_DataVersion")) {
+			return true
+		}
+	}
+
+	var entries []map[string]interface{}
+	switch palette := m["palette"].(type) {
+	case []map[string]interface{}:
+		entries = palette
+	case []interface{}:
+		for _, entry := range palette {
+			if e, ok := entry.(map[string]interface{}); ok {
+				entries = append(entries, e)
+			}
+		}
+	}
+	for _, entry := range entries {
+		if name, _ := entry["Name"].(string); strings.HasPrefix(name, "create:") {
+			return true
+		}
+	}
+	return false
+}
+
+// isVanillaStructure reports whether typed Create data is a vanilla structure
+// block export, by the same markers as isVanillaStructureMap
+func (c *CreateNBT) isVanillaStructure() bool {
+	if len(c.Palette) == 0 || c.DataVersion == 0 || c.TileEntities != nil || c.RailwaysDataVersion != 0 {
+		return false
+	}
+	for _, p := range c.Palette {
+		if strings.HasPrefix(p.Name, "create:") {
+			return false
+		}
+	}
+	return true
+}
+
+// isStructureMap reports whether a decoded root compound is a vanilla
+// structure that holds only entities
+func isStructureMap(m map[string]interface{}) bool {
+	_, hasSize := m["size"]
+	_, hasEntities := m["entities"]
+	return hasSize && hasEntities
 }
 
 // ConvertFromStandard converts a StandardFormat to the specified format
 func ConvertFromStandard(standard *StandardFormat, format string) (interface{}, error) {
+	return ConvertFromStandardContext(context.Background(), standard, format)
+}
+
+// ConvertFromStandardContext is like ConvertFromStandard, but stops and
+// returns ctx.Err() once ctx is done, checking every few thousand blocks
+func ConvertFromStandardContext(ctx context.Context, standard *StandardFormat, format string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if standard.hasLegacyBlocks() {
+		standard = standard.clone()
+		standard.MigrateLegacyBlocks()
+	}
+
 	switch format {
 	case "standard":
 		return standard, nil
 	case "json":
 		return standard, nil
 	case "litematica":
-		return convertStandardToLitematica(standard)
+		return convertStandardToLitematica(ctx, standard)
 	case "worldedit":
-		return convertStandardToWorldEdit(standard)
+		return convertStandardToWorldEdit(ctx, standard)
 	case "create":
-		return convertStandardToCreate(standard)
+		return convertStandardToCreate(ctx, standard)
 	default:
-		return nil, fmt.Errorf("unsupported output format: %s", format)
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
 	}
 }
 
 // convertLitematicaToStandard converts a LitematicaNBT to StandardFormat
-func convertLitematicaToStandard(litematica *LitematicaNBT) (*StandardFormat, error) {
+func convertLitematicaToStandard(ctx context.Context, litematica *LitematicaNBT) (*StandardFormat, error) {
+	sf, region, err := litematicaStandardHeader(ctx, litematica)
+	if err != nil {
+		return nil, err
+	}
+
+	sizeX, sizeY, sizeZ := sf.Size.X, sf.Size.Y, sf.Size.Z
+	totalVolume := sizeX * sizeY * sizeZ
+	bitsPerEntry := litematicaBitsPerEntry(len(region.BlockStatePalette))
+
+	// Block names by palette index, to avoid a map lookup per block
+	names := make([]string, len(region.BlockStatePalette))
+	for i, palette := range region.BlockStatePalette {
+		names[i] = palette.Name
+	}
+
+	// Unpack the packed BlockStates straight into blocks with positions.
+	// Litematica order is YZX: Y is the outermost loop and X the innermost,
+	// so X varies fastest. Blocks are filled in place, which is much cheaper
+	// than appending copies.
+	sf.Blocks = make([]StandardBlock, totalVolume)
+	idx := 0
+	for y := 0; y < sizeY; y++ {
+		for z := 0; z < sizeZ; z++ {
+			for x := 0; x < sizeX; x++ {
+				if err := checkContext(ctx, idx); err != nil {
+					return nil, err
+				}
+				// Entries missing from a short array read as 0
+				paletteIdx, _ := litematicaStateAt(region.BlockStates, bitsPerEntry, idx)
+
+				block := &sf.Blocks[idx]
+				idx++
+				block.Type = "block"
+				block.State = paletteIdx
+				block.Position = StandardBlockPosition{X: float64(x), Y: float64(y), Z: float64(z)}
+
+				// Set the block ID from palette
+				if paletteIdx < len(names) {
+					block.ID = names[paletteIdx]
+				} else {
+					warn(ctx, WarningStateNotInPalette, "block at %d,%d,%d has state %d, which is not in the palette", x, y, z, paletteIdx)
+				}
+			}
+		}
+	}
+
+	return sf, nil
+}
+
+// litematicaStandardHeader converts everything but the blocks of a
+// LitematicaNBT, and returns the region the blocks should be read from
+func litematicaStandardHeader(ctx context.Context, litematica *LitematicaNBT) (*StandardFormat, LitematicaRegion, error) {
 	if litematica == nil {
-		return nil, fmt.Errorf("litematica data is nil")
+		return nil, LitematicaRegion{}, fmt.Errorf("litematica data is nil")
 	}
 
 	sf := &StandardFormat{
@@ -253,14 +665,25 @@ func convertLitematicaToStandard(litematica *LitematicaNBT) (*StandardFormat, er
 	}
 
 	if len(litematica.Regions) == 0 {
-		return nil, fmt.Errorf("no regions found in litematica file")
+		return nil, LitematicaRegion{}, ErrNoRegions
 	}
 
-	// Extract the first region
-	var region LitematicaRegion
-	for _, r := range litematica.Regions {
-		region = r
-		break
+	// Only the first region by name is converted
+	region, _ := firstLitematicaRegion(litematica.Regions)
+	if len(litematica.Regions) > 1 {
+		warn(ctx, WarningRegionsDropped, "only the first of %d regions by name was converted", len(litematica.Regions))
+	}
+
+	// Scheduled ticks have no place in the standard blocks, so they are kept
+	// aside and written back when converting to litematica
+	if len(region.PendingBlockTicks) > 0 || len(region.PendingFluidTicks) > 0 {
+		sf.Extra = make(map[string]interface{})
+		if len(region.PendingBlockTicks) > 0 {
+			sf.Extra["PendingBlockTicks"] = region.PendingBlockTicks
+		}
+		if len(region.PendingFluidTicks) > 0 {
+			sf.Extra["PendingFluidTicks"] = region.PendingFluidTicks
+		}
 	}
 
 	// Handle negative sizes (Litematica uses negative sizes to indicate direction)
@@ -272,9 +695,20 @@ func convertLitematicaToStandard(litematica *LitematicaNBT) (*StandardFormat, er
 	sf.Size.Y = sizeY
 	sf.Size.Z = sizeZ
 
-	sf.Position.X = int(region.Position.X)
-	sf.Position.Y = int(region.Position.Y)
-	sf.Position.Z = int(region.Position.Z)
+	minCorner, err := litematicaRegionMinCorner(region, litematica.Version)
+	if err != nil {
+		return nil, LitematicaRegion{}, err
+	}
+	sf.Position = minCorner
+
+	// Blocks are always stored from the minimum corner, but the region's
+	// growth direction is kept so it can be written back the same way
+	if region.Size.X < 0 || region.Size.Y < 0 || region.Size.Z < 0 {
+		if sf.Extra == nil {
+			sf.Extra = make(map[string]interface{})
+		}
+		sf.Extra["RegionSizeSigns"] = StandardPosition{X: sign(region.Size.X), Y: sign(region.Size.Y), Z: sign(region.Size.Z)}
+	}
 
 	// Convert palette
 	sf.Palette = make(map[int]StandardPalette, len(region.BlockStatePalette))
@@ -289,97 +723,202 @@ func convertLitematicaToStandard(litematica *LitematicaNBT) (*StandardFormat, er
 		}
 	}
 
-	// Decode the packed BlockStates int64 array
-	totalVolume := sizeX * sizeY * sizeZ
-	paletteSize := len(region.BlockStatePalette)
+	// Build a map of tile entities by block index, so they come out in
+	// block order. Positions outside the region can't match a block and are dropped.
+	tileEntityMap := make(map[int]LitematicaTileEntity, len(region.TileEntities))
+	for _, te := range region.TileEntities {
+		x, y, z := int(te.X), int(te.Y), int(te.Z)
+		if x < 0 || y < 0 || z < 0 || x >= sizeX || y >= sizeY || z >= sizeZ {
+			warn(ctx, WarningTileEntityDropped, "%s at %d,%d,%d is outside the %dx%dx%d region", te.Id, x, y, z, sizeX, sizeY, sizeZ)
+			continue
+		}
+		tileEntityMap[(y*sizeZ+z)*sizeX+x] = te
+	}
+	tileEntityIndices := make([]int, 0, len(tileEntityMap))
+	for i := range tileEntityMap {
+		tileEntityIndices = append(tileEntityIndices, i)
+	}
+	sort.Ints(tileEntityIndices)
+	for _, i := range tileEntityIndices {
+		te := tileEntityMap[i]
+
+		// Build NBT from tile entity fields
+		nbtData := make(map[string]interface{})
+		nbtData["id"] = te.Id
+		nbtData["x"] = te.X
+		nbtData["y"] = te.Y
+		nbtData["z"] = te.Z
+		if len(te.Items) > 0 {
+			nbtData["Items"] = te.Items
+		}
+		if te.CustomName != "" {
+			nbtData["CustomName"] = te.CustomName
+		}
+		if te.Lock != "" {
+			nbtData["Lock"] = te.Lock
+		}
+		sf.TileEntities = append(sf.TileEntities, StandardTileEntity{
+			ID:       te.Id,
+			Position: StandardBlockPosition{X: float64(te.X), Y: float64(te.Y), Z: float64(te.Z)},
+			NBT:      nbtData,
+		})
+	}
 
-	// Calculate bits per entry
-	bitsPerEntry := 2 // minimum 2
-	if paletteSize > 0 {
-		b := bits.Len(uint(paletteSize - 1))
-		if b > bitsPerEntry {
-			bitsPerEntry = b
+	// Entity positions are relative to the region's minimum corner, like blocks
+	for _, entity := range region.Entities {
+		if len(entity.Pos) < 3 {
+			warn(ctx, WarningEntitySkipped, "%s has position %v", entity.ID, entity.Pos)
+			continue
 		}
+		sf.Entities = append(sf.Entities, litematicaStandardEntity(entity))
 	}
 
-	// BlockStates is now directly []int64
-	longs := region.BlockStates
+	return sf, region, nil
+}
+
+// litematicaStandardEntity converts a Litematica entity with a full position
+func litematicaStandardEntity(entity LitematicaEntity) StandardEntity {
+	se := StandardEntity{
+		ID: entity.ID,
+		Position: StandardBlockPosition{
+			X: entity.Pos[0],
+			Y: entity.Pos[1],
+			Z: entity.Pos[2],
+		},
+	}
+	if len(entity.Rotation) >= 2 {
+		se.Rotation = StandardRotation{Yaw: float64(entity.Rotation[0]), Pitch: float64(entity.Rotation[1])}
+	}
+	if len(entity.Motion) >= 3 {
+		se.Motion = StandardMotion{X: entity.Motion[0], Y: entity.Motion[1], Z: entity.Motion[2]}
+	}
 
-	// Unpack palette indices from the long array
-	// Litematica: entries do NOT cross long boundaries
-	entriesPerLong := 64 / bitsPerEntry
-	mask := int64((1 << bitsPerEntry) - 1)
+	// Riders are kept nested in the entity NBT so the mount relationship survives
+	if len(entity.Passengers) > 0 {
+		se.NBT = map[string]interface{}{"Passengers": entity.Passengers}
+	}
+	return se
+}
 
-	paletteIndices := make([]int, totalVolume)
-	for i := 0; i < totalVolume; i++ {
-		longIndex := i / entriesPerLong
-		bitOffset := (i % entriesPerLong) * bitsPerEntry
+// normalizeLitematicaBlockStates returns a shallow copy of a litematica map
+// with every region's BlockStates converted to []int64. Regions stored as a
+// list rather than a name-keyed compound are keyed by synthesized names.
+func normalizeLitematicaBlockStates(m map[string]interface{}) map[string]interface{} {
+	regions, ok := m["Regions"].(map[string]interface{})
+	if list, isList := m["Regions"].([]interface{}); isList {
+		regions, ok = regionsFromList(list), true
+	}
+	if !ok {
+		return m
+	}
 
-		if longIndex < len(longs) {
-			paletteIndices[i] = int((longs[longIndex] >> bitOffset) & mask)
+	normalizedRegions := make(map[string]interface{}, len(regions))
+	for name, r := range regions {
+		region, ok := r.(map[string]interface{})
+		if !ok {
+			normalizedRegions[name] = r
+			continue
+		}
+		normalizedRegion := make(map[string]interface{}, len(region))
+		for key, value := range region {
+			normalizedRegion[key] = value
 		}
+		if states, ok := toInt64Slice(region["BlockStates"]); ok {
+			normalizedRegion["BlockStates"] = states
+		}
+		normalizedRegions[name] = normalizedRegion
 	}
 
-	// Build a map of tile entity positions for merging
-	tileEntityMap := make(map[[3]int]LitematicaTileEntity)
-	for _, te := range region.TileEntities {
-		key := [3]int{int(te.X), int(te.Y), int(te.Z)}
-		tileEntityMap[key] = te
+	normalized := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		normalized[key] = value
 	}
+	normalized["Regions"] = normalizedRegions
+	return normalized
+}
 
-	// Convert XZY-ordered indices to blocks with positions
-	// Litematica order: iterate X, then Z, then Y (innermost)
-	sf.Blocks = make([]StandardBlock, 0, totalVolume)
-	idx := 0
-	for y := 0; y < sizeY; y++ {
-		for z := 0; z < sizeZ; z++ {
-			for x := 0; x < sizeX; x++ {
-				if idx >= len(paletteIndices) {
-					break
-				}
-				paletteIdx := paletteIndices[idx]
-				idx++
+// regionsFromList keys a list of region compounds by name. A region's own
+// "Name" tag is used when present and unique; otherwise it is named
+// "Region N" after its 1-based position in the list.
+func regionsFromList(list []interface{}) map[string]interface{} {
+	regions := make(map[string]interface{}, len(list))
+	for i, r := range list {
+		region, _ := r.(map[string]interface{})
+		name, _ := region["Name"].(string)
+		for n := i + 1; name == "" || regions[name] != nil; n++ {
+			name = fmt.Sprintf("Region %d", n)
+		}
+		regions[name] = r
+	}
+	return regions
+}
 
-				block := StandardBlock{
-					Type:  "block",
-					State: paletteIdx,
-					Position: StandardBlockPosition{
-						X: float64(x),
-						Y: float64(y),
-						Z: float64(z),
-					},
-				}
+// toInt64Slice converts every long array representation go-mc can produce
+// ([]int64, []uint64 and a TAG_List of longs as []interface{}) to []int64.
+// Values are reinterpreted bit-for-bit, which is what packed block states need.
+func toInt64Slice(v interface{}) ([]int64, bool) {
+	switch vals := v.(type) {
+	case []int64:
+		return vals, true
+	case []uint64:
+		result := make([]int64, len(vals))
+		for i, val := range vals {
+			result[i] = int64(val)
+		}
+		return result, true
+	case []interface{}:
+		result := make([]int64, len(vals))
+		for i, val := range vals {
+			switch n := val.(type) {
+			case int64:
+				result[i] = n
+			case uint64:
+				result[i] = int64(n)
+			case int32:
+				result[i] = int64(n)
+			case int:
+				result[i] = int64(n)
+			default:
+				return nil, false
+			}
+		}
+		return result, true
+	}
+	return nil, false
+}
 
-				// Check if there's a tile entity at this position
-				key := [3]int{x, y, z}
-				if te, ok := tileEntityMap[key]; ok {
-					block.Type = "block_entity"
-					block.ID = te.Id
-					// Build NBT from tile entity fields
-					nbtData := make(map[string]interface{})
-					nbtData["id"] = te.Id
-					nbtData["x"] = int(te.X)
-					nbtData["y"] = int(te.Y)
-					nbtData["z"] = int(te.Z)
-					if len(te.Items) > 0 {
-						nbtData["Items"] = te.Items
-					}
-					block.NBT = nbtData
-				}
+// Supported Litematica schematic versions. Version 0 is accepted for data
+// that was built in memory without a version.
+const (
+	litematicaMinVersion     = 4
+	litematicaMaxVersion     = 7
+	litematicaDefaultVersion = 6
+)
 
-				// Set the block ID from palette
-				if p, ok := sf.Palette[paletteIdx]; ok {
-					if block.ID == "" {
-						block.ID = p.Name
-					}
-				}
+// litematicaRegionMinCorner returns the minimum corner of a region.
+// In every supported version (including the common 5 and 6 layouts) the region
+// Position is the origin corner and a negative Size component means the region
+// extends from that corner toward negative coordinates, so the minimum corner
+// is Position + Size + 1 on those axes. Block states are always stored
+// relative to the minimum corner.
+func litematicaRegionMinCorner(region LitematicaRegion, version int32) (StandardPosition, error) {
+	if version != 0 && (version < litematicaMinVersion || version > litematicaMaxVersion) {
+		return StandardPosition{}, fmt.Errorf("unsupported litematica version %d (supported: %d-%d)",
+			version, litematicaMinVersion, litematicaMaxVersion)
+	}
 
-				sf.Blocks = append(sf.Blocks, block)
-			}
+	corner := func(pos, size int32) int {
+		if size < 0 {
+			return int(pos + size + 1)
 		}
+		return int(pos)
 	}
 
-	return sf, nil
+	return StandardPosition{
+		X: corner(region.Position.X, region.Size.X),
+		Y: corner(region.Position.Y, region.Size.Y),
+		Z: corner(region.Position.Z, region.Size.Z),
+	}, nil
 }
 
 // Helper function to get absolute value of an integer
@@ -390,8 +929,16 @@ func abs(x int) int {
 	return x
 }
 
+// sign returns -1 for negative values and 1 otherwise
+func sign(x int32) int {
+	if x < 0 {
+		return -1
+	}
+	return 1
+}
+
 // convertWorldEditToStandard converts a WorldEditNBT to StandardFormat
-func convertWorldEditToStandard(worldEdit *WorldEditNBT) (*StandardFormat, error) {
+func convertWorldEditToStandard(ctx context.Context, worldEdit *WorldEditNBT) (*StandardFormat, error) {
 	if worldEdit == nil {
 		return nil, fmt.Errorf("worldEdit data is nil")
 	}
@@ -416,11 +963,19 @@ func convertWorldEditToStandard(worldEdit *WorldEditNBT) (*StandardFormat, error
 		sf.Position.Z = int(worldEdit.Offset[2])
 	}
 
+	// WorldEdit keeps the paste offset relative to the player in its metadata,
+	// separate from the world position in Offset
+	if m := worldEdit.Metadata; m.WEOffsetX != 0 || m.WEOffsetY != 0 || m.WEOffsetZ != 0 {
+		sf.Extra = map[string]interface{}{
+			"WEOffset": StandardPosition{X: int(m.WEOffsetX), Y: int(m.WEOffsetY), Z: int(m.WEOffsetZ)},
+		}
+	}
+
 	// Convert palette — WorldEdit palette maps "name[props]" → index
 	// The map VALUES are the palette indices, not iteration order
 	sf.Palette = make(map[int]StandardPalette, len(worldEdit.Palette))
 	for name, paletteIndex := range worldEdit.Palette {
-		blockName, properties := parseWorldEditBlockName(name)
+		blockName, properties := DecodePropertyString(name)
 		sf.Palette[int(paletteIndex)] = StandardPalette{
 			Name:       blockName,
 			Properties: properties,
@@ -430,17 +985,18 @@ func convertWorldEditToStandard(worldEdit *WorldEditNBT) (*StandardFormat, error
 	// Decode the varint-encoded BlockData byte array
 	// WorldEdit BlockData is a varint-encoded stream iterated in YZX order
 	totalVolume := width * height * length
-	paletteIndices := make([]int, 0, totalVolume)
+	paletteIndices, err := decodeVarintBlockData(worldEdit.BlockData, totalVolume)
+	if err != nil {
+		return nil, err
+	}
 
-	blockDataBytes := worldEdit.BlockData
-	offset := 0
-	for offset < len(blockDataBytes) && len(paletteIndices) < totalVolume {
-		value, bytesRead := readVarint(blockDataBytes, offset)
-		if bytesRead == 0 {
-			break
+	// Sponge v2 stores one biome per column
+	if len(worldEdit.BiomePalette) > 0 {
+		biomes, err := decodeWorldEditBiomes(worldEdit.BiomePalette, worldEdit.BiomeData, StandardSize{X: width, Y: 1, Z: length})
+		if err != nil {
+			return nil, err
 		}
-		offset += bytesRead
-		paletteIndices = append(paletteIndices, value)
+		sf.Biomes = biomes
 	}
 
 	// Build a map of block entity positions for merging
@@ -448,15 +1004,32 @@ func convertWorldEditToStandard(worldEdit *WorldEditNBT) (*StandardFormat, error
 	for _, be := range worldEdit.BlockEntities {
 		x, y, z := extractBlockEntityPosition(be)
 		key := [3]int{int(x), int(y), int(z)}
+		if key[0] < 0 || key[1] < 0 || key[2] < 0 || key[0] >= width || key[1] >= height || key[2] >= length {
+			warn(ctx, WarningTileEntityDropped, "%v at %d,%d,%d is outside the %dx%dx%d schematic", be["Id"], key[0], key[1], key[2], width, height, length)
+			continue
+		}
 		blockEntityMap[key] = be
 	}
 
+	// Entities keep their whole compound as NBT, riders included
+	for _, entity := range worldEdit.Entities {
+		se, ok := worldEditStandardEntity(entity)
+		if !ok {
+			warn(ctx, WarningEntitySkipped, "%v has position %v", entity["Id"], entity["Pos"])
+			continue
+		}
+		sf.Entities = append(sf.Entities, se)
+	}
+
 	// Convert YZX-ordered indices to blocks with positions
 	sf.Blocks = make([]StandardBlock, 0, totalVolume)
 	idx := 0
 	for y := 0; y < height; y++ {
 		for z := 0; z < length; z++ {
 			for x := 0; x < width; x++ {
+				if err := checkContext(ctx, idx); err != nil {
+					return nil, err
+				}
 				if idx >= len(paletteIndices) {
 					break
 				}
@@ -476,18 +1049,16 @@ func convertWorldEditToStandard(worldEdit *WorldEditNBT) (*StandardFormat, error
 				// Check if there's a block entity at this position
 				key := [3]int{x, y, z}
 				if be, ok := blockEntityMap[key]; ok {
-					block.Type = "block_entity"
-					if id, ok := be["Id"].(string); ok {
-						block.ID = id
-					}
-					block.NBT = be
+					te := StandardTileEntity{Position: block.Position, NBT: be}
+					te.ID, _ = be["Id"].(string)
+					sf.TileEntities = append(sf.TileEntities, te)
 				}
 
 				// Set the block ID from palette
 				if p, ok := sf.Palette[paletteIdx]; ok {
-					if block.ID == "" {
-						block.ID = p.Name
-					}
+					block.ID = p.Name
+				} else {
+					warn(ctx, WarningStateNotInPalette, "block at %d,%d,%d has state %d, which is not in the palette", x, y, z, paletteIdx)
 				}
 
 				sf.Blocks = append(sf.Blocks, block)
@@ -498,24 +1069,74 @@ func convertWorldEditToStandard(worldEdit *WorldEditNBT) (*StandardFormat, error
 	return sf, nil
 }
 
-// parseWorldEditBlockName parses "minecraft:block[prop1=val1,prop2=val2]" into name and properties
-func parseWorldEditBlockName(name string) (string, map[string]string) {
-	nameAndProps := strings.SplitN(name, "[", 2)
-	blockName := nameAndProps[0]
-	properties := make(map[string]string)
+// EncodePropertyString formats a block name and properties in the bracket
+// style used by WorldEdit palettes, e.g. "minecraft:oak_log[axis=y]".
+// Keys are sorted so the same block state always yields the same string.
+func EncodePropertyString(name string, props map[string]string) string {
+	if len(props) == 0 {
+		return name
+	}
+
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(name)
+	sb.WriteByte('[')
+	for i, key := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(key)
+		sb.WriteByte('=')
+		sb.WriteString(props[key])
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// DecodePropertyString parses "minecraft:block[prop1=val1,prop2=val2]" into name and properties
+func DecodePropertyString(s string) (name string, props map[string]string) {
+	nameAndProps := strings.SplitN(s, "[", 2)
+	name = nameAndProps[0]
+	props = make(map[string]string)
 
 	if len(nameAndProps) > 1 {
 		propsStr := strings.TrimSuffix(nameAndProps[1], "]")
-		props := strings.Split(propsStr, ",")
-		for _, prop := range props {
+		for _, prop := range strings.Split(propsStr, ",") {
 			kv := strings.SplitN(prop, "=", 2)
 			if len(kv) == 2 {
-				properties[kv[0]] = kv[1]
+				props[kv[0]] = kv[1]
 			}
 		}
 	}
 
-	return blockName, properties
+	return name, props
+}
+
+// decodeVarintBlockData decodes volume palette indices from a Sponge schematic
+// BlockData stream, where each index is an unsigned LEB128 varint
+func decodeVarintBlockData(data []byte, volume int) ([]int, error) {
+	indices := make([]int, 0, volume)
+	offset := 0
+	for len(indices) < volume {
+		if offset >= len(data) {
+			return nil, fmt.Errorf("block data ended after %d of %d entries", len(indices), volume)
+		}
+		value, bytesRead := readVarint(data, offset)
+		if bytesRead > 5 {
+			return nil, fmt.Errorf("block data varint at byte %d is longer than 5 bytes", offset)
+		}
+		if data[offset+bytesRead-1]&0x80 != 0 {
+			return nil, fmt.Errorf("block data varint at byte %d is truncated", offset)
+		}
+		offset += bytesRead
+		indices = append(indices, value)
+	}
+	return indices, nil
 }
 
 // readVarint reads a varint from a byte slice at the given offset.
@@ -543,11 +1164,9 @@ func readVarint(data []byte, offset int) (int, int) {
 
 // Helper function to extract position from a block entity
 func extractBlockEntityPosition(blockEntity map[string]any) (x, y, z float64) {
-	if vals, ok := blockEntity["Pos"].([]interface{}); ok && len(vals) >= 3 {
-		x, _ = toFloat64(vals[0])
-		y, _ = toFloat64(vals[1])
-		z, _ = toFloat64(vals[2])
-		return
+	// Pos is an int array when decoded directly, and a list after a JSON round trip
+	if vals := numberList(blockEntity["Pos"]); len(vals) >= 3 {
+		return vals[0], vals[1], vals[2]
 	}
 	// Try individual x/y/z fields
 	if v, ok := blockEntity["x"]; ok {
@@ -568,6 +1187,10 @@ func toFloat64(v interface{}) (float64, bool) {
 		return val, true
 	case int:
 		return float64(val), true
+	case int8:
+		return float64(val), true
+	case int16:
+		return float64(val), true
 	case int32:
 		return float64(val), true
 	case int64:
@@ -578,8 +1201,165 @@ func toFloat64(v interface{}) (float64, bool) {
 	return 0, false
 }
 
+// normalizeCreateBlocks returns a shallow copy of a Create map whose blocks are
+// a list of compounds with decoded per-block NBT, so the JSON round trip into
+// CreateNBT sees a uniform layout
+func normalizeCreateBlocks(m map[string]interface{}) (map[string]interface{}, error) {
+	var blocks []interface{}
+	switch b := m["blocks"].(type) {
+	case map[string]interface{}:
+		// Some Create versions store blocks as parallel arrays instead of a list of compounds
+		zipped, err := zipCreateBlocks(b)
+		if err != nil {
+			return nil, err
+		}
+		blocks = zipped
+	case []interface{}:
+		blocks = b
+	default:
+		return m, nil
+	}
+
+	// Compressed per-block NBT would otherwise become a base64 string in JSON
+	normalizedBlocks := make([]interface{}, len(blocks))
+	for i, block := range blocks {
+		if bm, ok := block.(map[string]interface{}); ok {
+			if raw, ok := bm["nbt"].([]byte); ok {
+				decoded := make(map[string]interface{}, len(bm))
+				for key, value := range bm {
+					decoded[key] = value
+				}
+				decoded["nbt"] = decodeBlockNBT(raw)
+				block = decoded
+			}
+		}
+		normalizedBlocks[i] = block
+	}
+
+	normalized := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		normalized[key] = value
+	}
+	normalized["blocks"] = normalizedBlocks
+	return normalized, nil
+}
+
+// decodeBlockNBT decodes per-block NBT stored as a (possibly compressed) byte
+// blob into a map. Other values, and blobs that fail to decode, are returned unchanged.
+func decodeBlockNBT(v interface{}) interface{} {
+	raw, ok := v.([]byte)
+	if !ok || len(raw) == 0 {
+		return v
+	}
+	decoded, err := DecodeAny(raw)
+	if err != nil {
+		return v
+	}
+	if ptr, ok := decoded.(*interface{}); ok {
+		return *ptr
+	}
+	return decoded
+}
+
+// zipCreateBlocks converts a structure-of-arrays blocks compound, holding
+// parallel "pos", "state" and optional "nbt" arrays, into a list of block compounds
+func zipCreateBlocks(soa map[string]interface{}) ([]interface{}, error) {
+	states, ok := toInt32Slice(soa["state"])
+	if !ok {
+		return nil, fmt.Errorf("create blocks: missing or invalid state array")
+	}
+
+	positions, err := zipPositions(soa["pos"], len(states))
+	if err != nil {
+		return nil, err
+	}
+
+	nbtValues, _ := soa["nbt"].([]interface{})
+
+	blocks := make([]interface{}, len(states))
+	for i, state := range states {
+		block := map[string]interface{}{
+			"pos":   positions[i],
+			"state": state,
+		}
+		if i < len(nbtValues) && nbtValues[i] != nil {
+			block["nbt"] = nbtValues[i]
+		}
+		blocks[i] = block
+	}
+	return blocks, nil
+}
+
+// zipPositions reads n positions from either a list of [x, y, z] lists or a flat coordinate array
+func zipPositions(v interface{}, n int) ([][]int32, error) {
+	positions := make([][]int32, 0, n)
+
+	if flat, ok := toInt32Slice(v); ok && len(flat) == 3*n {
+		for i := 0; i < n; i++ {
+			positions = append(positions, flat[i*3:i*3+3])
+		}
+		return positions, nil
+	}
+
+	if list, ok := v.([]interface{}); ok && len(list) == n {
+		for _, item := range list {
+			pos, ok := toInt32Slice(item)
+			if !ok || len(pos) < 3 {
+				return nil, fmt.Errorf("create blocks: invalid position %v", item)
+			}
+			positions = append(positions, pos)
+		}
+		return positions, nil
+	}
+
+	return nil, fmt.Errorf("create blocks: pos array does not match %d states", n)
+}
+
+// toInt32Slice converts the numeric array and list types produced by the decoders to []int32
+func toInt32Slice(v interface{}) ([]int32, bool) {
+	switch vals := v.(type) {
+	case []int32:
+		return vals, true
+	case []int64:
+		result := make([]int32, len(vals))
+		for i, val := range vals {
+			result[i] = int32(val)
+		}
+		return result, true
+	case []interface{}:
+		result := make([]int32, len(vals))
+		for i, val := range vals {
+			f, ok := toFloat64(val)
+			if !ok {
+				return nil, false
+			}
+			result[i] = int32(f)
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// toFloat64Slice converts a list of numbers to []float64, returning nil if
+// v is not a list or holds anything else
+func toFloat64Slice(v interface{}) []float64 {
+	vals, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]float64, len(vals))
+	for i, val := range vals {
+		f, ok := toFloat64(val)
+		if !ok {
+			return nil
+		}
+		result[i] = f
+	}
+	return result
+}
+
 // convertCreateToStandard converts a CreateNBT (vanilla structure format) to StandardFormat
-func convertCreateToStandard(create *CreateNBT) (*StandardFormat, error) {
+func convertCreateToStandard(ctx context.Context, create *CreateNBT) (*StandardFormat, error) {
 	if create == nil {
 		return nil, fmt.Errorf("create data is nil")
 	}
@@ -589,12 +1369,21 @@ func convertCreateToStandard(create *CreateNBT) (*StandardFormat, error) {
 		DataVersion:    int(create.DataVersion),
 		Extra:          make(map[string]interface{}),
 	}
+	// DecodeCreate also reads vanilla structures, which DecodeAny data reports as such
+	if create.isVanillaStructure() {
+		sf.OriginalFormat = "structure"
+	}
 
 	// Preserve mod-specific data versions
 	if create.RailwaysDataVersion != 0 {
 		sf.Extra["Railways_DataVersion"] = create.RailwaysDataVersion
 	}
 
+	// Worldgen templates may carry a processor list, which is written back as is
+	if create.Processors != nil {
+		sf.Extra["processors"] = create.Processors
+	}
+
 	// Set size
 	if len(create.Size) >= 3 {
 		sf.Size.X = int(create.Size[0])
@@ -626,14 +1415,29 @@ func convertCreateToStandard(create *CreateNBT) (*StandardFormat, error) {
 
 	// Process blocks
 	sf.Blocks = make([]StandardBlock, 0, len(create.Blocks))
-	for _, block := range create.Blocks {
+	var inlineStates *paletteIndex
+	for i, block := range create.Blocks {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
+		}
 		if len(block.Pos) < 3 {
+			warn(ctx, WarningBlockSkipped, "block %d has position %v", i, block.Pos)
 			continue
 		}
 
+		state := int(block.State)
+
+		// Blocks carrying an inline name extend the palette on the fly
+		if block.Name != "" {
+			if inlineStates == nil {
+				inlineStates = newPaletteIndex(sf.Palette)
+			}
+			state = inlineStates.indexFor(block.Name, block.Properties)
+		}
+
 		sb := StandardBlock{
 			Type:  "block",
-			State: int(block.State),
+			State: state,
 			Position: StandardBlockPosition{
 				X: float64(block.Pos[0]),
 				Y: float64(block.Pos[1]),
@@ -642,24 +1446,25 @@ func convertCreateToStandard(create *CreateNBT) (*StandardFormat, error) {
 		}
 
 		// Set the block ID from palette
-		if p, ok := sf.Palette[int(block.State)]; ok {
+		if p, ok := sf.Palette[state]; ok {
 			sb.ID = p.Name
+		} else {
+			warn(ctx, WarningStateNotInPalette, "block %d has state %d, which is not in the palette", i, state)
 		}
 
-		// Handle NBT from the block itself
-		if block.Nbt != nil {
-			sb.NBT = block.Nbt
-		}
-
-		// Check if there's a tile entity at this position
+		// Block entity data is normally kept inline on the block, but a
+		// separate tileEntities entry at the same position takes precedence
 		key := [3]int32{block.Pos[0], block.Pos[1], block.Pos[2]}
 		if te, ok := tileEntityMap[key]; ok {
-			sb.Type = "block_entity"
-			if idVal, ok := te.NBT["id"].(string); ok {
-				sb.ID = idVal
-			}
-			sb.NBT = te.NBT
+			sf.TileEntities = append(sf.TileEntities, standardTileEntity(sb.Position, te.NBT))
 			delete(tileEntityMap, key) // mark as consumed
+		} else if block.Nbt != nil {
+			decoded := decodeBlockNBT(block.Nbt)
+			if nbtMap, ok := decoded.(map[string]interface{}); ok {
+				sf.TileEntities = append(sf.TileEntities, standardTileEntity(sb.Position, nbtMap))
+			} else {
+				sb.NBT = decoded
+			}
 		}
 
 		sf.Blocks = append(sf.Blocks, sb)
@@ -668,34 +1473,26 @@ func convertCreateToStandard(create *CreateNBT) (*StandardFormat, error) {
 	// Add any remaining tile entities that weren't matched to blocks
 	for _, te := range tileEntityMap {
 		if len(te.Pos) < 3 {
+			warn(ctx, WarningTileEntityDropped, "tile entity has position %v", te.Pos)
 			continue
 		}
-		id := "unknown"
-		if idVal, ok := te.NBT["id"].(string); ok {
-			id = idVal
-		}
-		sb := StandardBlock{
-			Type: "block_entity",
-			ID:   id,
-			Position: StandardBlockPosition{
-				X: float64(te.Pos[0]),
-				Y: float64(te.Pos[1]),
-				Z: float64(te.Pos[2]),
-			},
-			NBT: te.NBT,
+		position := StandardBlockPosition{
+			X: float64(te.Pos[0]),
+			Y: float64(te.Pos[1]),
+			Z: float64(te.Pos[2]),
 		}
-		sf.Blocks = append(sf.Blocks, sb)
+		sf.TileEntities = append(sf.TileEntities, standardTileEntity(position, te.NBT))
 	}
 
 	// Convert entities
 	for _, entity := range create.Entities {
 		if len(entity.Pos) < 3 {
+			warn(ctx, WarningEntitySkipped, "%s has position %v", entity.Nbt.ID, entity.Pos)
 			continue
 		}
 
-		entityBlock := StandardBlock{
-			Type: "entity",
-			ID:   entity.Nbt.ID,
+		se := StandardEntity{
+			ID: entity.Nbt.ID,
 			Position: StandardBlockPosition{
 				X: entity.Pos[0],
 				Y: entity.Pos[1],
@@ -704,32 +1501,323 @@ func convertCreateToStandard(create *CreateNBT) (*StandardFormat, error) {
 		}
 
 		if len(entity.Nbt.Rotation) >= 2 {
-			entityBlock.Rotation = StandardRotation{
+			se.Rotation = StandardRotation{
 				Yaw:   float64(entity.Nbt.Rotation[0]),
 				Pitch: float64(entity.Nbt.Rotation[1]),
 			}
 		}
 
 		if len(entity.Nbt.Motion) >= 3 {
-			entityBlock.Motion = StandardMotion{
-				X: float64(entity.Nbt.Motion[0]),
-				Y: float64(entity.Nbt.Motion[1]),
-				Z: float64(entity.Nbt.Motion[2]),
+			se.Motion = StandardMotion{
+				X: entity.Nbt.Motion[0],
+				Y: entity.Nbt.Motion[1],
+				Z: entity.Nbt.Motion[2],
 			}
 		}
 
-		sf.Blocks = append(sf.Blocks, entityBlock)
+		// Riders are kept nested in the entity NBT so the mount relationship survives
+		if len(entity.Nbt.Passengers) > 0 {
+			se.NBT = map[string]interface{}{"Passengers": entity.Nbt.Passengers}
+		}
+
+		sf.Entities = append(sf.Entities, se)
 	}
 
 	return sf, nil
 }
 
+// standardTileEntity builds a tile entity from block entity NBT, taking its
+// ID from the "id" tag or "unknown" when there is none
+func standardTileEntity(position StandardBlockPosition, nbtMap map[string]interface{}) StandardTileEntity {
+	id, ok := nbtMap["id"].(string)
+	if !ok {
+		id = "unknown"
+	}
+	return StandardTileEntity{ID: id, Position: position, NBT: nbtMap}
+}
+
+// convertWorldEditV3ToStandard converts a Sponge v3 schematic through the v2
+// path, then adds its biomes, which v3 stores per block rather than per column
+func convertWorldEditV3ToStandard(ctx context.Context, v3 *WorldEditV3NBT) (*StandardFormat, error) {
+	sf, err := convertWorldEditToStandard(ctx, v3.toV2())
+	if err != nil {
+		return nil, err
+	}
+	if len(v3.Biomes.Palette) > 0 {
+		sf.Biomes, err = decodeWorldEditBiomes(v3.Biomes.Palette, v3.Biomes.Data, sf.Size)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sf, nil
+}
+
+// convertWorldEditLegacyToStandard converts a pre-1.13 schematic through the v2 path
+func convertWorldEditLegacyToStandard(ctx context.Context, legacy *WorldEditLegacyNBT) (*StandardFormat, error) {
+	v2, err := legacy.toV2(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return convertWorldEditToStandard(ctx, v2)
+}
+
+// worldEditStandardEntity converts a WorldEdit entity compound, which names
+// its type Id in Sponge schematics and id in pre-1.13 ones. ok is false when
+// the entity has no full position.
+func worldEditStandardEntity(entity map[string]any) (se StandardEntity, ok bool) {
+	pos := toFloat64Slice(entity["Pos"])
+	if len(pos) < 3 {
+		return se, false
+	}
+	se = StandardEntity{
+		Position: StandardBlockPosition{X: pos[0], Y: pos[1], Z: pos[2]},
+		NBT:      entity,
+	}
+	if id, ok := entity["Id"].(string); ok {
+		se.ID = id
+	} else {
+		se.ID, _ = entity["id"].(string)
+	}
+	if rot := toFloat64Slice(entity["Rotation"]); len(rot) >= 2 {
+		se.Rotation = StandardRotation{Yaw: rot[0], Pitch: rot[1]}
+	}
+	if motion := toFloat64Slice(entity["Motion"]); len(motion) >= 3 {
+		se.Motion = StandardMotion{X: motion[0], Y: motion[1], Z: motion[2]}
+	}
+	return se, true
+}
+
+// decodeWorldEditBiomes decodes a WorldEdit biome palette and varint biome
+// data covering a grid of the given size
+func decodeWorldEditBiomes(palette map[string]int32, data []byte, size StandardSize) (*StandardBiomes, error) {
+	indices, err := decodeVarintBlockData(data, size.X*size.Y*size.Z)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode biome data: %w", err)
+	}
+	biomes := &StandardBiomes{Size: size, Palette: make(map[int]string, len(palette)), Data: indices}
+	for name, index := range palette {
+		biomes.Palette[int(index)] = name
+	}
+	return biomes, nil
+}
+
+// convertStructureToStandard converts a vanilla structure block export to StandardFormat
+func convertStructureToStandard(ctx context.Context, structure *NbtSchematic) (*StandardFormat, error) {
+	if structure == nil {
+		return nil, fmt.Errorf("structure data is nil")
+	}
+
+	sf := &StandardFormat{
+		OriginalFormat: "structure",
+		DataVersion:    structure.DataVersion,
+	}
+
+	// Worldgen templates may carry a processor list, which is written back as is
+	if structure.Processors != nil {
+		sf.Extra = map[string]interface{}{"processors": structure.Processors}
+	}
+
+	if len(structure.Size) >= 3 {
+		sf.Size.X = structure.Size[0]
+		sf.Size.Y = structure.Size[1]
+		sf.Size.Z = structure.Size[2]
+	}
+
+	sf.Palette = make(map[int]StandardPalette, len(structure.Palette))
+	for i, palette := range structure.Palette {
+		props := palette.Properties
+		if props == nil {
+			props = make(map[string]string)
+		}
+		sf.Palette[i] = StandardPalette{
+			Name:       palette.Name,
+			Properties: props,
+		}
+	}
+
+	// Vanilla structures keep block entity data inline on the block
+	sf.Blocks = make([]StandardBlock, 0, len(structure.Blocks))
+	for i, block := range structure.Blocks {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
+		}
+		if len(block.Pos) < 3 {
+			warn(ctx, WarningBlockSkipped, "block %d has position %v", i, block.Pos)
+			continue
+		}
+
+		sb := StandardBlock{
+			Type:  "block",
+			State: block.State,
+			Position: StandardBlockPosition{
+				X: float64(block.Pos[0]),
+				Y: float64(block.Pos[1]),
+				Z: float64(block.Pos[2]),
+			},
+		}
+		if p, ok := sf.Palette[block.State]; ok {
+			sb.ID = p.Name
+		} else {
+			warn(ctx, WarningStateNotInPalette, "block %d has state %d, which is not in the palette", i, block.State)
+		}
+		if nbtMap, ok := decodeBlockNBT(block.Nbt).(map[string]interface{}); ok {
+			sf.TileEntities = append(sf.TileEntities, standardTileEntity(sb.Position, nbtMap))
+		}
+
+		sf.Blocks = append(sf.Blocks, sb)
+	}
+
+	for _, entity := range structure.Entities {
+		if len(entity.Pos) < 3 {
+			warn(ctx, WarningEntitySkipped, "%v has position %v", entity.Nbt["id"], entity.Pos)
+			continue
+		}
+
+		se := StandardEntity{
+			Position: StandardBlockPosition{
+				X: entity.Pos[0],
+				Y: entity.Pos[1],
+				Z: entity.Pos[2],
+			},
+			NBT: entity.Nbt,
+		}
+		if id, ok := entity.Nbt["id"].(string); ok {
+			se.ID = id
+		}
+		if rotation := numberList(entity.Nbt["Rotation"]); len(rotation) >= 2 {
+			se.Rotation = StandardRotation{Yaw: rotation[0], Pitch: rotation[1]}
+		}
+		if motion := numberList(entity.Nbt["Motion"]); len(motion) >= 3 {
+			se.Motion = StandardMotion{X: motion[0], Y: motion[1], Z: motion[2]}
+		}
+
+		sf.Entities = append(sf.Entities, se)
+	}
+
+	return sf, nil
+}
+
+// convertPassengers decodes the Passengers stored in a standard entity's NBT
+// into out, a pointer to the target format's entity slice. The entity types
+// share their NBT field names, so riders convert between formats via JSON.
+func convertPassengers(nbtData interface{}, out interface{}) error {
+	m, ok := nbtData.(map[string]interface{})
+	if !ok || m["Passengers"] == nil {
+		return nil
+	}
+	data, err := json.Marshal(m["Passengers"])
+	if err != nil {
+		return fmt.Errorf("failed to marshal passengers: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to convert passengers: %w", err)
+	}
+	return nil
+}
+
+// EachBlock calls fn with each block in order, stopping at and returning the
+// first error fn returns
+func (sf *StandardFormat) EachBlock(fn func(b StandardBlock) error) error {
+	for _, block := range sf.Blocks {
+		if err := fn(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PaletteIndicesForName returns every palette index whose block name is name,
+// whatever its properties, in ascending order
+func (sf *StandardFormat) PaletteIndicesForName(name string) []int {
+	var indices []int
+	for i, p := range sf.Palette {
+		if p.Name == name {
+			indices = append(indices, i)
+		}
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// paletteIndexFor returns the index of the palette entry matching name and
+// properties, appending a new entry if none exists yet. It scans the whole
+// palette, so code looking up many blocks should use a paletteIndex instead.
+func paletteIndexFor(palette map[int]StandardPalette, name string, properties map[string]string) int {
+	return newPaletteIndex(palette).indexFor(name, properties)
+}
+
+// paletteIndex finds palette entries by name and properties with a map built
+// once, appending entries the palette doesn't have yet. It assumes nothing
+// else adds to the palette while it is in use.
+type paletteIndex struct {
+	palette map[int]StandardPalette
+	states  map[string]int
+}
+
+// newPaletteIndex indexes palette, keeping the lowest index of duplicate entries
+func newPaletteIndex(palette map[int]StandardPalette) *paletteIndex {
+	pi := &paletteIndex{palette: palette, states: make(map[string]int, len(palette))}
+	for i, p := range palette {
+		key := EncodePropertyString(p.Name, p.Properties)
+		if existing, ok := pi.states[key]; !ok || i < existing {
+			pi.states[key] = i
+		}
+	}
+	return pi
+}
+
+// indexFor returns the index of the entry matching name and properties,
+// appending a new entry at the first free index if there is none
+func (pi *paletteIndex) indexFor(name string, properties map[string]string) int {
+	key := EncodePropertyString(name, properties)
+	if index, ok := pi.states[key]; ok {
+		return index
+	}
+
+	index := len(pi.palette)
+	for {
+		if _, taken := pi.palette[index]; !taken {
+			break
+		}
+		index++
+	}
+
+	props := make(map[string]string, len(properties))
+	for k, v := range properties {
+		props[k] = v
+	}
+	pi.palette[index] = StandardPalette{
+		Name:       name,
+		Properties: props,
+	}
+	pi.states[key] = index
+	return index
+}
+
+// equalProperties reports whether two property maps hold the same keys and values
+func equalProperties(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
 // convertStandardToLitematica converts a StandardFormat to LitematicaNBT
-func convertStandardToLitematica(standard *StandardFormat) (*LitematicaNBT, error) {
+func convertStandardToLitematica(ctx context.Context, standard *StandardFormat) (*LitematicaNBT, error) {
+	standard = standard.withAirPalette()
 	litematica := &LitematicaNBT{}
 
 	litematica.MinecraftDataVersion = int32(standard.DataVersion)
 	litematica.Version = int32(standard.Version)
+	if standard.OriginalFormat != "litematica" || litematica.Version == 0 {
+		// Version numbers from other formats have a different meaning
+		litematica.Version = litematicaDefaultVersion
+	}
 
 	litematica.Metadata.Name = standard.Metadata.Name
 	litematica.Metadata.Author = standard.Metadata.Author
@@ -761,6 +1849,20 @@ func convertStandardToLitematica(standard *StandardFormat) (*LitematicaNBT, erro
 	region.Position.Y = int32(standard.Position.Y)
 	region.Position.Z = int32(standard.Position.Z)
 
+	// A region that grew in the negative direction is anchored at its far
+	// corner, the inverse of litematicaRegionMinCorner
+	if signs, ok := standard.extraPosition("RegionSizeSigns"); ok {
+		restore := func(pos, size *int32, sign int) {
+			if sign < 0 && *size > 0 {
+				*pos += *size - 1
+				*size = -*size
+			}
+		}
+		restore(&region.Position.X, &region.Size.X, signs.X)
+		restore(&region.Position.Y, &region.Size.Y, signs.Y)
+		restore(&region.Position.Z, &region.Size.Z, signs.Z)
+	}
+
 	// Convert palette
 	region.BlockStatePalette = make([]LitematicaBlockStatePalette, len(standard.Palette))
 	for i, palette := range standard.Palette {
@@ -776,22 +1878,14 @@ func convertStandardToLitematica(standard *StandardFormat) (*LitematicaNBT, erro
 	sizeZ := standard.Size.Z
 	totalVolume := sizeX * sizeY * sizeZ
 
-	grid := make([]int, totalVolume)
+	grid := newStateGrid(standard, totalVolume)
 	var tileEntities []LitematicaTileEntity
 	var entities []LitematicaEntity
 
-	for _, block := range standard.Blocks {
-		if block.Type == "entity" {
-			e := LitematicaEntity{
-				ID:       block.ID,
-				Pos:      []float64{block.Position.X, block.Position.Y, block.Position.Z},
-				Rotation: []float32{float32(block.Rotation.Yaw), float32(block.Rotation.Pitch)},
-				Motion:   []float64{block.Motion.X, block.Motion.Y, block.Motion.Z},
-			}
-			entities = append(entities, e)
-			continue
+	for i, block := range standard.Blocks {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
 		}
-
 		x, y, z := int(block.Position.X), int(block.Position.Y), int(block.Position.Z)
 		if x < 0 || x >= sizeX || y < 0 || y >= sizeY || z < 0 || z >= sizeZ {
 			continue
@@ -802,57 +1896,256 @@ func convertStandardToLitematica(standard *StandardFormat) (*LitematicaNBT, erro
 		if idx >= 0 && idx < totalVolume {
 			grid[idx] = block.State
 		}
+	}
 
-		// Collect tile entities
-		if block.Type == "block_entity" && block.NBT != nil {
-			te := LitematicaTileEntity{
-				Id: block.ID,
-				X:  int32(x),
-				Y:  int32(y),
-				Z:  int32(z),
-			}
-			tileEntities = append(tileEntities, te)
+	for _, te := range standard.TileEntities {
+		x, y, z := int(te.Position.X), int(te.Position.Y), int(te.Position.Z)
+		if x < 0 || x >= sizeX || y < 0 || y >= sizeY || z < 0 || z >= sizeZ || te.NBT == nil {
+			continue
+		}
+		lte := LitematicaTileEntity{
+			Id: te.ID,
+			X:  int32(x),
+			Y:  int32(y),
+			Z:  int32(z),
 		}
+		lte.CustomName, lte.Lock, _ = ContainerMetadata(te)
+		tileEntities = append(tileEntities, lte)
 	}
 
-	// Pack palette indices into int64 long array
-	// Entries do NOT cross long boundaries in Litematica
-	paletteSize := len(region.BlockStatePalette)
-	bitsPerEntry := 2 // minimum
-	if paletteSize > 0 {
-		b := bits.Len(uint(paletteSize - 1))
-		if b > bitsPerEntry {
-			bitsPerEntry = b
+	for _, entity := range standard.Entities {
+		e := LitematicaEntity{
+			ID:       entity.ID,
+			Pos:      []float64{entity.Position.X, entity.Position.Y, entity.Position.Z},
+			Rotation: []float32{float32(entity.Rotation.Yaw), float32(entity.Rotation.Pitch)},
+			Motion:   []float64{entity.Motion.X, entity.Motion.Y, entity.Motion.Z},
 		}
+		if err := convertPassengers(entity.NBT, &e.Passengers); err != nil {
+			return nil, err
+		}
+		entities = append(entities, e)
 	}
 
-	entriesPerLong := 64 / bitsPerEntry
-	numLongs := (totalVolume + entriesPerLong - 1) / entriesPerLong
-	mask := int64((1 << bitsPerEntry) - 1)
+	// Pack palette indices in YZX order (same order as the grid)
+	region.BlockStates = packLitematicaBlockStates(grid, len(region.BlockStatePalette))
 
-	packedLongs := make([]int64, numLongs)
+	region.TileEntities = tileEntities
+	region.Entities = entities
+	region.PendingBlockTicks = pendingTicks(standard.Extra["PendingBlockTicks"])
+	region.PendingFluidTicks = pendingTicks(standard.Extra["PendingFluidTicks"])
 
-	// Pack in YZX order (same order as the grid)
-	for i := 0; i < totalVolume; i++ {
-		longIndex := i / entriesPerLong
-		bitOffset := (i % entriesPerLong) * bitsPerEntry
-		state := int64(grid[i]) & mask
-		packedLongs[longIndex] |= state << bitOffset
+	litematica.Regions = map[string]LitematicaRegion{"main": region}
+
+	return litematica, nil
+}
+
+// pendingTicks reads scheduled ticks kept in StandardFormat.Extra. They are
+// normally stored as []LitematicaPendingTick, but arrive as generic values
+// when the standard format itself was loaded from JSON.
+func pendingTicks(v interface{}) []LitematicaPendingTick {
+	switch ticks := v.(type) {
+	case nil:
+		return nil
+	case []LitematicaPendingTick:
+		return ticks
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
 	}
+	var ticks []LitematicaPendingTick
+	if err := json.Unmarshal(data, &ticks); err != nil {
+		return nil
+	}
+	return ticks
+}
 
-	// BlockStates is now directly []int64
-	region.BlockStates = packedLongs
+// ToLitematicaRegions converts sf into a Litematica schematic with one region
+// per distinct name returned by partition, e.g. to split a large build into
+// Y bands. Each region is cropped to the bounding box of its blocks, with any
+// gaps filled with air, and is positioned where its blocks were. Tile
+// entities, entities and scheduled ticks go to the region of the block they
+// are in; an entity or tick in a cell without a block is partitioned as a
+// block at its position.
+func (sf *StandardFormat) ToLitematicaRegions(partition func(StandardBlock) string) (*LitematicaNBT, error) {
+	groups := make(map[string][]StandardBlock)
+	regionAt := make(map[[3]int]string, len(sf.Blocks))
+	for _, block := range sf.Blocks {
+		name := partition(block)
+		groups[name] = append(groups[name], block)
+		regionAt[positionKey(block.Position)] = name
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("no blocks to partition into regions")
+	}
 
-	region.TileEntities = tileEntities
-	region.Entities = entities
+	tileEntities := make(map[string][]StandardTileEntity)
+	for _, te := range sf.TileEntities {
+		if name, ok := regionAt[positionKey(te.Position)]; ok {
+			tileEntities[name] = append(tileEntities[name], te)
+		}
+	}
+	// Scheduled ticks go to the region of the block they are scheduled for
+	ticks := make(map[string]map[string]interface{})
+	for _, key := range tickKeys {
+		for _, tick := range pendingTicks(sf.Extra[key]) {
+			cell := StandardBlockPosition{X: float64(tick.X), Y: float64(tick.Y), Z: float64(tick.Z)}
+			name, ok := regionAt[positionKey(cell)]
+			if !ok {
+				name = partition(StandardBlock{Type: "block", Position: cell})
+			}
+			if _, ok := groups[name]; !ok {
+				continue
+			}
+			if ticks[name] == nil {
+				ticks[name] = make(map[string]interface{})
+			}
+			regionTicks, _ := ticks[name][key].([]LitematicaPendingTick)
+			ticks[name][key] = append(regionTicks, tick)
+		}
+	}
 
-	litematica.Regions = map[string]LitematicaRegion{"main": region}
+	entities := make(map[string][]StandardEntity)
+	for _, e := range sf.Entities {
+		cell := StandardBlockPosition{X: math.Floor(e.Position.X), Y: math.Floor(e.Position.Y), Z: math.Floor(e.Position.Z)}
+		name, ok := regionAt[positionKey(cell)]
+		if !ok {
+			name = partition(StandardBlock{Type: "block", Position: cell})
+		}
+		if _, ok := groups[name]; ok {
+			entities[name] = append(entities[name], e)
+		}
+	}
 
+	header := &StandardFormat{
+		Metadata:       sf.Metadata,
+		DataVersion:    sf.DataVersion,
+		Version:        sf.Version,
+		Size:           sf.Size,
+		Position:       sf.Position,
+		Palette:        map[int]StandardPalette{},
+		OriginalFormat: sf.OriginalFormat,
+	}
+	litematica, err := convertStandardToLitematica(context.Background(), header)
+	if err != nil {
+		return nil, err
+	}
+	litematica.Regions = make(map[string]LitematicaRegion, len(groups))
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	totalBlocks, totalVolume := 0, 0
+	for _, name := range names {
+		group := &StandardFormat{
+			DataVersion:    sf.DataVersion,
+			Version:        sf.Version,
+			Position:       sf.Position,
+			Blocks:         groups[name],
+			Entities:       entities[name],
+			TileEntities:   tileEntities[name],
+			Palette:        make(map[int]StandardPalette, len(sf.Palette)+1),
+			OriginalFormat: sf.OriginalFormat,
+			Extra:          ticks[name],
+		}
+		for i, p := range sf.Palette {
+			group.Palette[i] = p
+		}
+		// Cells between this region's blocks must read as air, not palette index 0
+		if _, ok := group.airState(); !ok {
+			paletteIndexFor(group.Palette, "minecraft:air", nil)
+		}
+
+		var minPos, maxPos StandardPosition
+		for i, block := range group.Blocks {
+			pos := StandardPosition{
+				X: int(math.Floor(block.Position.X)),
+				Y: int(math.Floor(block.Position.Y)),
+				Z: int(math.Floor(block.Position.Z)),
+			}
+			if i == 0 {
+				minPos, maxPos = pos, pos
+				continue
+			}
+			minPos = StandardPosition{X: min(minPos.X, pos.X), Y: min(minPos.Y, pos.Y), Z: min(minPos.Z, pos.Z)}
+			maxPos = StandardPosition{X: max(maxPos.X, pos.X), Y: max(maxPos.Y, pos.Y), Z: max(maxPos.Z, pos.Z)}
+		}
+
+		cropped, err := group.Crop(minPos, maxPos)
+		if err != nil {
+			return nil, fmt.Errorf("failed to crop region %q: %w", name, err)
+		}
+		converted, err := convertStandardToLitematica(context.Background(), cropped)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert region %q: %w", name, err)
+		}
+		litematica.Regions[name] = converted.Regions["main"]
+
+		for _, block := range cropped.Blocks {
+			if cropped.isSolid(block) {
+				totalBlocks++
+			}
+		}
+		totalVolume += cropped.Size.X * cropped.Size.Y * cropped.Size.Z
+	}
+
+	litematica.Metadata.RegionCount = int32(len(litematica.Regions))
+	litematica.Metadata.TotalBlocks = int32(totalBlocks)
+	litematica.Metadata.TotalVolume = int32(totalVolume)
 	return litematica, nil
 }
 
 // convertStandardToWorldEdit converts a StandardFormat to WorldEditNBT
-func convertStandardToWorldEdit(standard *StandardFormat) (*WorldEditNBT, error) {
+func convertStandardToWorldEdit(ctx context.Context, standard *StandardFormat) (*WorldEditNBT, error) {
+	standard = standard.withAirPalette()
+	blockData, err := worldEditBlockData(ctx, standard)
+	if err != nil {
+		return nil, err
+	}
+	return worldEditWithBlockData(standard, blockData)
+}
+
+// worldEditBlockData encodes the palette indices of a StandardFormat's blocks
+// as varint block data in YZX order, with air where there is no block
+func worldEditBlockData(ctx context.Context, standard *StandardFormat) ([]byte, error) {
+	width := standard.Size.X
+	height := standard.Size.Y
+	length := standard.Size.Z
+
+	// Build a 3D grid of palette indices
+	totalVolume := width * height * length
+	grid := newStateGrid(standard, totalVolume)
+	for i, block := range standard.Blocks {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
+		}
+		x, y, z := int(block.Position.X), int(block.Position.Y), int(block.Position.Z)
+		if x < 0 || x >= width || y < 0 || y >= height || z < 0 || z >= length {
+			continue
+		}
+
+		idx := y*length*width + z*width + x
+		if idx >= 0 && idx < totalVolume {
+			grid[idx] = block.State
+		}
+	}
+
+	var blockData []byte
+	for i := 0; i < totalVolume; i++ {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
+		}
+		blockData = append(blockData, writeVarint(grid[i])...)
+	}
+	return blockData, nil
+}
+
+// worldEditWithBlockData converts everything but the blocks of a
+// StandardFormat to WorldEditNBT, and sets its BlockData to blockData
+func worldEditWithBlockData(standard *StandardFormat, blockData []byte) (*WorldEditNBT, error) {
 	worldEdit := &WorldEditNBT{}
 
 	worldEdit.DataVersion = int32(standard.DataVersion)
@@ -864,9 +2157,13 @@ func convertStandardToWorldEdit(standard *StandardFormat) (*WorldEditNBT, error)
 
 	worldEdit.Offset = []int32{int32(standard.Position.X), int32(standard.Position.Y), int32(standard.Position.Z)}
 
-	worldEdit.Metadata.WEOffsetX = int32(standard.Position.X)
-	worldEdit.Metadata.WEOffsetY = int32(standard.Position.Y)
-	worldEdit.Metadata.WEOffsetZ = int32(standard.Position.Z)
+	weOffset, ok := standard.extraPosition("WEOffset")
+	if !ok {
+		weOffset = standard.Position
+	}
+	worldEdit.Metadata.WEOffsetX = int32(weOffset.X)
+	worldEdit.Metadata.WEOffsetY = int32(weOffset.Y)
+	worldEdit.Metadata.WEOffsetZ = int32(weOffset.Z)
 
 	width := standard.Size.X
 	height := standard.Size.Y
@@ -875,74 +2172,100 @@ func convertStandardToWorldEdit(standard *StandardFormat) (*WorldEditNBT, error)
 	// Convert palette — WorldEdit uses "name[props]" → index
 	worldEdit.Palette = make(map[string]int32)
 	for i, palette := range standard.Palette {
-		blockName := palette.Name
-		if len(palette.Properties) > 0 {
-			blockName += "["
-			first := true
-			for key, value := range palette.Properties {
-				if !first {
-					blockName += ","
-				}
-				blockName += key + "=" + value
-				first = false
-			}
-			blockName += "]"
-		}
+		blockName := EncodePropertyString(palette.Name, palette.Properties)
 		worldEdit.Palette[blockName] = int32(i)
 	}
 	worldEdit.PaletteMax = int32(len(standard.Palette))
 
-	// Build a 3D grid of palette indices
-	totalVolume := width * height * length
-	grid := make([]int, totalVolume)
-
 	var blockEntities []map[string]any
-
-	for _, block := range standard.Blocks {
-		if block.Type == "entity" {
-			continue
-		}
-
-		x, y, z := int(block.Position.X), int(block.Position.Y), int(block.Position.Z)
+	for _, te := range standard.TileEntities {
+		x, y, z := int(te.Position.X), int(te.Position.Y), int(te.Position.Z)
 		if x < 0 || x >= width || y < 0 || y >= height || z < 0 || z >= length {
 			continue
 		}
-
-		idx := y*length*width + z*width + x
-		if idx >= 0 && idx < totalVolume {
-			grid[idx] = block.State
+		be := map[string]any{
+			"Id":  te.ID,
+			"Pos": []int32{int32(x), int32(y), int32(z)},
 		}
-
-		// Collect block entities
-		if block.Type == "block_entity" {
-			be := map[string]any{
-				"Id": block.ID,
-				"Pos": []int{
-					int(block.Position.X),
-					int(block.Position.Y),
-					int(block.Position.Z),
-				},
-			}
-			if nbtMap, ok := block.NBT.(map[string]interface{}); ok {
-				for key, value := range nbtMap {
-					be[key] = value
-				}
+		if nbtMap, ok := te.NBT.(map[string]interface{}); ok {
+			for key, value := range nbtMap {
+				be[key] = value
 			}
-			blockEntities = append(blockEntities, be)
 		}
+		blockEntities = append(blockEntities, be)
 	}
 
-	// Encode block data as varint byte array in YZX order
-	var blockData []byte
-	for i := 0; i < totalVolume; i++ {
-		blockData = append(blockData, writeVarint(grid[i])...)
-	}
 	worldEdit.BlockData = blockData
 	worldEdit.BlockEntities = blockEntities
 
+	// Entity NBT, riders included, is written back with the current position
+	for _, entity := range standard.Entities {
+		e := make(map[string]any)
+		if nbtMap, ok := entity.NBT.(map[string]interface{}); ok {
+			for key, value := range nbtMap {
+				e[key] = value
+			}
+		}
+		delete(e, "id")
+		e["Id"] = entity.ID
+		e["Pos"] = []float64{entity.Position.X, entity.Position.Y, entity.Position.Z}
+		e["Rotation"] = []float32{float32(entity.Rotation.Yaw), float32(entity.Rotation.Pitch)}
+		e["Motion"] = []float64{entity.Motion.X, entity.Motion.Y, entity.Motion.Z}
+		worldEdit.Entities = append(worldEdit.Entities, e)
+	}
+
+	// Sponge v2 only has per-column biomes, so 3D biomes keep their bottom layer
+	if biomes := standard.Biomes; biomes != nil && len(biomes.Palette) > 0 {
+		if biomes.Size.X != width || biomes.Size.Z != length || (biomes.Size.Y != 1 && biomes.Size.Y != height) {
+			return nil, fmt.Errorf("biome grid is %dx%dx%d, which does not cover the %dx%dx%d schematic",
+				biomes.Size.X, biomes.Size.Y, biomes.Size.Z, width, height, length)
+		}
+		columns := biomes.Size.X * biomes.Size.Z
+		if len(biomes.Data) < columns {
+			return nil, fmt.Errorf("biome data has %d entries, expected at least %d", len(biomes.Data), columns)
+		}
+		worldEdit.BiomePalette = make(map[string]int32, len(biomes.Palette))
+		for index, name := range biomes.Palette {
+			worldEdit.BiomePalette[name] = int32(index)
+		}
+		worldEdit.BiomePaletteMax = int32(len(biomes.Palette))
+		for _, index := range biomes.Data[:columns] {
+			worldEdit.BiomeData = append(worldEdit.BiomeData, writeVarint(index)...)
+		}
+	}
+
 	return worldEdit, nil
 }
 
+// withAirPalette returns standard, or a copy with an air entry added to its
+// palette if it has none. Structure and Create palettes only list the blocks
+// they use, but dense formats need air for the cells that have no block.
+func (sf *StandardFormat) withAirPalette() *StandardFormat {
+	if _, ok := sf.airState(); ok {
+		return sf
+	}
+	c := *sf
+	c.index = nil
+	c.Palette = make(map[int]StandardPalette, len(sf.Palette)+1)
+	for i, p := range sf.Palette {
+		c.Palette[i] = p
+	}
+	paletteIndexFor(c.Palette, "minecraft:air", nil)
+	return &c
+}
+
+// newStateGrid returns a flat grid of palette indices for a dense format,
+// with every cell initialized to the palette's air entry
+func newStateGrid(standard *StandardFormat, volume int) []int {
+	grid := make([]int, volume)
+	if air, ok := standard.airState(); ok && air != 0 {
+		for i := range grid {
+			grid[i] = air
+		}
+	}
+	return grid
+}
+
 // writeVarint encodes an integer as a varint byte sequence
 func writeVarint(value int) []byte {
 	var buf []byte
@@ -962,7 +2285,7 @@ func writeVarint(value int) []byte {
 }
 
 // convertStandardToCreate converts a StandardFormat to CreateNBT (vanilla structure format)
-func convertStandardToCreate(standard *StandardFormat) (*CreateNBT, error) {
+func convertStandardToCreate(ctx context.Context, standard *StandardFormat) (*CreateNBT, error) {
 	create := &CreateNBT{}
 
 	create.DataVersion = int32(standard.DataVersion)
@@ -980,6 +2303,8 @@ func convertStandardToCreate(standard *StandardFormat) (*CreateNBT, error) {
 		}
 	}
 
+	create.Processors = standard.Extra["processors"]
+
 	// Convert palette — Properties is now map[string]string
 	create.Palette = make([]CreatePalette, len(standard.Palette))
 	for i, palette := range standard.Palette {
@@ -998,43 +2323,61 @@ func convertStandardToCreate(standard *StandardFormat) (*CreateNBT, error) {
 	var entities []CreateEntity
 	var tileEntities []CreateTileEntity
 
-	for _, block := range standard.Blocks {
-		if block.Type == "entity" {
-			e := CreateEntity{
-				Pos: []float64{block.Position.X, block.Position.Y, block.Position.Z},
-				Nbt: CreateEntityNbt{
-					ID: block.ID,
-				},
-			}
-			if block.Rotation.Yaw != 0 || block.Rotation.Pitch != 0 {
-				e.Nbt.Rotation = []float32{float32(block.Rotation.Yaw), float32(block.Rotation.Pitch)}
-			}
-			if block.Motion.X != 0 || block.Motion.Y != 0 || block.Motion.Z != 0 {
-				e.Nbt.Motion = []float64{block.Motion.X, block.Motion.Y, block.Motion.Z}
-			}
-			entities = append(entities, e)
+	tileEntityNBT := make(map[[3]int]interface{}, len(standard.TileEntities))
+	for _, te := range standard.TileEntities {
+		if te.NBT == nil {
+			continue
+		}
+		ct := CreateTileEntity{
+			Pos: []int32{int32(te.Position.X), int32(te.Position.Y), int32(te.Position.Z)},
+		}
+		if nbtMap, ok := te.NBT.(map[string]interface{}); ok {
+			ct.NBT = nbtMap
+		} else {
+			ct.NBT = map[string]interface{}{"id": te.ID}
+		}
+		tileEntities = append(tileEntities, ct)
+		tileEntityNBT[positionKey(te.Position)] = te.NBT
+	}
+
+	for i, block := range standard.Blocks {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
+		}
+		// Air is implied by absence, whichever palette index it sits at
+		if p, ok := standard.Palette[block.State]; ok && isAirPalette(p) {
 			continue
 		}
 
+		// Block entity data is written inline on its block, as vanilla does
 		cb := CreateBlock{
 			Pos:   []int32{int32(block.Position.X), int32(block.Position.Y), int32(block.Position.Z)},
 			State: int32(block.State),
 			Nbt:   block.NBT,
 		}
+		if nbtData, ok := tileEntityNBT[positionKey(block.Position)]; ok {
+			cb.Nbt = nbtData
+		}
 		blocks = append(blocks, cb)
+	}
 
-		// Collect tile entities
-		if block.Type == "block_entity" && block.NBT != nil {
-			te := CreateTileEntity{
-				Pos: []int32{int32(block.Position.X), int32(block.Position.Y), int32(block.Position.Z)},
-			}
-			if nbtMap, ok := block.NBT.(map[string]interface{}); ok {
-				te.NBT = nbtMap
-			} else {
-				te.NBT = map[string]interface{}{"id": block.ID}
-			}
-			tileEntities = append(tileEntities, te)
+	for _, entity := range standard.Entities {
+		e := CreateEntity{
+			Pos: []float64{entity.Position.X, entity.Position.Y, entity.Position.Z},
+			Nbt: CreateEntityNbt{
+				ID: entity.ID,
+			},
+		}
+		if entity.Rotation.Yaw != 0 || entity.Rotation.Pitch != 0 {
+			e.Nbt.Rotation = []float32{float32(entity.Rotation.Yaw), float32(entity.Rotation.Pitch)}
+		}
+		if entity.Motion.X != 0 || entity.Motion.Y != 0 || entity.Motion.Z != 0 {
+			e.Nbt.Motion = []float64{entity.Motion.X, entity.Motion.Y, entity.Motion.Z}
+		}
+		if err := convertPassengers(entity.NBT, &e.Nbt.Passengers); err != nil {
+			return nil, err
 		}
+		entities = append(entities, e)
 	}
 
 	create.Blocks = blocks
@@ -0,0 +1,257 @@
+package mcnbt
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+// TestPropertyStringRoundTrip verifies property strings encode canonically and decode back
+func TestPropertyStringRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name    string
+		props   map[string]string
+		encoded string
+	}{
+		{"minecraft:stone", nil, "minecraft:stone"},
+		{"minecraft:oak_log", map[string]string{"axis": "y"}, "minecraft:oak_log[axis=y]"},
+		{
+			"minecraft:oak_stairs",
+			map[string]string{"waterlogged": "false", "facing": "north", "half": "top", "shape": "straight"},
+			"minecraft:oak_stairs[facing=north,half=top,shape=straight,waterlogged=false]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded := EncodePropertyString(tc.name, tc.props)
+			if encoded != tc.encoded {
+				t.Errorf("Expected %q, got %q", tc.encoded, encoded)
+			}
+
+			name, props := DecodePropertyString(encoded)
+			if name != tc.name {
+				t.Errorf("Expected name %q, got %q", tc.name, name)
+			}
+			if !equalProperties(props, tc.props) {
+				t.Errorf("Expected properties %v, got %v", tc.props, props)
+			}
+		})
+	}
+}
+
+// TestPropertiesRoundTrip verifies arbitrary block state properties survive
+// encoding to each format and converting back
+func TestPropertiesRoundTrip(t *testing.T) {
+	props := map[string]string{"facing": "north", "half": "top"}
+
+	for _, format := range []string{"litematica", "worldedit", "create"} {
+		t.Run(format, func(t *testing.T) {
+			sf := newTestStandard(1, 1, 1)
+			sf.Palette[2] = StandardPalette{Name: "minecraft:oak_stairs", Properties: props}
+			addTestBlock(sf, 0, 0, 0, 2)
+
+			encoded, err := EncodeToBytes(sf, format)
+			if err != nil {
+				t.Fatalf("Failed to encode: %v", err)
+			}
+			data, err := DecodeAny(encoded)
+			if err != nil {
+				t.Fatalf("Failed to decode: %v", err)
+			}
+			standard, err := ConvertToStandard(data)
+			if err != nil {
+				t.Fatalf("Failed to convert: %v", err)
+			}
+
+			block, ok := standard.GetBlockAt(0, 0, 0)
+			if !ok {
+				t.Fatalf("Expected a block at 0,0,0")
+			}
+			p := standard.Palette[block.State]
+			if p.Name != "minecraft:oak_stairs" {
+				t.Errorf("Expected minecraft:oak_stairs, got %s", p.Name)
+			}
+			if !equalProperties(p.Properties, props) {
+				t.Errorf("Expected properties %v, got %v", props, p.Properties)
+			}
+		})
+	}
+}
+
+// TestAirAtNonZeroIndex verifies air is recognized by name when it is not palette index 0
+func TestAirAtNonZeroIndex(t *testing.T) {
+	sf := &StandardFormat{
+		Size: StandardSize{X: 3, Y: 1, Z: 1},
+		Palette: map[int]StandardPalette{
+			0: {Name: "minecraft:stone"},
+			1: {Name: "minecraft:dirt"},
+			2: {Name: "minecraft:glass"},
+			3: {Name: "minecraft:air"},
+		},
+	}
+	addTestBlock(sf, 0, 0, 0, 0)
+	addTestBlock(sf, 1, 0, 0, 3)
+
+	create, err := ConvertFromStandard(sf, "create")
+	if err != nil {
+		t.Fatalf("Failed to convert to create: %v", err)
+	}
+	if blocks := create.(*CreateNBT).Blocks; len(blocks) != 1 {
+		t.Errorf("Expected air blocks to be skipped leaving 1 block, got %d", len(blocks))
+	}
+
+	// The unset cell at X=2 must become air rather than palette index 0
+	worldEdit, err := ConvertFromStandard(sf, "worldedit")
+	if err != nil {
+		t.Fatalf("Failed to convert to worldedit: %v", err)
+	}
+	states, err := decodeVarintBlockData(worldEdit.(*WorldEditNBT).BlockData, 3)
+	if err != nil {
+		t.Fatalf("Failed to decode block data: %v", err)
+	}
+	expected := []int{0, 3, 3}
+	for i, state := range expected {
+		if states[i] != state {
+			t.Errorf("Cell %d: expected state %d, got %d", i, state, states[i])
+		}
+	}
+}
+
+// TestVanillaStructure verifies a structure block export converts as a vanilla structure, not a Create schematic
+func TestVanillaStructure(t *testing.T) {
+	payload, err := os.ReadFile("testdata/structure_block.nbt")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	if format, err := DetectFormat(payload); err != nil || format != "structure" {
+		t.Errorf("Expected structure, got %q (%v)", format, err)
+	}
+
+	data, err := DecodeAny(payload)
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+
+	if standard.OriginalFormat != "structure" {
+		t.Errorf("Expected original format structure, got %s", standard.OriginalFormat)
+	}
+	if standard.Size != (StandardSize{X: 3, Y: 2, Z: 2}) {
+		t.Errorf("Unexpected size %+v", standard.Size)
+	}
+	if len(standard.Blocks) != 12 || len(standard.Entities) != 1 {
+		t.Errorf("Expected 12 blocks and 1 entity, got %d and %d", len(standard.Blocks), len(standard.Entities))
+	}
+
+	chest, ok := standard.GetBlockAt(1, 1, 0)
+	if !ok || chest.ID != "minecraft:chest" {
+		t.Fatalf("Expected a chest at 1,1,0, got %+v", chest)
+	}
+	if p := standard.Palette[chest.State]; p.Properties["facing"] != "north" {
+		t.Errorf("Expected chest facing north, got %v", p.Properties)
+	}
+	if len(standard.TileEntities) != 1 || standard.TileEntities[0].Position != chest.Position {
+		t.Fatalf("Expected a tile entity at the chest, got %+v", standard.TileEntities)
+	}
+	if items := ContainerItems(standard.TileEntities[0]); len(items) != 1 || items[0].ID != "minecraft:bread" || items[0].Count != 5 {
+		t.Errorf("Unexpected chest items %+v", items)
+	}
+
+	for _, e := range standard.Entities {
+		if e.ID != "minecraft:cat" || e.Position != (StandardBlockPosition{X: 0.5, Y: 1, Z: 1.5}) || e.Rotation.Yaw != 45 {
+			t.Errorf("Unexpected entity %+v", e)
+		}
+	}
+}
+
+// TestPaletteIndicesForName verifies every oak_stairs entry is found regardless of properties
+func TestPaletteIndicesForName(t *testing.T) {
+	sf := newTestStandard(1, 1, 1)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:oak_stairs", Properties: map[string]string{"facing": "north"}}
+	sf.Palette[5] = StandardPalette{Name: "minecraft:oak_stairs", Properties: map[string]string{"facing": "south"}}
+	sf.Palette[3] = StandardPalette{Name: "minecraft:oak_stairs", Properties: map[string]string{"facing": "east", "half": "top"}}
+	sf.Palette[4] = StandardPalette{Name: "minecraft:spruce_stairs", Properties: map[string]string{"facing": "north"}}
+
+	indices := sf.PaletteIndicesForName("minecraft:oak_stairs")
+	if len(indices) != 3 || indices[0] != 2 || indices[1] != 3 || indices[2] != 5 {
+		t.Errorf("Expected [2 3 5], got %v", indices)
+	}
+	if indices := sf.PaletteIndicesForName("minecraft:birch_stairs"); len(indices) != 0 {
+		t.Errorf("Expected no indices, got %v", indices)
+	}
+}
+
+// TestPaletteIndex verifies lookups find existing entries regardless of
+// property order, prefer the lowest duplicate and add missing entries once
+func TestPaletteIndex(t *testing.T) {
+	sf := newTestStandard(1, 1, 1)
+	sf.Palette[3] = StandardPalette{Name: "minecraft:oak_stairs", Properties: map[string]string{"facing": "east", "half": "top"}}
+	sf.Palette[2] = sf.Palette[3]
+
+	states := newPaletteIndex(sf.Palette)
+	if index := states.indexFor("minecraft:oak_stairs", map[string]string{"half": "top", "facing": "east"}); index != 2 {
+		t.Errorf("Expected the lowest matching index 2, got %d", index)
+	}
+	if index := states.indexFor("minecraft:stone", nil); index != 1 {
+		t.Errorf("Expected stone at 1, got %d", index)
+	}
+
+	glass := states.indexFor("minecraft:glass", nil)
+	if glass != 4 || sf.Palette[4].Name != "minecraft:glass" {
+		t.Fatalf("Expected glass added at the first free index 4, got %d: %v", glass, sf.Palette)
+	}
+	if again := states.indexFor("minecraft:glass", map[string]string{}); again != glass || len(sf.Palette) != 5 {
+		t.Errorf("Expected glass to be reused, got %d with %d entries", again, len(sf.Palette))
+	}
+}
+
+// cancelAfterContext reports itself canceled once Err has been called more
+// than after times, simulating a cancel that arrives mid-conversion
+type cancelAfterContext struct {
+	context.Context
+	calls, after int
+}
+
+func (c *cancelAfterContext) Err() error {
+	c.calls++
+	if c.calls > c.after {
+		return context.Canceled
+	}
+	return nil
+}
+
+// TestConvertContextCanceled verifies conversions in both directions stop
+// with context.Canceled when the context is canceled partway through
+func TestConvertContextCanceled(t *testing.T) {
+	cube := newTestCube(32, "minecraft:stone")
+	worldEdit, err := ConvertFromStandard(cube, "worldedit")
+	if err != nil {
+		t.Fatalf("Failed to convert to worldedit: %v", err)
+	}
+
+	ctx := &cancelAfterContext{Context: context.Background(), after: 3}
+	if _, err := ConvertToStandardContext(ctx, worldEdit); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled converting to standard, got %v", err)
+	}
+	if ctx.calls <= ctx.after {
+		t.Errorf("Expected the conversion to be canceled partway, but Err was only checked %d times", ctx.calls)
+	}
+
+	for _, format := range []string{"litematica", "worldedit", "create"} {
+		ctx := &cancelAfterContext{Context: context.Background(), after: 3}
+		if _, err := ConvertFromStandardContext(ctx, cube, format); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", format, err)
+		}
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ConvertToStandardContext(canceled, worldEdit); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled for an already canceled context, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package mcnbt
+
+import (
+	"sort"
+	"strings"
+)
+
+// FindByTag returns the positions of blocks belonging to a block tag such as
+// "minecraft:logs", ordered by Y, then Z, then X. tagTable maps tag names to
+// their members as in the game's data/<namespace>/tags/blocks files; a member
+// starting with "#" names another tag whose blocks are included too. The tag
+// may be given with or without its leading "#".
+func (sf *StandardFormat) FindByTag(tag string, tagTable map[string][]string) []StandardBlockPosition {
+	members := make(map[string]bool)
+	resolveTag(strings.TrimPrefix(tag, "#"), tagTable, members, make(map[string]bool))
+	if len(members) == 0 {
+		return nil
+	}
+
+	var positions []StandardBlockPosition
+	for _, block := range sf.Blocks {
+		if p, ok := sf.Palette[block.State]; ok && members[p.Name] {
+			positions = append(positions, block.Position)
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return lessPosition(positionKey(positions[i]), positionKey(positions[j]))
+	})
+	return positions
+}
+
+// resolveTag adds the block names in tag to members, following nested tags.
+// visited guards against tags that include each other.
+func resolveTag(tag string, tagTable map[string][]string, members, visited map[string]bool) {
+	if visited[tag] {
+		return
+	}
+	visited[tag] = true
+
+	for _, member := range tagTable[tag] {
+		if nested, ok := strings.CutPrefix(member, "#"); ok {
+			resolveTag(nested, tagTable, members, visited)
+			continue
+		}
+		members[member] = true
+	}
+}
@@ -0,0 +1,37 @@
+package mcnbt
+
+import "testing"
+
+// TestFindByTag verifies a tag resolves through nested tags to matching positions
+func TestFindByTag(t *testing.T) {
+	sf := newTestStandard(3, 2, 1)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:oak_log", Properties: map[string]string{"axis": "y"}}
+	sf.Palette[3] = StandardPalette{Name: "minecraft:birch_log", Properties: map[string]string{"axis": "x"}}
+	sf.Palette[4] = StandardPalette{Name: "minecraft:oak_planks"}
+	addTestBlock(sf, 2, 1, 0, 2)
+	addTestBlock(sf, 0, 0, 0, 3)
+	addTestBlock(sf, 1, 0, 0, 4)
+	addTestBlock(sf, 2, 0, 0, 1)
+
+	tagTable := map[string][]string{
+		"minecraft:logs":       {"#minecraft:oak_logs", "#minecraft:birch_logs", "#minecraft:logs"},
+		"minecraft:oak_logs":   {"minecraft:oak_log", "minecraft:oak_wood"},
+		"minecraft:birch_logs": {"minecraft:birch_log", "minecraft:birch_wood"},
+		"minecraft:planks":     {"minecraft:oak_planks"},
+	}
+
+	positions := sf.FindByTag("#minecraft:logs", tagTable)
+	expected := []StandardBlockPosition{{X: 0, Y: 0, Z: 0}, {X: 2, Y: 1, Z: 0}}
+	if len(positions) != len(expected) {
+		t.Fatalf("Expected %d positions, got %d: %+v", len(expected), len(positions), positions)
+	}
+	for i := range expected {
+		if positions[i] != expected[i] {
+			t.Errorf("Position %d: expected %+v, got %+v", i, expected[i], positions[i])
+		}
+	}
+
+	if positions := sf.FindByTag("minecraft:missing", tagTable); positions != nil {
+		t.Errorf("Expected no positions for an unknown tag, got %+v", positions)
+	}
+}
@@ -0,0 +1,775 @@
+package mcnbt
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// remapPalette applies fn to every palette entry, replacing entries for which
+// fn reports a change. It returns the number of blocks referencing a changed entry.
+func (sf *StandardFormat) remapPalette(fn func(StandardPalette) (StandardPalette, bool)) int {
+	changed := make(map[int]bool)
+	for i, p := range sf.Palette {
+		if np, ok := fn(p); ok {
+			sf.Palette[i] = np
+			changed[i] = true
+		}
+	}
+	if len(changed) == 0 {
+		return 0
+	}
+
+	count := 0
+	for i, block := range sf.Blocks {
+		if !changed[block.State] {
+			continue
+		}
+		if p, ok := sf.Palette[block.State]; ok {
+			sf.Blocks[i].ID = p.Name
+		}
+		count++
+	}
+	return count
+}
+
+// splitNamespace splits "minecraft:stone" into "minecraft:" and "stone"
+func splitNamespace(name string) (string, string) {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[:i+1], name[i+1:]
+	}
+	return "", name
+}
+
+// Recolor swaps the dye color of color-variant blocks (wool, concrete,
+// terracotta, stained glass, etc.) from fromColor to toColor, e.g. "red" to
+// "blue". It returns the number of blocks changed, or 0 if either color is unknown.
+func (sf *StandardFormat) Recolor(fromColor, toColor string) int {
+	if !dyeColors[fromColor] || !dyeColors[toColor] || fromColor == toColor {
+		return 0
+	}
+
+	return sf.remapPalette(func(p StandardPalette) (StandardPalette, bool) {
+		namespace, base := splitNamespace(p.Name)
+		family, ok := strings.CutPrefix(base, fromColor+"_")
+		if !ok || !colorFamilies[family] {
+			return p, false
+		}
+		p.Name = namespace + toColor + "_" + family
+		return p, true
+	})
+}
+
+// ReplaceBlock renames every palette entry named from to to, keeping its
+// properties, and returns the number of blocks affected
+func (sf *StandardFormat) ReplaceBlock(from, to string) int {
+	return sf.ReplaceBlockWithProperties(from, to, true)
+}
+
+// ReplaceBlockWithProperties renames every palette entry named from to to and
+// returns the number of blocks affected. When keepProperties is false the
+// replaced entries lose their properties. Entries that end up identical to
+// another entry are merged onto one index and the palette is compacted.
+func (sf *StandardFormat) ReplaceBlockWithProperties(from, to string, keepProperties bool) int {
+	if from == to && keepProperties {
+		return 0
+	}
+
+	count := sf.remapPalette(func(p StandardPalette) (StandardPalette, bool) {
+		if p.Name != from {
+			return p, false
+		}
+		p.Name = to
+		if !keepProperties {
+			p.Properties = make(map[string]string)
+		}
+		return p, true
+	})
+
+	if sf.mergeDuplicatePalette() {
+		sf.CompactPalette()
+		sf.invalidateIndex()
+	}
+	return count
+}
+
+// mergeDuplicatePalette points blocks using a palette entry that duplicates
+// another onto the lowest matching index, reporting whether any were found.
+// Duplicates are left in the palette for CompactPalette to drop.
+func (sf *StandardFormat) mergeDuplicatePalette() bool {
+	states := make([]int, 0, len(sf.Palette))
+	for state := range sf.Palette {
+		states = append(states, state)
+	}
+	sort.Ints(states)
+
+	canonical := make(map[string]int, len(states))
+	remap := make(map[int]int)
+	for _, state := range states {
+		p := sf.Palette[state]
+		key := EncodePropertyString(p.Name, p.Properties)
+		if first, ok := canonical[key]; ok {
+			remap[state] = first
+		} else {
+			canonical[key] = state
+		}
+	}
+	if len(remap) == 0 {
+		return false
+	}
+
+	for i, block := range sf.Blocks {
+		if state, ok := remap[block.State]; ok {
+			sf.Blocks[i].State = state
+		}
+	}
+	return true
+}
+
+// clone returns a copy of sf whose blocks, entities and palette can be modified independently
+func (sf *StandardFormat) clone() *StandardFormat {
+	c := *sf
+	c.index = nil
+	c.Blocks = append([]StandardBlock(nil), sf.Blocks...)
+	c.Entities = append([]StandardEntity(nil), sf.Entities...)
+	c.TileEntities = append([]StandardTileEntity(nil), sf.TileEntities...)
+	c.Palette = make(map[int]StandardPalette, len(sf.Palette))
+	for i, p := range sf.Palette {
+		c.Palette[i] = p
+	}
+	if sf.Extra != nil {
+		c.Extra = make(map[string]interface{}, len(sf.Extra))
+		for k, v := range sf.Extra {
+			c.Extra[k] = v
+		}
+	}
+	return &c
+}
+
+// FitBounds shrinks the schematic to the bounding box of its solid blocks.
+// Blocks and entities are shifted so the box starts at the origin, Size is
+// recomputed, and Position moves by the same amount so world placement is unchanged.
+// Blocks (air) and tile entities left outside the box are dropped, and biomes
+// are cropped to the box.
+func (sf *StandardFormat) FitBounds() {
+	solid := sf.solidPositions()
+	if len(solid) == 0 {
+		return
+	}
+
+	first := true
+	var minPos, maxPos [3]int
+	for pos := range solid {
+		for axis := 0; axis < 3; axis++ {
+			if first || pos[axis] < minPos[axis] {
+				minPos[axis] = pos[axis]
+			}
+			if first || pos[axis] > maxPos[axis] {
+				maxPos[axis] = pos[axis]
+			}
+		}
+		first = false
+	}
+
+	size := StandardSize{
+		X: maxPos[0] - minPos[0] + 1,
+		Y: maxPos[1] - minPos[1] + 1,
+		Z: maxPos[2] - minPos[2] + 1,
+	}
+
+	shift := func(pos *StandardBlockPosition) {
+		pos.X -= float64(minPos[0])
+		pos.Y -= float64(minPos[1])
+		pos.Z -= float64(minPos[2])
+	}
+	inside := func(pos StandardBlockPosition) bool {
+		x, y, z := int(pos.X), int(pos.Y), int(pos.Z)
+		return x >= 0 && x < size.X && y >= 0 && y < size.Y && z >= 0 && z < size.Z
+	}
+
+	blocks := sf.Blocks[:0]
+	for _, block := range sf.Blocks {
+		shift(&block.Position)
+		if inside(block.Position) {
+			blocks = append(blocks, block)
+		}
+	}
+	tileEntities := sf.TileEntities[:0]
+	for _, te := range sf.TileEntities {
+		shift(&te.Position)
+		if inside(te.Position) {
+			tileEntities = append(tileEntities, te)
+		}
+	}
+	for i := range sf.Entities {
+		shift(&sf.Entities[i].Position)
+	}
+
+	sf.Blocks = blocks
+	sf.TileEntities = tileEntities
+	sf.invalidateIndex()
+	if sf.Biomes != nil {
+		sf.Biomes = sf.Biomes.cropped(StandardPosition{X: minPos[0], Y: minPos[1], Z: minPos[2]}, size)
+	}
+	sf.Size = size
+	sf.Position.X += minPos[0]
+	sf.Position.Y += minPos[1]
+	sf.Position.Z += minPos[2]
+}
+
+// Translate moves every block, tile entity and entity by (dx, dy, dz) and
+// shifts Position by the same amount. Scheduled ticks move with their
+// blocks. Size and the palette are left unchanged.
+func (sf *StandardFormat) Translate(dx, dy, dz int) {
+	move := func(pos *StandardBlockPosition) {
+		pos.X += float64(dx)
+		pos.Y += float64(dy)
+		pos.Z += float64(dz)
+	}
+	for i := range sf.Blocks {
+		move(&sf.Blocks[i].Position)
+	}
+	for i := range sf.TileEntities {
+		move(&sf.TileEntities[i].Position)
+	}
+	for i := range sf.Entities {
+		move(&sf.Entities[i].Position)
+	}
+	sf.moveTicks(func(pos StandardBlockPosition) StandardBlockPosition {
+		move(&pos)
+		return pos
+	})
+	sf.Position.X += dx
+	sf.Position.Y += dy
+	sf.Position.Z += dz
+	sf.invalidateIndex()
+}
+
+// SnapEntitiesToGrid floors every entity position to the block containing it,
+// so an entity at (3.7, 64.0, 2.1) moves to (3, 64, 2). Add 0.5 on X and Z
+// afterwards to stand entities in the center of their block.
+func (sf *StandardFormat) SnapEntitiesToGrid() {
+	for i, entity := range sf.Entities {
+		sf.Entities[i].Position = StandardBlockPosition{
+			X: math.Floor(entity.Position.X),
+			Y: math.Floor(entity.Position.Y),
+			Z: math.Floor(entity.Position.Z),
+		}
+	}
+}
+
+// horizontalDirections lists the horizontal directions in clockwise order
+var horizontalDirections = []string{"north", "east", "south", "west"}
+
+// rotateDirection turns a horizontal direction clockwise by the given number
+// of quarter turns. Other values (e.g. "up") are returned unchanged.
+func rotateDirection(dir string, quarterTurns int) string {
+	for i, d := range horizontalDirections {
+		if d == dir {
+			return horizontalDirections[(i+quarterTurns)%4]
+		}
+	}
+	return dir
+}
+
+// rotateProperties returns a copy of a palette entry's properties turned
+// clockwise by the given number of quarter turns
+func rotateProperties(p StandardPalette, quarterTurns int) map[string]string {
+	rotated := make(map[string]string, len(p.Properties))
+	for key, value := range p.Properties {
+		switch key {
+		case "axis":
+			if quarterTurns%2 == 1 {
+				switch value {
+				case "x":
+					value = "z"
+				case "z":
+					value = "x"
+				}
+			}
+		case "shape":
+			value = mapRailShape(value, func(dir string) string {
+				return rotateDirection(dir, quarterTurns)
+			})
+		case "north", "east", "south", "west":
+			// Fences, walls, panes and redstone wire keep one key per side
+			key = rotateDirection(key, quarterTurns)
+		}
+		rotated[key] = value
+	}
+
+	if degrees, ok := NormalizeOrientation(p.Name, p.Properties); ok {
+		setOrientation(rotated, degrees+90*float64(quarterTurns))
+	}
+	return rotated
+}
+
+// isHorizontalDirection reports whether dir is north, east, south or west
+func isHorizontalDirection(dir string) bool {
+	for _, d := range horizontalDirections {
+		if d == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// mapRailShape applies fn to the directions of a rail shape such as
+// "north_east" or "ascending_west" and rewrites it in canonical form. Stair
+// shapes such as "inner_left" are not made of directions and are returned unchanged.
+func mapRailShape(shape string, fn func(string) string) string {
+	if dir, ok := strings.CutPrefix(shape, "ascending_"); ok {
+		return "ascending_" + fn(dir)
+	}
+	parts := strings.Split(shape, "_")
+	if len(parts) != 2 || !isHorizontalDirection(parts[0]) || !isHorizontalDirection(parts[1]) {
+		return shape
+	}
+	a, b := fn(parts[0]), fn(parts[1])
+	switch {
+	case (a == "north" || a == "south") && (b == "north" || b == "south"):
+		return "north_south"
+	case (a == "east" || a == "west") && (b == "east" || b == "west"):
+		return "east_west"
+	case a == "east" || a == "west":
+		// Curved rail shapes are written north/south first
+		a, b = b, a
+	}
+	return a + "_" + b
+}
+
+// Rotate turns the schematic clockwise around the Y axis, as seen from above,
+// by 90, 180 or 270 degrees. Block positions, Size, the biome grid,
+// directional block properties and entity positions and yaw are all
+// rotated; Position is kept.
+func (sf *StandardFormat) Rotate(degrees int) error {
+	switch degrees {
+	case 90, 180, 270:
+	default:
+		return fmt.Errorf("unsupported rotation: %d degrees", degrees)
+	}
+	quarterTurns := degrees / 90
+
+	for turn := 0; turn < quarterTurns; turn++ {
+		sizeZ := sf.Size.Z
+		rotateCell := func(pos StandardBlockPosition) StandardBlockPosition {
+			return StandardBlockPosition{X: float64(sizeZ-1) - pos.Z, Y: pos.Y, Z: pos.X}
+		}
+		for i, block := range sf.Blocks {
+			sf.Blocks[i].Position = rotateCell(block.Position)
+		}
+		for i, te := range sf.TileEntities {
+			sf.TileEntities[i].Position = rotateCell(te.Position)
+		}
+		sf.moveTicks(rotateCell)
+		for i, entity := range sf.Entities {
+			// Entities sit at continuous coordinates spanning the whole block
+			pos := entity.Position
+			sf.Entities[i].Position = StandardBlockPosition{X: float64(sizeZ) - pos.Z, Y: pos.Y, Z: pos.X}
+			sf.Entities[i].Rotation.Yaw = math.Mod(entity.Rotation.Yaw+90, 360)
+		}
+		sf.Size.X, sf.Size.Z = sf.Size.Z, sf.Size.X
+
+		if b := sf.Biomes; b != nil {
+			size := StandardSize{X: b.Size.Z, Y: b.Size.Y, Z: b.Size.X}
+			sf.Biomes = b.moved(size, func(x, y, z int) (int, int, int) {
+				return b.Size.Z - 1 - z, y, x
+			})
+		}
+	}
+
+	for i, p := range sf.Palette {
+		if len(p.Properties) > 0 {
+			p.Properties = rotateProperties(p, quarterTurns)
+			sf.Palette[i] = p
+		}
+	}
+
+	sf.invalidateIndex()
+	return nil
+}
+
+// mirroredValues maps property values to their mirror image across each axis
+var mirroredValues = map[string]map[string]string{
+	"x": {"east": "west", "west": "east"},
+	"y": {"up": "down", "down": "up", "top": "bottom", "bottom": "top", "floor": "ceiling", "ceiling": "floor"},
+	"z": {"north": "south", "south": "north"},
+}
+
+// mirroredHandedness swaps left and right, which every horizontal mirror flips
+var mirroredHandedness = map[string]string{
+	"left": "right", "right": "left",
+	"inner_left": "inner_right", "inner_right": "inner_left",
+	"outer_left": "outer_right", "outer_right": "outer_left",
+}
+
+// mirrorProperties returns a copy of a palette entry's properties mirrored
+// across the given axis
+func mirrorProperties(p StandardPalette, axis string) map[string]string {
+	swap := func(v string) string {
+		if m, ok := mirroredValues[axis][v]; ok {
+			return m
+		}
+		return v
+	}
+
+	mirrored := make(map[string]string, len(p.Properties))
+	for key, value := range p.Properties {
+		switch key {
+		case "facing", "half", "type", "attachment", "face":
+			// Up/down facings and the vertical halves of stairs, slabs, bells,
+			// buttons and levers; horizontal facings are handled below
+			if axis == "y" {
+				value = swap(value)
+			}
+		case "hinge":
+			if m, ok := mirroredHandedness[value]; ok && axis != "y" {
+				value = m
+			}
+		case "shape":
+			if m, ok := mirroredHandedness[value]; ok && axis != "y" {
+				value = m
+			} else {
+				value = mapRailShape(value, swap)
+			}
+		case "north", "east", "south", "west":
+			// Fences, walls, panes and redstone wire keep one key per side
+			key = swap(key)
+		}
+		mirrored[key] = value
+	}
+
+	if degrees, ok := NormalizeOrientation(p.Name, p.Properties); ok {
+		switch axis {
+		case "x":
+			setOrientation(mirrored, 360-degrees)
+		case "z":
+			setOrientation(mirrored, 180-degrees)
+		}
+	}
+	return mirrored
+}
+
+// Mirror flips the schematic across the given axis ("x", "y" or "z") within
+// its bounding box, so coordinates stay within [0, Size). The biome grid,
+// directional block properties, entity positions and entity yaw (or pitch
+// for "y") are mirrored too.
+func (sf *StandardFormat) Mirror(axis string) error {
+	if _, ok := mirroredValues[axis]; !ok {
+		return fmt.Errorf("unsupported mirror axis: %q", axis)
+	}
+
+	// Blocks occupy [p, p+1), so a block flips to size-1-p and an
+	// entity's continuous coordinate flips to size-p
+	flip := func(pos *StandardBlockPosition, offset float64) {
+		switch axis {
+		case "x":
+			pos.X = float64(sf.Size.X) - offset - pos.X
+		case "y":
+			pos.Y = float64(sf.Size.Y) - offset - pos.Y
+		case "z":
+			pos.Z = float64(sf.Size.Z) - offset - pos.Z
+		}
+	}
+	for i := range sf.Blocks {
+		flip(&sf.Blocks[i].Position, 1)
+	}
+	if b := sf.Biomes; b != nil {
+		sf.Biomes = b.moved(b.Size, func(x, y, z int) (int, int, int) {
+			switch axis {
+			case "x":
+				x = b.Size.X - 1 - x
+			case "y":
+				y = b.Size.Y - 1 - y
+			case "z":
+				z = b.Size.Z - 1 - z
+			}
+			return x, y, z
+		})
+	}
+	for i := range sf.TileEntities {
+		flip(&sf.TileEntities[i].Position, 1)
+	}
+	sf.moveTicks(func(pos StandardBlockPosition) StandardBlockPosition {
+		flip(&pos, 1)
+		return pos
+	})
+	for i := range sf.Entities {
+		flip(&sf.Entities[i].Position, 0)
+		rot := &sf.Entities[i].Rotation
+		switch axis {
+		case "x":
+			rot.Yaw = math.Mod(360-rot.Yaw, 360)
+		case "y":
+			rot.Pitch = -rot.Pitch
+		case "z":
+			rot.Yaw = math.Mod(540-rot.Yaw, 360)
+		}
+	}
+
+	for i, p := range sf.Palette {
+		if len(p.Properties) > 0 {
+			p.Properties = mirrorProperties(p, axis)
+			sf.Palette[i] = p
+		}
+	}
+
+	sf.invalidateIndex()
+	return nil
+}
+
+// tickKeys are the Extra keys holding scheduled ticks, which are positioned
+// like blocks
+var tickKeys = []string{"PendingBlockTicks", "PendingFluidTicks"}
+
+// moveTicks replaces the scheduled ticks kept in Extra with copies whose
+// positions fn has moved. The originals may be shared with a clone, so they
+// are not modified.
+func (sf *StandardFormat) moveTicks(fn func(StandardBlockPosition) StandardBlockPosition) {
+	for _, key := range tickKeys {
+		if _, ok := sf.Extra[key]; !ok {
+			continue
+		}
+		ticks := append([]LitematicaPendingTick(nil), pendingTicks(sf.Extra[key])...)
+		for i, tick := range ticks {
+			pos := fn(StandardBlockPosition{X: float64(tick.X), Y: float64(tick.Y), Z: float64(tick.Z)})
+			ticks[i].X, ticks[i].Y, ticks[i].Z = int32(pos.X), int32(pos.Y), int32(pos.Z)
+		}
+		sf.Extra[key] = ticks
+	}
+}
+
+// Crop returns a new schematic holding only the blocks and entities inside
+// the inclusive box from min to max. Positions are rebased so min becomes
+// the origin, Position moves by min so world placement is unchanged, and the
+// palette is compacted to the entries the cropped blocks use. Biomes are
+// cropped to the same box, or dropped if the box extends past them.
+func (sf *StandardFormat) Crop(min, max StandardPosition) (*StandardFormat, error) {
+	if min.X > max.X || min.Y > max.Y || min.Z > max.Z {
+		return nil, fmt.Errorf("invalid crop box: min %+v is greater than max %+v", min, max)
+	}
+
+	cropped := &StandardFormat{
+		Metadata:       sf.Metadata,
+		DataVersion:    sf.DataVersion,
+		Version:        sf.Version,
+		Size:           StandardSize{X: max.X - min.X + 1, Y: max.Y - min.Y + 1, Z: max.Z - min.Z + 1},
+		Position:       StandardPosition{X: sf.Position.X + min.X, Y: sf.Position.Y + min.Y, Z: sf.Position.Z + min.Z},
+		Palette:        make(map[int]StandardPalette),
+		OriginalFormat: sf.OriginalFormat,
+	}
+	if sf.Extra != nil {
+		cropped.Extra = make(map[string]interface{}, len(sf.Extra))
+		for k, v := range sf.Extra {
+			cropped.Extra[k] = v
+		}
+	}
+
+	// Scheduled ticks are positioned like blocks, so rebase them the same way
+	for _, key := range tickKeys {
+		if _, ok := cropped.Extra[key]; !ok {
+			continue
+		}
+		var kept []LitematicaPendingTick
+		for _, tick := range pendingTicks(cropped.Extra[key]) {
+			x, y, z := int(tick.X), int(tick.Y), int(tick.Z)
+			if x < min.X || x > max.X || y < min.Y || y > max.Y || z < min.Z || z > max.Z {
+				continue
+			}
+			tick.X -= int32(min.X)
+			tick.Y -= int32(min.Y)
+			tick.Z -= int32(min.Z)
+			kept = append(kept, tick)
+		}
+		if len(kept) > 0 {
+			cropped.Extra[key] = kept
+		} else {
+			delete(cropped.Extra, key)
+		}
+	}
+
+	for i, p := range sf.Palette {
+		cropped.Palette[i] = p
+	}
+	if sf.Biomes != nil {
+		cropped.Biomes = sf.Biomes.cropped(min, cropped.Size)
+	}
+
+	// rebase moves pos into the cropped box, reporting false if it falls outside
+	rebase := func(pos *StandardBlockPosition) bool {
+		x := int(math.Floor(pos.X))
+		y := int(math.Floor(pos.Y))
+		z := int(math.Floor(pos.Z))
+		if x < min.X || x > max.X || y < min.Y || y > max.Y || z < min.Z || z > max.Z {
+			return false
+		}
+		pos.X -= float64(min.X)
+		pos.Y -= float64(min.Y)
+		pos.Z -= float64(min.Z)
+		return true
+	}
+
+	for _, block := range sf.Blocks {
+		if rebase(&block.Position) {
+			cropped.Blocks = append(cropped.Blocks, block)
+		}
+	}
+	for _, te := range sf.TileEntities {
+		if rebase(&te.Position) {
+			cropped.TileEntities = append(cropped.TileEntities, te)
+		}
+	}
+	for _, entity := range sf.Entities {
+		if rebase(&entity.Position) {
+			cropped.Entities = append(cropped.Entities, entity)
+		}
+	}
+
+	cropped.CompactPalette()
+	return cropped, nil
+}
+
+// CompactPalette drops palette entries no block references and renumbers the
+// rest in their original order, rewriting every block's State to match. An air
+// entry, if the palette has one, is kept at index 0 where formats expect it.
+// Blocks whose State is missing from the palette get an entry of their own at
+// the end, named after the block's ID, so they can't end up sharing another
+// block's index.
+func (sf *StandardFormat) CompactPalette() {
+	used := make(map[int]bool)
+	missing := make(map[int]string)
+	for _, block := range sf.Blocks {
+		used[block.State] = true
+		if _, ok := sf.Palette[block.State]; !ok {
+			if _, seen := missing[block.State]; !seen || missing[block.State] == "" {
+				missing[block.State] = block.ID
+			}
+		}
+	}
+
+	air, hasAir := sf.airState()
+	var states []int
+	for state := range sf.Palette {
+		if used[state] && !(hasAir && state == air) {
+			states = append(states, state)
+		}
+	}
+	sort.Ints(states)
+	if hasAir {
+		states = append([]int{air}, states...)
+	}
+
+	remap := make(map[int]int, len(states)+len(missing))
+	palette := make(map[int]StandardPalette, len(states)+len(missing))
+	for i, state := range states {
+		remap[state] = i
+		palette[i] = sf.Palette[state]
+	}
+
+	missingStates := make([]int, 0, len(missing))
+	for state := range missing {
+		missingStates = append(missingStates, state)
+	}
+	sort.Ints(missingStates)
+	for _, state := range missingStates {
+		remap[state] = len(palette)
+		palette[len(palette)] = StandardPalette{Name: missing[state], Properties: map[string]string{}}
+	}
+
+	for i, block := range sf.Blocks {
+		sf.Blocks[i].State = remap[block.State]
+	}
+	sf.Palette = palette
+}
+
+// movedBiomes returns a copy of b with a grid of the given size, each cell
+// moved to the position fn returns. fn must map the grid onto the new one.
+func (b *StandardBiomes) moved(size StandardSize, fn func(x, y, z int) (int, int, int)) *StandardBiomes {
+	out := &StandardBiomes{Size: size, Palette: b.Palette, Data: make([]int, len(b.Data))}
+	for y := 0; y < b.Size.Y; y++ {
+		for z := 0; z < b.Size.Z; z++ {
+			for x := 0; x < b.Size.X; x++ {
+				i := (y*b.Size.Z+z)*b.Size.X + x
+				if i >= len(b.Data) {
+					return nil
+				}
+				nx, ny, nz := fn(x, y, z)
+				out.Data[(ny*size.Z+nz)*size.X+nx] = b.Data[i]
+			}
+		}
+	}
+	return out
+}
+
+// cropped returns the part of b inside the box of the given size starting at
+// min, or nil if the box doesn't fit in the grid. Per-column grids, with a
+// Y size of 1, keep their single layer.
+func (b *StandardBiomes) cropped(min StandardPosition, size StandardSize) *StandardBiomes {
+	if b.Size.Y == 1 {
+		min.Y, size.Y = 0, 1
+	}
+	if min.X < 0 || min.Y < 0 || min.Z < 0 ||
+		min.X+size.X > b.Size.X || min.Y+size.Y > b.Size.Y || min.Z+size.Z > b.Size.Z ||
+		len(b.Data) < b.Size.X*b.Size.Y*b.Size.Z {
+		return nil
+	}
+	out := &StandardBiomes{Size: size, Palette: b.Palette, Data: make([]int, 0, size.X*size.Y*size.Z)}
+	for y := min.Y; y < min.Y+size.Y; y++ {
+		for z := min.Z; z < min.Z+size.Z; z++ {
+			start := (y*b.Size.Z+z)*b.Size.X + min.X
+			out.Data = append(out.Data, b.Data[start:start+size.X]...)
+		}
+	}
+	return out
+}
+
+// pasted returns a copy of b with src's cells written over it at offset and
+// src's biome names added to the palette, or nil if src doesn't fit or the
+// grids differ in whether they are per-column
+func (b *StandardBiomes) pasted(src *StandardBiomes, offset StandardPosition) *StandardBiomes {
+	if (b.Size.Y == 1) != (src.Size.Y == 1) {
+		return nil
+	}
+	if b.Size.Y == 1 {
+		offset.Y = 0
+	}
+	if offset.X < 0 || offset.Y < 0 || offset.Z < 0 ||
+		offset.X+src.Size.X > b.Size.X || offset.Y+src.Size.Y > b.Size.Y || offset.Z+src.Size.Z > b.Size.Z ||
+		len(b.Data) < b.Size.X*b.Size.Y*b.Size.Z || len(src.Data) < src.Size.X*src.Size.Y*src.Size.Z {
+		return nil
+	}
+
+	out := &StandardBiomes{Size: b.Size, Palette: make(map[int]string, len(b.Palette)), Data: append([]int(nil), b.Data...)}
+	indices := make(map[string]int, len(b.Palette))
+	for i, name := range b.Palette {
+		out.Palette[i] = name
+		indices[name] = i
+	}
+	remap := make(map[int]int, len(src.Palette))
+	for i, name := range src.Palette {
+		index, ok := indices[name]
+		if !ok {
+			index = len(out.Palette)
+			for _, taken := out.Palette[index]; taken; _, taken = out.Palette[index] {
+				index++
+			}
+			out.Palette[index] = name
+			indices[name] = index
+		}
+		remap[i] = index
+	}
+
+	for y := 0; y < src.Size.Y; y++ {
+		for z := 0; z < src.Size.Z; z++ {
+			for x := 0; x < src.Size.X; x++ {
+				index := src.Data[(y*src.Size.Z+z)*src.Size.X+x]
+				if mapped, ok := remap[index]; ok {
+					index = mapped
+				}
+				out.Data[((y+offset.Y)*b.Size.Z+z+offset.Z)*b.Size.X+x+offset.X] = index
+			}
+		}
+	}
+	return out
+}
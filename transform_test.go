@@ -0,0 +1,518 @@
+package mcnbt
+
+import (
+	"testing"
+)
+
+// TestRecolor verifies red wool and concrete become blue while other blocks are untouched
+func TestRecolor(t *testing.T) {
+	sf := newTestStandard(4, 1, 1)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:red_wool"}
+	sf.Palette[3] = StandardPalette{Name: "minecraft:red_concrete"}
+	sf.Palette[4] = StandardPalette{Name: "minecraft:red_sandstone"}
+	addTestBlock(sf, 0, 0, 0, 2)
+	addTestBlock(sf, 1, 0, 0, 3)
+	addTestBlock(sf, 2, 0, 0, 3)
+	addTestBlock(sf, 3, 0, 0, 4)
+
+	changed := sf.Recolor("red", "blue")
+	if changed != 3 {
+		t.Errorf("Expected 3 blocks recolored, got %d", changed)
+	}
+
+	expected := map[int]string{
+		2: "minecraft:blue_wool",
+		3: "minecraft:blue_concrete",
+		4: "minecraft:red_sandstone",
+	}
+	for i, name := range expected {
+		if sf.Palette[i].Name != name {
+			t.Errorf("Palette[%d]: expected %s, got %s", i, name, sf.Palette[i].Name)
+		}
+	}
+
+	if sf.Recolor("red", "not_a_color") != 0 {
+		t.Errorf("Expected unknown color to change nothing")
+	}
+}
+
+// TestSnapEntitiesToGrid verifies entity positions are floored and blocks are untouched
+func TestSnapEntitiesToGrid(t *testing.T) {
+	sf := newTestStandard(4, 1, 1)
+	addTestBlock(sf, 1, 0, 0, 1)
+	sf.Entities = []StandardEntity{
+		{Position: StandardBlockPosition{X: 3.7, Y: 64.0, Z: 2.1}},
+		{Position: StandardBlockPosition{X: -0.5, Y: 1.2, Z: -2.9}},
+	}
+
+	sf.SnapEntitiesToGrid()
+
+	if pos := sf.Blocks[0].Position; pos != (StandardBlockPosition{X: 1, Y: 0, Z: 0}) {
+		t.Errorf("Expected the block to stay at 1,0,0, got %+v", pos)
+	}
+	expected := []StandardBlockPosition{
+		{X: 3, Y: 64, Z: 2},
+		{X: -1, Y: 1, Z: -3},
+	}
+	for i, pos := range expected {
+		if sf.Entities[i].Position != pos {
+			t.Errorf("Entity %d: expected %+v, got %+v", i, pos, sf.Entities[i].Position)
+		}
+	}
+}
+
+// TestRotateFullTurn verifies four quarter turns restore the original layout
+func TestRotateFullTurn(t *testing.T) {
+	sf := newTestStandard(3, 2, 2)
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 2, 1, 0, 1)
+	addTestBlock(sf, 1, 0, 1, 1)
+	sf.Entities = []StandardEntity{{
+		Position: StandardBlockPosition{X: 2.5, Y: 1, Z: 0.25},
+		Rotation: StandardRotation{Yaw: 45},
+	}}
+	original := append([]StandardBlock(nil), sf.Blocks...)
+
+	for i := 0; i < 4; i++ {
+		if err := sf.Rotate(90); err != nil {
+			t.Fatalf("Failed to rotate: %v", err)
+		}
+	}
+
+	if sf.Size != (StandardSize{X: 3, Y: 2, Z: 2}) {
+		t.Errorf("Expected original size, got %+v", sf.Size)
+	}
+	for i, block := range original {
+		if sf.Blocks[i].Position != block.Position {
+			t.Errorf("Block %d: expected %+v, got %+v", i, block.Position, sf.Blocks[i].Position)
+		}
+	}
+	if entity := sf.Entities[0]; entity.Position != (StandardBlockPosition{X: 2.5, Y: 1, Z: 0.25}) || entity.Rotation.Yaw != 45 {
+		t.Errorf("Expected entity at 2.5,1,0.25 with yaw 45, got %+v", entity)
+	}
+}
+
+// TestRotateQuarterTurn verifies positions, size and directional properties after 90 degrees
+func TestRotateQuarterTurn(t *testing.T) {
+	sf := newTestStandard(3, 1, 2)
+	sf.Palette[2] = StandardPalette{
+		Name:       "minecraft:oak_stairs",
+		Properties: map[string]string{"facing": "north", "half": "top"},
+	}
+	sf.Palette[3] = StandardPalette{
+		Name:       "minecraft:oak_fence",
+		Properties: map[string]string{"north": "true", "east": "false", "south": "false", "west": "false"},
+	}
+	addTestBlock(sf, 2, 0, 0, 2)
+	addTestBlock(sf, 0, 0, 1, 3)
+
+	if err := sf.Rotate(90); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+
+	if sf.Size.X != 2 || sf.Size.Z != 3 {
+		t.Errorf("Expected X and Z sizes to swap, got %+v", sf.Size)
+	}
+	if pos := sf.Blocks[0].Position; pos != (StandardBlockPosition{X: 1, Y: 0, Z: 2}) {
+		t.Errorf("Unexpected stairs position: %+v", pos)
+	}
+	if pos := sf.Blocks[1].Position; pos != (StandardBlockPosition{X: 0, Y: 0, Z: 0}) {
+		t.Errorf("Unexpected fence position: %+v", pos)
+	}
+	if facing := sf.Palette[2].Properties["facing"]; facing != "east" {
+		t.Errorf("Expected facing east, got %s", facing)
+	}
+	if sf.Palette[2].Properties["half"] != "top" {
+		t.Errorf("Expected half to be unchanged")
+	}
+	if sf.Palette[3].Properties["east"] != "true" || sf.Palette[3].Properties["north"] != "false" {
+		t.Errorf("Expected fence connection to move from north to east, got %v", sf.Palette[3].Properties)
+	}
+
+	if err := sf.Rotate(45); err == nil {
+		t.Errorf("Expected an error for a 45 degree rotation")
+	}
+}
+
+// TestMirrorTwice verifies mirroring twice on the same axis restores the original
+func TestMirrorTwice(t *testing.T) {
+	for _, axis := range []string{"x", "y", "z"} {
+		t.Run(axis, func(t *testing.T) {
+			sf := newTestStandard(3, 2, 4)
+			sf.Palette[2] = StandardPalette{
+				Name:       "minecraft:oak_stairs",
+				Properties: map[string]string{"facing": "east", "half": "bottom", "shape": "inner_left"},
+			}
+			addTestBlock(sf, 0, 0, 0, 1)
+			addTestBlock(sf, 2, 1, 3, 2)
+			entity := StandardEntity{
+				Position: StandardBlockPosition{X: 0.5, Y: 1.25, Z: 3.75},
+				Rotation: StandardRotation{Yaw: 45, Pitch: 10},
+			}
+			sf.Entities = []StandardEntity{entity}
+			original := append([]StandardBlock(nil), sf.Blocks...)
+
+			if err := sf.Mirror(axis); err != nil {
+				t.Fatalf("Failed to mirror: %v", err)
+			}
+			for _, block := range sf.Blocks {
+				p := block.Position
+				if p.X < 0 || p.X >= 3 || p.Y < 0 || p.Y >= 2 || p.Z < 0 || p.Z >= 4 {
+					t.Errorf("Mirrored block left the bounding box: %+v", p)
+				}
+			}
+			if err := sf.Mirror(axis); err != nil {
+				t.Fatalf("Failed to mirror: %v", err)
+			}
+
+			for i, block := range original {
+				if sf.Blocks[i].Position != block.Position {
+					t.Errorf("Block %d: expected %+v, got %+v", i, block.Position, sf.Blocks[i].Position)
+				}
+			}
+			if got := sf.Entities[0]; got.Position != entity.Position || got.Rotation != entity.Rotation {
+				t.Errorf("Expected entity %+v %+v, got %+v %+v", entity.Position, entity.Rotation, got.Position, got.Rotation)
+			}
+			props := sf.Palette[2].Properties
+			if props["facing"] != "east" || props["half"] != "bottom" || props["shape"] != "inner_left" {
+				t.Errorf("Expected original properties, got %v", props)
+			}
+		})
+	}
+}
+
+// TestMirrorProperties verifies directional properties flip across the mirrored axis only
+func TestMirrorProperties(t *testing.T) {
+	sf := newTestStandard(2, 1, 1)
+	sf.Palette[2] = StandardPalette{
+		Name:       "minecraft:oak_stairs",
+		Properties: map[string]string{"facing": "east", "half": "top", "shape": "outer_left"},
+	}
+	addTestBlock(sf, 0, 0, 0, 2)
+
+	if err := sf.Mirror("x"); err != nil {
+		t.Fatalf("Failed to mirror: %v", err)
+	}
+
+	if pos := sf.Blocks[0].Position; pos.X != 1 {
+		t.Errorf("Expected block at X=1, got %+v", pos)
+	}
+	props := sf.Palette[2].Properties
+	if props["facing"] != "west" || props["half"] != "top" || props["shape"] != "outer_right" {
+		t.Errorf("Unexpected mirrored properties: %v", props)
+	}
+
+	if err := sf.Mirror("w"); err == nil {
+		t.Errorf("Expected an error for an unknown axis")
+	}
+}
+
+// TestCropCorner verifies cropping a corner keeps only its blocks and used palette entries
+func TestCropCorner(t *testing.T) {
+	sf := newTestStandard(3, 3, 3)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:dirt"}
+	sf.Position = StandardPosition{X: 100, Y: 64, Z: -20}
+	for y := 0; y < 3; y++ {
+		for z := 0; z < 3; z++ {
+			for x := 0; x < 3; x++ {
+				state := 1
+				if x == 2 {
+					state = 2
+				}
+				addTestBlock(sf, x, y, z, state)
+			}
+		}
+	}
+	sf.Entities = []StandardEntity{{Position: StandardBlockPosition{X: 2.5, Y: 2.1, Z: 2.9}}}
+
+	cropped, err := sf.Crop(StandardPosition{X: 1, Y: 1, Z: 1}, StandardPosition{X: 2, Y: 2, Z: 2})
+	if err != nil {
+		t.Fatalf("Failed to crop: %v", err)
+	}
+
+	if cropped.Size != (StandardSize{X: 2, Y: 2, Z: 2}) {
+		t.Errorf("Expected size 2x2x2, got %+v", cropped.Size)
+	}
+	if cropped.Position != (StandardPosition{X: 101, Y: 65, Z: -19}) {
+		t.Errorf("Unexpected position: %+v", cropped.Position)
+	}
+	if len(cropped.Blocks) != 8 || len(cropped.Entities) != 1 {
+		t.Fatalf("Expected 8 blocks and 1 entity, got %d and %d", len(cropped.Blocks), len(cropped.Entities))
+	}
+	if pos := cropped.Entities[0].Position; pos != (StandardBlockPosition{X: 1.5, Y: 1.1, Z: 1.9}) {
+		t.Errorf("Unexpected entity position: %+v", pos)
+	}
+	if len(cropped.Palette) != 3 {
+		t.Errorf("Expected stone, dirt and air in the palette, got %v", cropped.Palette)
+	}
+	for _, block := range cropped.Blocks {
+		want := "minecraft:stone"
+		if block.Position.X == 1 {
+			want = "minecraft:dirt"
+		}
+		if got := cropped.Palette[block.State].Name; got != want {
+			t.Errorf("Block at %+v: expected %s, got %s", block.Position, want, got)
+		}
+	}
+
+	if _, err := sf.Crop(StandardPosition{X: 2}, StandardPosition{X: 1}); err == nil {
+		t.Errorf("Expected an error for an inverted box")
+	}
+}
+
+// TestCompactPalette verifies unused entries are dropped and blocks still resolve
+func TestCompactPalette(t *testing.T) {
+	sf := newTestStandard(3, 1, 1)
+	names := []string{"minecraft:dirt", "minecraft:glass", "minecraft:sand", "minecraft:gravel",
+		"minecraft:clay", "minecraft:snow_block", "minecraft:ice", "minecraft:obsidian"}
+	for i, name := range names {
+		sf.Palette[i+2] = StandardPalette{Name: name}
+	}
+	addTestBlock(sf, 0, 0, 0, 9)
+	addTestBlock(sf, 1, 0, 0, 4)
+	addTestBlock(sf, 2, 0, 0, 1)
+
+	if len(sf.Palette) != 10 {
+		t.Fatalf("Expected a 10 entry palette, got %d", len(sf.Palette))
+	}
+
+	sf.CompactPalette()
+
+	if len(sf.Palette) != 4 {
+		t.Fatalf("Expected 3 used entries plus air, got %d: %v", len(sf.Palette), sf.Palette)
+	}
+	if sf.Palette[0].Name != "minecraft:air" {
+		t.Errorf("Expected air to stay at index 0, got %s", sf.Palette[0].Name)
+	}
+
+	expected := []string{"minecraft:obsidian", "minecraft:sand", "minecraft:stone"}
+	for i, name := range expected {
+		if got := sf.Palette[sf.Blocks[i].State].Name; got != name {
+			t.Errorf("Block %d: expected %s, got %s", i, name, got)
+		}
+	}
+
+	// A block whose state isn't in the palette gets its own entry instead of
+	// keeping an index that now belongs to another block
+	sf = newTestStandard(3, 1, 1)
+	sf.Palette[3] = StandardPalette{Name: "minecraft:glass"}
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 1, 0, 0, 3)
+	sf.Blocks = append(sf.Blocks, StandardBlock{Type: "block", ID: "minecraft:bedrock", State: 2})
+
+	sf.CompactPalette()
+
+	expected = []string{"minecraft:stone", "minecraft:glass", "minecraft:bedrock"}
+	for i, name := range expected {
+		if got := sf.Palette[sf.Blocks[i].State].Name; got != name {
+			t.Errorf("Block %d: expected %s, got %s", i, name, got)
+		}
+	}
+}
+
+// TestReplaceBlock verifies oak_planks becomes spruce_planks, merging with an existing entry
+func TestReplaceBlock(t *testing.T) {
+	sf := newTestStandard(5, 1, 1)
+	sf.Palette[2] = StandardPalette{Name: "minecraft:oak_planks", Properties: map[string]string{}}
+	sf.Palette[3] = StandardPalette{Name: "minecraft:spruce_planks", Properties: map[string]string{}}
+	sf.Palette[4] = StandardPalette{Name: "minecraft:oak_stairs", Properties: map[string]string{"facing": "north"}}
+	addTestBlock(sf, 0, 0, 0, 2)
+	addTestBlock(sf, 1, 0, 0, 2)
+	addTestBlock(sf, 2, 0, 0, 3)
+	addTestBlock(sf, 3, 0, 0, 4)
+	addTestBlock(sf, 4, 0, 0, 1)
+
+	if changed := sf.ReplaceBlock("minecraft:oak_planks", "minecraft:spruce_planks"); changed != 2 {
+		t.Errorf("Expected 2 blocks replaced, got %d", changed)
+	}
+
+	spruce := 0
+	for _, p := range sf.Palette {
+		switch p.Name {
+		case "minecraft:oak_planks":
+			t.Errorf("Expected oak_planks to be gone from the palette")
+		case "minecraft:spruce_planks":
+			spruce++
+		}
+	}
+	if spruce != 1 {
+		t.Errorf("Expected a single spruce_planks entry, got %d", spruce)
+	}
+	for x := 0; x < 3; x++ {
+		if block, ok := sf.GetBlockAt(x, 0, 0); !ok || sf.Palette[block.State].Name != "minecraft:spruce_planks" {
+			t.Errorf("Expected spruce_planks at x=%d", x)
+		}
+	}
+
+	sf.ReplaceBlock("minecraft:oak_stairs", "minecraft:spruce_stairs")
+	stairs, _ := sf.GetBlockAt(3, 0, 0)
+	if p := sf.Palette[stairs.State]; p.Name != "minecraft:spruce_stairs" || p.Properties["facing"] != "north" {
+		t.Errorf("Expected spruce_stairs facing north, got %+v", p)
+	}
+
+	sf.ReplaceBlockWithProperties("minecraft:spruce_stairs", "minecraft:spruce_slab", false)
+	if p := sf.Palette[stairs.State]; p.Name != "minecraft:spruce_slab" || len(p.Properties) != 0 {
+		t.Errorf("Expected spruce_slab without properties, got %+v", p)
+	}
+}
+
+// TestTranslate verifies lookups follow the shift and that translations compose
+func TestTranslate(t *testing.T) {
+	sf := newTestStandard(2, 1, 1)
+	addTestBlock(sf, 0, 0, 0, 1)
+	sf.Entities = []StandardEntity{{Position: StandardBlockPosition{X: 0.5, Y: 0, Z: 0.5}}}
+	sf.Extra = map[string]interface{}{
+		"PendingBlockTicks": []LitematicaPendingTick{{Block: "minecraft:stone", X: 0, Y: 0, Z: 0}},
+	}
+
+	if _, ok := sf.GetBlockAt(0, 0, 0); !ok {
+		t.Fatalf("Expected a block at the origin before translating")
+	}
+
+	sf.Translate(3, -1, 2)
+	if _, ok := sf.GetBlockAt(0, 0, 0); ok {
+		t.Errorf("Expected no block at the origin after translating")
+	}
+	if _, ok := sf.GetBlockAt(3, -1, 2); !ok {
+		t.Errorf("Expected the block at 3,-1,2")
+	}
+
+	sf.Translate(-1, 4, 0)
+	if _, ok := sf.GetBlockAt(2, 3, 2); !ok {
+		t.Errorf("Expected the block at 2,3,2 after a second translation")
+	}
+	if sf.Position != (StandardPosition{X: 2, Y: 3, Z: 2}) {
+		t.Errorf("Expected position 2,3,2, got %+v", sf.Position)
+	}
+	if entity := sf.Entities[0].Position; entity != (StandardBlockPosition{X: 2.5, Y: 3, Z: 2.5}) {
+		t.Errorf("Expected entity at 2.5,3,2.5, got %+v", entity)
+	}
+	if tick := pendingTicks(sf.Extra["PendingBlockTicks"])[0]; tick.X != 2 || tick.Y != 3 || tick.Z != 2 {
+		t.Errorf("Expected the tick to move with its block to 2,3,2, got %+v", tick)
+	}
+	if sf.Size != (StandardSize{X: 2, Y: 1, Z: 1}) {
+		t.Errorf("Expected size to be unchanged, got %+v", sf.Size)
+	}
+}
+
+// TestTransformsKeepBiomes verifies Rotate, Mirror, Crop and Merge move the
+// biome grid with the blocks, so the result still encodes as WorldEdit
+func TestTransformsKeepBiomes(t *testing.T) {
+	// One biome per column, numbered by its index in a 2x3 grid
+	newBiomed := func() *StandardFormat {
+		sf := newTestStandard(2, 1, 3)
+		sf.Biomes = &StandardBiomes{Size: StandardSize{X: 2, Y: 1, Z: 3}, Palette: map[int]string{}}
+		for i := 0; i < 6; i++ {
+			sf.Biomes.Palette[i] = string(rune('a' + i))
+			sf.Biomes.Data = append(sf.Biomes.Data, i)
+		}
+		return sf
+	}
+	biomeAt := func(sf *StandardFormat, x, z int) string {
+		b := sf.Biomes
+		return b.Palette[b.Data[z*b.Size.X+x]]
+	}
+
+	rotated := newBiomed()
+	if err := rotated.Rotate(90); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	// Column (x, z) moves to (sizeZ-1-z, x), so (1, 0) "b" lands at (2, 1)
+	if rotated.Biomes.Size != (StandardSize{X: 3, Y: 1, Z: 2}) || biomeAt(rotated, 2, 1) != "b" || biomeAt(rotated, 0, 0) != "e" {
+		t.Errorf("Unexpected rotated biomes %+v", rotated.Biomes)
+	}
+
+	mirrored := newBiomed()
+	if err := mirrored.Mirror("z"); err != nil {
+		t.Fatalf("Failed to mirror: %v", err)
+	}
+	if biomeAt(mirrored, 0, 0) != "e" || biomeAt(mirrored, 1, 2) != "b" {
+		t.Errorf("Unexpected mirrored biomes %+v", mirrored.Biomes)
+	}
+
+	cropped, err := newBiomed().Crop(StandardPosition{X: 1, Z: 1}, StandardPosition{X: 1, Z: 2})
+	if err != nil {
+		t.Fatalf("Failed to crop: %v", err)
+	}
+	if cropped.Biomes == nil || len(cropped.Biomes.Data) != 2 || biomeAt(cropped, 0, 0) != "d" || biomeAt(cropped, 0, 1) != "f" {
+		t.Errorf("Unexpected cropped biomes %+v", cropped.Biomes)
+	}
+
+	overlay := newTestStandard(1, 1, 1)
+	overlay.Biomes = &StandardBiomes{Size: StandardSize{X: 1, Y: 1, Z: 1}, Palette: map[int]string{0: "z"}, Data: []int{0}}
+	merged, err := Merge(newBiomed(), overlay, StandardPosition{X: 1, Z: 2})
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if biomeAt(merged, 1, 2) != "z" || biomeAt(merged, 0, 2) != "e" {
+		t.Errorf("Unexpected merged biomes %+v", merged.Biomes)
+	}
+	grown, err := Merge(newBiomed(), overlay, StandardPosition{X: 2})
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if grown.Biomes != nil {
+		t.Errorf("Expected biomes to be dropped when the merged box grows, got %+v", grown.Biomes)
+	}
+
+	for name, sf := range map[string]*StandardFormat{"rotated": rotated, "mirrored": mirrored, "cropped": cropped, "merged": merged} {
+		if _, err := ConvertFromStandard(sf, "worldedit"); err != nil {
+			t.Errorf("Failed to convert %s schematic to worldedit: %v", name, err)
+		}
+	}
+
+	// A grid that doesn't match the schematic is rejected rather than written
+	mismatched := newBiomed()
+	mismatched.Size.X = 3
+	if _, err := ConvertFromStandard(mismatched, "worldedit"); err == nil {
+		t.Errorf("Expected an error for a biome grid smaller than the schematic")
+	}
+}
+
+// TestTransformsMoveTicks verifies scheduled ticks move with their blocks in
+// Rotate, Mirror and Merge
+func TestTransformsMoveTicks(t *testing.T) {
+	newTicked := func() *StandardFormat {
+		sf := newTestStandard(2, 1, 3)
+		addTestBlock(sf, 1, 0, 0, 1)
+		sf.Extra = map[string]interface{}{
+			"PendingBlockTicks": []LitematicaPendingTick{{Block: "minecraft:stone", X: 1, Y: 0, Z: 0}},
+		}
+		return sf
+	}
+	tickAt := func(sf *StandardFormat) StandardBlockPosition {
+		ticks := pendingTicks(sf.Extra["PendingBlockTicks"])
+		if len(ticks) != 1 {
+			t.Fatalf("Expected 1 tick, got %+v", ticks)
+		}
+		return StandardBlockPosition{X: float64(ticks[0].X), Y: float64(ticks[0].Y), Z: float64(ticks[0].Z)}
+	}
+
+	original := newTicked()
+	rotated := original.clone()
+	if err := rotated.Rotate(90); err != nil {
+		t.Fatalf("Failed to rotate: %v", err)
+	}
+	if pos := tickAt(rotated); pos != rotated.Blocks[0].Position {
+		t.Errorf("Expected the tick at the rotated block %+v, got %+v", rotated.Blocks[0].Position, pos)
+	}
+	if pos := tickAt(original); pos != (StandardBlockPosition{X: 1}) {
+		t.Errorf("Expected the original's tick to be unchanged, got %+v", pos)
+	}
+
+	mirrored := newTicked()
+	if err := mirrored.Mirror("x"); err != nil {
+		t.Fatalf("Failed to mirror: %v", err)
+	}
+	if pos := tickAt(mirrored); pos != mirrored.Blocks[0].Position {
+		t.Errorf("Expected the tick at the mirrored block %+v, got %+v", mirrored.Blocks[0].Position, pos)
+	}
+
+	merged, err := Merge(newTestStandard(1, 1, 1), newTicked(), StandardPosition{X: -2})
+	if err != nil {
+		t.Fatalf("Failed to merge: %v", err)
+	}
+	if pos := tickAt(merged); pos != (StandardBlockPosition{X: 1}) {
+		t.Errorf("Expected the merged tick at 1,0,0, got %+v", pos)
+	}
+}
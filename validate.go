@@ -0,0 +1,127 @@
+package mcnbt
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Validate checks the invariants the encoders rely on: Size is non-negative,
+// every block's State is in Palette and its position lies within [0, Size),
+// and entity positions are finite. Converters silently skip blocks that break
+// these, so Validate reports them first. The result joins one error per
+// problem, each wrapping ErrInvalidStandard, or is nil if there are none.
+func (sf *StandardFormat) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidStandard, fmt.Sprintf(format, args...)))
+	}
+
+	if sf.Size.X < 0 || sf.Size.Y < 0 || sf.Size.Z < 0 {
+		invalid("negative size %dx%dx%d", sf.Size.X, sf.Size.Y, sf.Size.Z)
+	}
+
+	for i, block := range sf.Blocks {
+		if _, ok := sf.Palette[block.State]; !ok {
+			invalid("block %d has state %d, which is not in the palette", i, block.State)
+		}
+		pos := block.Position
+		if pos.X < 0 || pos.X >= float64(sf.Size.X) ||
+			pos.Y < 0 || pos.Y >= float64(sf.Size.Y) ||
+			pos.Z < 0 || pos.Z >= float64(sf.Size.Z) {
+			invalid("block %d at %v,%v,%v is outside the %dx%dx%d bounds",
+				i, pos.X, pos.Y, pos.Z, sf.Size.X, sf.Size.Y, sf.Size.Z)
+		}
+	}
+
+	for i, entity := range sf.Entities {
+		for _, v := range []float64{entity.Position.X, entity.Position.Y, entity.Position.Z} {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				invalid("entity %d (%s) has a non-finite position", i, entity.ID)
+				break
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidationError describes a palette property that does not match a schema
+type ValidationError struct {
+	// Palette index of the offending entry
+	State int `json:"state"`
+
+	// Block name of the offending entry
+	Block string `json:"block"`
+
+	// Property key and value that failed validation
+	Property string `json:"property"`
+	Value    string `json:"value"`
+
+	// Human-readable explanation
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("palette entry %d (%s): %s", e.State, e.Block, e.Message)
+}
+
+// ValidateProperties checks every palette entry's properties against schema,
+// which maps a property to its allowed values. A key may be scoped to one
+// block as "minecraft:oak_stairs[facing]", which takes precedence over the
+// bare "facing". Properties with no matching key are reported as unknown, and
+// values missing from the allowed list as out of range. Results are ordered by
+// palette index, then property.
+func (sf *StandardFormat) ValidateProperties(schema map[string][]string) []ValidationError {
+	states := make([]int, 0, len(sf.Palette))
+	for state := range sf.Palette {
+		states = append(states, state)
+	}
+	sort.Ints(states)
+
+	var errs []ValidationError
+	for _, state := range states {
+		p := sf.Palette[state]
+		keys := make([]string, 0, len(p.Properties))
+		for key := range p.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			value := p.Properties[key]
+			allowed, ok := schema[p.Name+"["+key+"]"]
+			if !ok {
+				allowed, ok = schema[key]
+			}
+
+			var message string
+			switch {
+			case !ok:
+				message = fmt.Sprintf("unknown property %q", key)
+			case !containsString(allowed, value):
+				message = fmt.Sprintf("invalid value %q for %s, expected one of %v", value, key, allowed)
+			default:
+				continue
+			}
+			errs = append(errs, ValidationError{
+				State:    state,
+				Block:    p.Name,
+				Property: key,
+				Value:    value,
+				Message:  message,
+			})
+		}
+	}
+	return errs
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
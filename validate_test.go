@@ -0,0 +1,72 @@
+package mcnbt
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestValidate verifies each broken invariant is reported
+func TestValidate(t *testing.T) {
+	sf := newTestStandard(2, 1, 1)
+	addTestBlock(sf, 0, 0, 0, 1)
+	addTestBlock(sf, 1, 0, 0, 0)
+	sf.Entities = append(sf.Entities, StandardEntity{ID: "minecraft:pig", Position: StandardBlockPosition{X: 1.5, Y: 0, Z: 0.5}})
+	if err := sf.Validate(); err != nil {
+		t.Fatalf("Expected a valid structure, got %v", err)
+	}
+
+	sf.Blocks[0].State = 7
+	sf.Blocks[1].Position.X = 2
+	sf.Entities[0].Position.Y = math.NaN()
+
+	err := sf.Validate()
+	if !errors.Is(err, ErrInvalidStandard) {
+		t.Fatalf("Expected ErrInvalidStandard, got %v", err)
+	}
+	problems := strings.Split(err.Error(), "\n")
+	if len(problems) != 3 {
+		t.Fatalf("Expected 3 problems, got %d: %v", len(problems), err)
+	}
+	for i, fragment := range []string{"state 7", "outside", "non-finite"} {
+		if !strings.Contains(problems[i], fragment) {
+			t.Errorf("Expected problem %d to mention %q, got %q", i, fragment, problems[i])
+		}
+	}
+}
+
+// TestValidateProperties verifies invalid values and unknown keys are flagged
+// and block-scoped schema entries take precedence
+func TestValidateProperties(t *testing.T) {
+	sf := newTestStandard(1, 1, 1)
+	sf.Palette[2] = StandardPalette{
+		Name:       "minecraft:oak_stairs",
+		Properties: map[string]string{"facing": "upside_down", "half": "top", "shape": "straight"},
+	}
+	sf.Palette[3] = StandardPalette{
+		Name:       "minecraft:hopper",
+		Properties: map[string]string{"facing": "down"},
+	}
+
+	schema := map[string][]string{
+		"facing":                      {"north", "south", "east", "west"},
+		"half":                        {"top", "bottom"},
+		"minecraft:hopper[facing]":    {"down", "north", "south", "east", "west"},
+		"minecraft:oak_stairs[shape]": {"straight", "inner_left", "inner_right", "outer_left", "outer_right"},
+	}
+
+	errs := sf.ValidateProperties(schema)
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 validation error, got %d: %+v", len(errs), errs)
+	}
+	if e := errs[0]; e.State != 2 || e.Property != "facing" || e.Value != "upside_down" {
+		t.Errorf("Expected facing=upside_down on state 2 to be flagged, got %+v", e)
+	}
+
+	delete(schema, "half")
+	errs = sf.ValidateProperties(schema)
+	if len(errs) != 2 || errs[1].Property != "half" {
+		t.Errorf("Expected half to be reported as unknown, got %+v", errs)
+	}
+}
@@ -0,0 +1,97 @@
+package mcnbt
+
+import (
+	"context"
+	"fmt"
+)
+
+// WarningCode identifies a kind of non-fatal conversion issue
+type WarningCode string
+
+// Codes of the warnings ConvertToStandardVerbose reports
+const (
+	// A pre-1.13 block ID has no known name and became minecraft:legacy_block
+	WarningUnknownLegacyBlock WarningCode = "unknown_legacy_block"
+
+	// A Litematica file has more than one region and only the first was converted
+	WarningRegionsDropped WarningCode = "regions_dropped"
+
+	// A block references a palette index the palette doesn't have, so it has no ID
+	WarningStateNotInPalette WarningCode = "state_not_in_palette"
+
+	// A block without a full position was skipped
+	WarningBlockSkipped WarningCode = "block_skipped"
+
+	// A block entity outside the schematic's bounds was dropped
+	WarningTileEntityDropped WarningCode = "tile_entity_dropped"
+
+	// An entity without a full position was skipped
+	WarningEntitySkipped WarningCode = "entity_skipped"
+)
+
+// Warning describes data a conversion dropped or made up instead of failing
+type Warning struct {
+	Code WarningCode `json:"code"`
+
+	// Description of the first occurrence
+	Message string `json:"message"`
+
+	// Number of times the issue occurred
+	Count int `json:"count"`
+}
+
+func (w Warning) String() string {
+	if w.Count > 1 {
+		return fmt.Sprintf("%s: %s (%d times)", w.Code, w.Message, w.Count)
+	}
+	return fmt.Sprintf("%s: %s", w.Code, w.Message)
+}
+
+// ConvertToStandardVerbose converts like ConvertToStandard and also returns
+// the non-fatal issues found along the way, one Warning per code in the
+// order they first occurred. Warnings collected before an error are
+// returned with it.
+func ConvertToStandardVerbose(data interface{}) (*StandardFormat, []Warning, error) {
+	collector := &warningCollector{}
+	ctx := context.WithValue(context.Background(), warningsKey{}, collector)
+	sf, err := ConvertToStandardContext(ctx, data)
+	return sf, collector.warnings, err
+}
+
+// warningsKey is the context key of the warningCollector a conversion reports to
+type warningsKey struct{}
+
+// warningCollector accumulates warnings, counting repeats of a code
+type warningCollector struct {
+	warnings []Warning
+}
+
+// warn reports a non-fatal issue to the Logger and to the warningCollector
+// in ctx, if there is one. The message is only formatted for the first
+// occurrence of a code, or for the Logger when one is set.
+func warn(ctx context.Context, code WarningCode, format string, args ...interface{}) {
+	collector, _ := ctx.Value(warningsKey{}).(*warningCollector)
+	if collector != nil {
+		for i := range collector.warnings {
+			if collector.warnings[i].Code == code {
+				collector.warnings[i].Count++
+				logWarning(code, format, args...)
+				return
+			}
+		}
+		collector.warnings = append(collector.warnings, Warning{
+			Code:    code,
+			Message: fmt.Sprintf(format, args...),
+			Count:   1,
+		})
+	}
+	logWarning(code, format, args...)
+}
+
+// logWarning sends a warning to the current Logger, unless messages are discarded
+func logWarning(code WarningCode, format string, args ...interface{}) {
+	l := currentLogger()
+	if _, discard := l.(nopLogger); !discard {
+		l.Println(string(code)+":", fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,89 @@
+package mcnbt
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestConvertToStandardVerboseLegacyFallback verifies unknown pre-1.13 IDs
+// falling back to minecraft:legacy_block are reported as one counted warning
+func TestConvertToStandardVerboseLegacyFallback(t *testing.T) {
+	legacy := map[string]interface{}{
+		"Width":     int16(3),
+		"Height":    int16(1),
+		"Length":    int16(1),
+		"Materials": "Alpha",
+		"Blocks":    []byte{1, 253, 253},
+		"Data":      []byte{0, 0, 0},
+	}
+
+	sf, warnings, err := ConvertToStandardVerbose(legacy)
+	if err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+	if block, _ := sf.GetBlockAt(1, 0, 0); block.ID != "minecraft:legacy_block" {
+		t.Fatalf("Expected the fallback block at 1,0,0, got %+v", block)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %v", warnings)
+	}
+	if w := warnings[0]; w.Code != WarningUnknownLegacyBlock || w.Count != 2 || !strings.Contains(w.Message, "253") {
+		t.Errorf("Expected an unknown_legacy_block warning for ID 253 counted twice, got %+v", w)
+	}
+
+	// Warnings also reach a custom logger, once per occurrence
+	recorder := &recordingLogger{}
+	SetLogger(recorder)
+	defer SetLogger(nil)
+	if _, err := ConvertToStandard(legacy); err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+	if len(recorder.messages) != 2 || !strings.HasPrefix(recorder.messages[0], "unknown_legacy_block:") {
+		t.Errorf("Expected two unknown_legacy_block messages, got %q", recorder.messages)
+	}
+}
+
+// TestConvertToStandardVerboseSkips verifies skipped blocks and entities and
+// missing palette states each produce a warning, and clean files none
+func TestConvertToStandardVerboseSkips(t *testing.T) {
+	structure := map[string]interface{}{
+		"DataVersion": int32(3465),
+		"size":        []int32{2, 1, 1},
+		"palette":     []interface{}{map[string]interface{}{"Name": "minecraft:stone"}},
+		"blocks": []interface{}{
+			map[string]interface{}{"pos": []int32{0, 0, 0}, "state": int32(0)},
+			map[string]interface{}{"pos": []int32{1, 0, 0}, "state": int32(4)},
+			map[string]interface{}{"pos": []int32{1, 0}, "state": int32(0)},
+		},
+		"entities": []interface{}{
+			map[string]interface{}{"nbt": map[string]interface{}{"id": "minecraft:pig"}},
+		},
+	}
+
+	_, warnings, err := ConvertToStandardVerbose(structure)
+	if err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+	expected := []WarningCode{WarningStateNotInPalette, WarningBlockSkipped, WarningEntitySkipped}
+	if len(warnings) != len(expected) {
+		t.Fatalf("Expected %d warnings, got %v", len(expected), warnings)
+	}
+	for i, code := range expected {
+		if warnings[i].Code != code || warnings[i].Count != 1 {
+			t.Errorf("Warning %d: expected %s once, got %+v", i, code, warnings[i])
+		}
+	}
+
+	payload, err := os.ReadFile("testdata/color_field.litematic")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	data, err := DecodeAny(payload)
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	if _, warnings, err := ConvertToStandardVerbose(data); err != nil || len(warnings) != 0 {
+		t.Errorf("Expected no warnings for a clean file, got %v (%v)", warnings, err)
+	}
+}
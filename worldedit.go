@@ -1,5 +1,10 @@
 package mcnbt
 
+import (
+	"context"
+	"fmt"
+)
+
 // WorldEditMetadata represents the metadata of a WorldEdit schematic
 type WorldEditMetadata struct {
 	WEOffsetX int32 `json:"WEOffsetX" nbt:"WEOffsetX"`
@@ -9,15 +14,186 @@ type WorldEditMetadata struct {
 
 // WorldEditNBT represents a WorldEdit schematic
 type WorldEditNBT struct {
-	BlockData     []byte            `json:"BlockData" nbt:"BlockData"`
-	BlockEntities []map[string]any  `json:"BlockEntities" nbt:"BlockEntities"`
-	DataVersion   int32             `json:"DataVersion" nbt:"DataVersion"`
-	Height        int16             `json:"Height" nbt:"Height"`
-	Length        int16             `json:"Length" nbt:"Length"`
-	Metadata      WorldEditMetadata `json:"Metadata" nbt:"Metadata"`
-	Offset        []int32           `json:"Offset" nbt:"Offset"`
-	Palette       map[string]int32  `json:"Palette" nbt:"Palette"`
-	PaletteMax    int32             `json:"PaletteMax" nbt:"PaletteMax"`
-	Version       int32             `json:"Version" nbt:"Version"`
-	Width         int16             `json:"Width" nbt:"Width"`
+	BiomeData       []byte            `json:"BiomeData,omitempty" nbt:"BiomeData,omitempty"`
+	BiomePalette    map[string]int32  `json:"BiomePalette,omitempty" nbt:"BiomePalette,omitempty"`
+	BiomePaletteMax int32             `json:"BiomePaletteMax,omitempty" nbt:"BiomePaletteMax,omitempty"`
+	BlockData       []byte            `json:"BlockData" nbt:"BlockData"`
+	BlockEntities   []map[string]any  `json:"BlockEntities" nbt:"BlockEntities"`
+	DataVersion     int32             `json:"DataVersion" nbt:"DataVersion"`
+	Entities        []map[string]any  `json:"Entities,omitempty" nbt:"Entities,omitempty"`
+	Height          int16             `json:"Height" nbt:"Height"`
+	Length          int16             `json:"Length" nbt:"Length"`
+	Metadata        WorldEditMetadata `json:"Metadata" nbt:"Metadata"`
+	Offset          []int32           `json:"Offset" nbt:"Offset"`
+	Palette         map[string]int32  `json:"Palette" nbt:"Palette"`
+	PaletteMax      int32             `json:"PaletteMax" nbt:"PaletteMax"`
+	Version         int32             `json:"Version" nbt:"Version"`
+	Width           int16             `json:"Width" nbt:"Width"`
+}
+
+// WorldEditV3Blocks is the block container of a Sponge v3 schematic
+type WorldEditV3Blocks struct {
+	Palette       map[string]int32         `json:"Palette" nbt:"Palette"`
+	Data          []byte                   `json:"Data" nbt:"Data"`
+	BlockEntities []WorldEditV3BlockEntity `json:"BlockEntities" nbt:"BlockEntities"`
+}
+
+// WorldEditV3Biomes is the biome container of a Sponge v3 schematic, with one
+// entry per block in YZX order
+type WorldEditV3Biomes struct {
+	Palette map[string]int32 `json:"Palette" nbt:"Palette"`
+	Data    []byte           `json:"Data" nbt:"Data"`
+}
+
+// WorldEditV3BlockEntity is a Sponge v3 block entity, whose NBT is nested under Data
+type WorldEditV3BlockEntity struct {
+	Pos  []int32        `json:"Pos" nbt:"Pos"`
+	Id   string         `json:"Id" nbt:"Id"`
+	Data map[string]any `json:"Data" nbt:"Data"`
+}
+
+// WorldEditV3Entity is a Sponge v3 entity, whose NBT is nested under Data
+type WorldEditV3Entity struct {
+	Pos  []float64      `json:"Pos" nbt:"Pos"`
+	Id   string         `json:"Id" nbt:"Id"`
+	Data map[string]any `json:"Data" nbt:"Data"`
+}
+
+// WorldEditV3NBT represents a Sponge v3 schematic. Files wrap it in a
+// "Schematic" compound, and blocks are nested under Blocks instead of
+// the top-level Palette and BlockData of v2.
+type WorldEditV3NBT struct {
+	Biomes      WorldEditV3Biomes   `json:"Biomes" nbt:"Biomes"`
+	Blocks      WorldEditV3Blocks   `json:"Blocks" nbt:"Blocks"`
+	DataVersion int32               `json:"DataVersion" nbt:"DataVersion"`
+	Entities    []WorldEditV3Entity `json:"Entities,omitempty" nbt:"Entities,omitempty"`
+	Height      int16               `json:"Height" nbt:"Height"`
+	Length      int16               `json:"Length" nbt:"Length"`
+	Metadata    WorldEditMetadata   `json:"Metadata" nbt:"Metadata"`
+	Offset      []int32             `json:"Offset" nbt:"Offset"`
+	Version     int32               `json:"Version" nbt:"Version"`
+	Width       int16               `json:"Width" nbt:"Width"`
+}
+
+// toV2 flattens a v3 schematic into the v2 layout. Block entity and entity
+// Data is merged into the compound next to Pos and Id, as v2 stores it.
+func (v3 *WorldEditV3NBT) toV2() *WorldEditNBT {
+	v2 := &WorldEditNBT{
+		BlockData:   v3.Blocks.Data,
+		DataVersion: v3.DataVersion,
+		Height:      v3.Height,
+		Length:      v3.Length,
+		Metadata:    v3.Metadata,
+		Offset:      v3.Offset,
+		Palette:     v3.Blocks.Palette,
+		PaletteMax:  int32(len(v3.Blocks.Palette)),
+		Version:     v3.Version,
+		Width:       v3.Width,
+	}
+	for _, be := range v3.Blocks.BlockEntities {
+		flat := make(map[string]any, len(be.Data)+2)
+		for k, v := range be.Data {
+			flat[k] = v
+		}
+		if len(be.Pos) >= 3 {
+			flat["Pos"] = []interface{}{be.Pos[0], be.Pos[1], be.Pos[2]}
+		}
+		flat["Id"] = be.Id
+		v2.BlockEntities = append(v2.BlockEntities, flat)
+	}
+	for _, e := range v3.Entities {
+		flat := make(map[string]any, len(e.Data)+2)
+		for k, v := range e.Data {
+			flat[k] = v
+		}
+		if len(e.Pos) >= 3 {
+			flat["Pos"] = []float64{e.Pos[0], e.Pos[1], e.Pos[2]}
+		}
+		flat["Id"] = e.Id
+		v2.Entities = append(v2.Entities, flat)
+	}
+	return v2
+}
+
+// WorldEditLegacyNBT represents a pre-1.13 MCEdit/WorldEdit schematic, which
+// stores numeric block IDs and data values in YZX order instead of a palette
+type WorldEditLegacyNBT struct {
+	Width        int16            `json:"Width" nbt:"Width"`
+	Height       int16            `json:"Height" nbt:"Height"`
+	Length       int16            `json:"Length" nbt:"Length"`
+	Materials    string           `json:"Materials" nbt:"Materials"`
+	Blocks       []byte           `json:"Blocks" nbt:"Blocks"`
+	AddBlocks    []byte           `json:"AddBlocks,omitempty" nbt:"AddBlocks,omitempty"`
+	Data         []byte           `json:"Data" nbt:"Data"`
+	TileEntities []map[string]any `json:"TileEntities" nbt:"TileEntities"`
+	Entities     []map[string]any `json:"Entities" nbt:"Entities"`
+	WEOffsetX    int32            `json:"WEOffsetX" nbt:"WEOffsetX"`
+	WEOffsetY    int32            `json:"WEOffsetY" nbt:"WEOffsetY"`
+	WEOffsetZ    int32            `json:"WEOffsetZ" nbt:"WEOffsetZ"`
+}
+
+// blockID returns the numeric block ID at index i, including the high bits
+// held in AddBlocks, which packs two 4-bit values per byte with the even
+// index in the low nibble
+func (l *WorldEditLegacyNBT) blockID(i int) int {
+	id := int(l.Blocks[i])
+	if i>>1 < len(l.AddBlocks) {
+		add := int(l.AddBlocks[i>>1])
+		if i&1 == 0 {
+			add &= 0x0f
+		} else {
+			add >>= 4
+		}
+		id |= add << 8
+	}
+	return id
+}
+
+// toV2 converts a legacy schematic into the v2 layout, building a palette
+// from the distinct ID and data pairs through LegacyBlockState. Block entities
+// keep their x, y and z tags, and their lowercase id is copied to Id.
+// Entities are kept as they are.
+func (l *WorldEditLegacyNBT) toV2(ctx context.Context) (*WorldEditNBT, error) {
+	volume := int(l.Width) * int(l.Height) * int(l.Length)
+	if len(l.Blocks) < volume || len(l.Data) < volume {
+		return nil, fmt.Errorf("legacy schematic has %d blocks and %d data values, expected %d", len(l.Blocks), len(l.Data), volume)
+	}
+
+	v2 := &WorldEditNBT{
+		Height:   l.Height,
+		Length:   l.Length,
+		Metadata: WorldEditMetadata{WEOffsetX: l.WEOffsetX, WEOffsetY: l.WEOffsetY, WEOffsetZ: l.WEOffsetZ},
+		Entities: l.Entities,
+		Palette:  make(map[string]int32),
+		Width:    l.Width,
+	}
+	for i := 0; i < volume; i++ {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
+		}
+		id := l.blockID(i)
+		if _, known := legacyBlockNames[id]; !known {
+			warn(ctx, WarningUnknownLegacyBlock, "block ID %d at index %d has no known name and became minecraft:legacy_block", id, i)
+		}
+		state := EncodePropertyString(LegacyBlockState(id, int(l.Data[i]&0x0f)))
+		index, ok := v2.Palette[state]
+		if !ok {
+			index = int32(len(v2.Palette))
+			v2.Palette[state] = index
+		}
+		v2.BlockData = append(v2.BlockData, writeVarint(int(index))...)
+	}
+	v2.PaletteMax = int32(len(v2.Palette))
+
+	for _, te := range l.TileEntities {
+		flat := make(map[string]any, len(te)+1)
+		for k, v := range te {
+			flat[k] = v
+		}
+		if id, ok := te["id"].(string); ok {
+			flat["Id"] = id
+		}
+		v2.BlockEntities = append(v2.BlockEntities, flat)
+	}
+	return v2, nil
 }
@@ -0,0 +1,508 @@
+package mcnbt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// TestWorldEditOutOfOrderPalette verifies blocks resolve through the palette
+// index stored as the map value, even when indices are sparse
+func TestWorldEditOutOfOrderPalette(t *testing.T) {
+	worldEdit := &WorldEditNBT{
+		Width:  4,
+		Height: 1,
+		Length: 1,
+		Palette: map[string]int32{
+			"minecraft:stone":           7,
+			"minecraft:air":             0,
+			"minecraft:oak_log[axis=x]": 2,
+			"minecraft:glass":           1,
+		},
+		BlockData: []byte{7, 2, 0, 1},
+	}
+
+	standard, err := ConvertToStandard(worldEdit)
+	if err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+
+	expected := []string{"minecraft:stone", "minecraft:oak_log", "minecraft:air", "minecraft:glass"}
+	if len(standard.Blocks) != len(expected) {
+		t.Fatalf("Expected %d blocks, got %d", len(expected), len(standard.Blocks))
+	}
+
+	for i, name := range expected {
+		block := standard.Blocks[i]
+		p, ok := standard.Palette[block.State]
+		if !ok {
+			t.Fatalf("Block %d references missing palette index %d", i, block.State)
+		}
+		if p.Name != name {
+			t.Errorf("Block %d: expected %s, got %s", i, name, p.Name)
+		}
+	}
+
+	if axis := standard.Palette[2].Properties["axis"]; axis != "x" {
+		t.Errorf("Expected oak_log axis=x, got %q", axis)
+	}
+}
+
+// TestWorldEditLargePalette verifies palette indices above 127 decode from multi-byte varints
+func TestWorldEditLargePalette(t *testing.T) {
+	const paletteSize = 300
+
+	palette := make(map[string]int32, paletteSize)
+	for i := 0; i < paletteSize; i++ {
+		palette[fmt.Sprintf("minecraft:test_block_%d", i)] = int32(i)
+	}
+
+	// One block per palette entry, in reverse so every varint width is exercised
+	var blockData []byte
+	for i := paletteSize - 1; i >= 0; i-- {
+		blockData = append(blockData, writeVarint(i)...)
+	}
+
+	worldEdit := &WorldEditNBT{
+		Width:     paletteSize,
+		Height:    1,
+		Length:    1,
+		Palette:   palette,
+		BlockData: blockData,
+	}
+
+	standard, err := ConvertToStandard(worldEdit)
+	if err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+	if len(standard.Blocks) != paletteSize {
+		t.Fatalf("Expected %d blocks, got %d", paletteSize, len(standard.Blocks))
+	}
+
+	for x, block := range standard.Blocks {
+		expected := fmt.Sprintf("minecraft:test_block_%d", paletteSize-1-x)
+		if name := standard.Palette[block.State].Name; name != expected {
+			t.Fatalf("Block %d: expected %s, got %s", x, expected, name)
+		}
+	}
+}
+
+// TestDecodeVarintBlockDataErrors verifies truncated and short streams are rejected
+func TestDecodeVarintBlockDataErrors(t *testing.T) {
+	if _, err := decodeVarintBlockData([]byte{0x80}, 1); err == nil {
+		t.Errorf("Expected an error for a truncated varint")
+	}
+	if _, err := decodeVarintBlockData([]byte{0x01}, 2); err == nil {
+		t.Errorf("Expected an error for too few entries")
+	}
+	if _, err := decodeVarintBlockData([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 1); err == nil {
+		t.Errorf("Expected an error for an overlong varint")
+	}
+}
+
+// TestWorldEditBlockDataHighBytes verifies BlockData bytes >= 0x80 survive both decode paths intact
+func TestWorldEditBlockDataHighBytes(t *testing.T) {
+	palette := make(map[string]int32, 256)
+	for i := 0; i < 256; i++ {
+		palette[fmt.Sprintf("minecraft:test_block_%d", i)] = int32(i)
+	}
+	// 255 encodes as the varint 0xFF 0x01
+	blockData := []byte{0xff, 0x01, 0x80, 0x01, 0x00}
+
+	encoded := encodeTestNBT(t, &WorldEditNBT{
+		Width:      3,
+		Height:     1,
+		Length:     1,
+		Offset:     []int32{0, 0, 0},
+		Palette:    palette,
+		PaletteMax: 256,
+		Version:    2,
+		BlockData:  blockData,
+	})
+
+	gz, err := gzip.NewReader(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	var typed WorldEditNBT
+	if _, err := nbt.NewDecoder(gz).Decode(&typed); err != nil {
+		t.Fatalf("Failed to decode fixture into WorldEditNBT: %v", err)
+	}
+	if !bytes.Equal(typed.BlockData, blockData) {
+		t.Errorf("Expected BlockData %x, got %x", blockData, typed.BlockData)
+	}
+
+	data, err := DecodeAny(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+	for i, state := range []int{255, 128, 0} {
+		if got := standard.Blocks[i].State; got != state {
+			t.Errorf("Block %d: expected state %d, got %d", i, state, got)
+		}
+	}
+}
+
+// TestWorldEditV3 verifies a Sponge v3 schematic converts to the same blocks as its v2 equivalent
+func TestWorldEditV3(t *testing.T) {
+	data, err := ParseAnyFromFileAsJSON("testdata/color_field.schem")
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	v2Standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert v2 fixture: %v", err)
+	}
+
+	raw, err := json.Marshal(*data.(*interface{}))
+	if err != nil {
+		t.Fatalf("Failed to marshal fixture: %v", err)
+	}
+	var v2 WorldEditNBT
+	if err := json.Unmarshal(raw, &v2); err != nil {
+		t.Fatalf("Failed to unmarshal fixture: %v", err)
+	}
+
+	v3 := WorldEditV3NBT{
+		Blocks:      WorldEditV3Blocks{Palette: v2.Palette, Data: v2.BlockData},
+		DataVersion: v2.DataVersion,
+		Height:      v2.Height,
+		Length:      v2.Length,
+		Metadata:    v2.Metadata,
+		Offset:      v2.Offset,
+		Version:     3,
+		Width:       v2.Width,
+	}
+	for _, be := range v2.BlockEntities {
+		x, y, z := extractBlockEntityPosition(be)
+		nested := make(map[string]any)
+		for k, v := range be {
+			if k != "Pos" && k != "Id" {
+				nested[k] = v
+			}
+		}
+		id, _ := be["Id"].(string)
+		v3.Blocks.BlockEntities = append(v3.Blocks.BlockEntities, WorldEditV3BlockEntity{
+			Pos:  []int32{int32(x), int32(y), int32(z)},
+			Id:   id,
+			Data: nested,
+		})
+	}
+	encoded := encodeTestNBT(t, map[string]interface{}{"Schematic": v3})
+
+	format, err := DetectFormat(encoded)
+	if err != nil {
+		t.Fatalf("Failed to detect format: %v", err)
+	}
+	if format != "worldedit" {
+		t.Errorf("Expected worldedit, got %s", format)
+	}
+
+	decoded, err := DecodeAny(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode v3 fixture: %v", err)
+	}
+	v3Standard, err := ConvertToStandard(decoded)
+	if err != nil {
+		t.Fatalf("Failed to convert v3 fixture: %v", err)
+	}
+
+	if len(v3Standard.Blocks) != len(v2Standard.Blocks) {
+		t.Fatalf("Expected %d blocks, got %d", len(v2Standard.Blocks), len(v3Standard.Blocks))
+	}
+	if !EqualIgnoringOffset(v2Standard, v3Standard) {
+		t.Errorf("Expected v3 blocks to match v2")
+	}
+
+	if got, expected := len(v3Standard.TileEntities), len(v2Standard.TileEntities); got != expected {
+		t.Errorf("Expected %d block entities, got %d", expected, got)
+	}
+}
+
+// TestWorldEditBiomes verifies v2 column biomes and v3 block biomes decode, and v2 biomes round trip
+func TestWorldEditBiomes(t *testing.T) {
+	v2 := &WorldEditNBT{
+		BiomeData:       []byte{0, 1, 1, 0},
+		BiomePalette:    map[string]int32{"minecraft:plains": 0, "minecraft:desert": 1},
+		BiomePaletteMax: 2,
+		BlockData:       make([]byte, 8),
+		Width:           2,
+		Height:          2,
+		Length:          2,
+		Offset:          []int32{0, 0, 0},
+		Palette:         map[string]int32{"minecraft:air": 0},
+		PaletteMax:      1,
+		Version:         2,
+	}
+
+	data, err := DecodeAny(encodeTestNBT(t, v2))
+	if err != nil {
+		t.Fatalf("Failed to decode v2 fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert v2 fixture: %v", err)
+	}
+	if standard.Biomes == nil {
+		t.Fatalf("Expected v2 biomes")
+	}
+	if standard.Biomes.Size != (StandardSize{X: 2, Y: 1, Z: 2}) {
+		t.Errorf("Expected a 2x1x2 biome grid, got %+v", standard.Biomes.Size)
+	}
+	if name := standard.Biomes.Palette[standard.Biomes.Data[1]]; name != "minecraft:desert" {
+		t.Errorf("Expected desert at x=1 z=0, got %s", name)
+	}
+
+	out, err := ConvertFromStandard(standard, "worldedit")
+	if err != nil {
+		t.Fatalf("Failed to convert to worldedit: %v", err)
+	}
+	if got := out.(*WorldEditNBT).BiomeData; !bytes.Equal(got, v2.BiomeData) {
+		t.Errorf("Expected biome data %v after round trip, got %v", v2.BiomeData, got)
+	}
+
+	v3 := WorldEditV3NBT{
+		Biomes: WorldEditV3Biomes{
+			Palette: map[string]int32{"minecraft:plains": 0, "minecraft:river": 1},
+			Data:    []byte{0, 0, 0, 0, 1, 1, 1, 1},
+		},
+		Blocks:  WorldEditV3Blocks{Palette: map[string]int32{"minecraft:air": 0}, Data: make([]byte, 8)},
+		Width:   2,
+		Height:  2,
+		Length:  2,
+		Offset:  []int32{0, 0, 0},
+		Version: 3,
+	}
+	data, err = DecodeAny(encodeTestNBT(t, map[string]interface{}{"Schematic": v3}))
+	if err != nil {
+		t.Fatalf("Failed to decode v3 fixture: %v", err)
+	}
+	standard, err = ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert v3 fixture: %v", err)
+	}
+	if standard.Biomes == nil || len(standard.Biomes.Data) != 8 {
+		t.Fatalf("Expected 8 v3 biome entries, got %+v", standard.Biomes)
+	}
+	if name := standard.Biomes.Palette[standard.Biomes.Data[7]]; name != "minecraft:river" {
+		t.Errorf("Expected river on the top layer, got %s", name)
+	}
+
+	v2.BiomeData, v2.BiomePalette, v2.BiomePaletteMax = nil, nil, 0
+	data, err = DecodeAny(encodeTestNBT(t, v2))
+	if err != nil {
+		t.Fatalf("Failed to decode fixture without biomes: %v", err)
+	}
+	standard, err = ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture without biomes: %v", err)
+	}
+	if standard.Biomes != nil {
+		t.Errorf("Expected no biomes, got %+v", standard.Biomes)
+	}
+}
+
+// TestWorldEditLegacyRouting verifies string-palette schematics and numeric-ID
+// legacy schematics are each routed to their own converter
+func TestWorldEditLegacyRouting(t *testing.T) {
+	for file, legacy := range map[string]bool{
+		"testdata/color_field.schem":       false,
+		"testdata/legacy_blocks.schematic": true,
+	} {
+		payload, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		if format, err := DetectFormat(payload); err != nil || format != "worldedit" {
+			t.Errorf("%s: expected format worldedit, got %q (%v)", file, format, err)
+		}
+		data, err := DecodeAny(payload)
+		if err != nil {
+			t.Fatalf("Failed to decode %s: %v", file, err)
+		}
+		root := (*data.(*interface{})).(map[string]interface{})
+		if got := isWorldEditLegacyMap(root); got != legacy {
+			t.Errorf("%s: expected legacy=%v, got %v", file, legacy, got)
+		}
+		if isWorldEditMap(root) == legacy || isWorldEditV3Map(root) {
+			t.Errorf("%s: routed to the wrong WorldEdit converter", file)
+		}
+		if _, err := ConvertToStandard(data); err != nil {
+			t.Errorf("Failed to convert %s: %v", file, err)
+		}
+	}
+}
+
+// TestWorldEditLegacy verifies numeric IDs and data values map to flattened
+// block states, along with the legacy block entities and entities
+func TestWorldEditLegacy(t *testing.T) {
+	data, err := ParseAnyFromFileAsJSON("testdata/legacy_blocks.schematic")
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert fixture: %v", err)
+	}
+	if standard.Size != (StandardSize{X: 3, Y: 2, Z: 2}) || len(standard.Blocks) != 12 {
+		t.Fatalf("Expected 12 blocks in a 3x2x2 grid, got %d in %+v", len(standard.Blocks), standard.Size)
+	}
+
+	expected := map[[3]int]string{
+		{0, 0, 0}: "minecraft:stone",
+		{1, 0, 0}: "minecraft:granite",
+		{2, 0, 0}: "minecraft:white_wool",
+		{0, 0, 1}: "minecraft:red_wool",
+		{1, 0, 1}: "minecraft:oak_log[axis=x]",
+		{2, 0, 1}: "minecraft:chest",
+		{0, 1, 0}: "minecraft:air",
+		{2, 1, 0}: "minecraft:birch_log[axis=z]",
+		{0, 1, 1}: "minecraft:oak_slab[type=top]",
+		{2, 1, 1}: "minecraft:stone_bricks",
+	}
+	for pos, state := range expected {
+		block, ok := standard.GetBlockAt(pos[0], pos[1], pos[2])
+		if !ok {
+			t.Errorf("Missing block at %v", pos)
+			continue
+		}
+		p := standard.Palette[block.State]
+		if got := EncodePropertyString(p.Name, p.Properties); got != state {
+			t.Errorf("Block at %v: expected %s, got %s", pos, state, got)
+		}
+	}
+
+	if len(standard.TileEntities) != 1 || standard.TileEntities[0].Position != (StandardBlockPosition{X: 2, Z: 1}) {
+		t.Errorf("Expected the chest block entity at 2,0,1, got %+v", standard.TileEntities)
+	}
+	if len(standard.Entities) != 1 {
+		t.Fatalf("Expected 1 entity, got %d", len(standard.Entities))
+	}
+	if e := standard.Entities[0]; e.ID != "Pig" || e.Position != (StandardBlockPosition{X: 0.5, Y: 1, Z: 0.5}) || e.Rotation.Yaw != 90 {
+		t.Errorf("Unexpected entity %+v", e)
+	}
+}
+
+// TestWorldEditStringVersion verifies a Version written as a string is read
+// leniently, so a "v3" schematic still routes to the v3 converter
+func TestWorldEditStringVersion(t *testing.T) {
+	inner := map[string]interface{}{
+		"Version":     "v3",
+		"DataVersion": int32(3465),
+		"Width":       int16(2),
+		"Height":      int16(1),
+		"Length":      int16(1),
+		"Offset":      []int32{0, 0, 0},
+		"Blocks": map[string]interface{}{
+			"Palette": map[string]int32{"minecraft:air": 0, "minecraft:stone": 1},
+			"Data":    []byte{1, 0},
+		},
+	}
+	encoded := encodeTestNBT(t, map[string]interface{}{"Schematic": inner})
+
+	if format, err := DetectFormat(encoded); err != nil || format != "worldedit" {
+		t.Errorf("Expected worldedit, got %q (%v)", format, err)
+	}
+	data, err := DecodeAny(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode fixture: %v", err)
+	}
+	root := (*data.(*interface{})).(map[string]interface{})["Schematic"].(map[string]interface{})
+	if !isWorldEditV3Map(root) {
+		t.Fatalf("Expected a \"v3\" Version to route to the v3 converter")
+	}
+
+	standard, err := ConvertToStandard(data)
+	if err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+	if standard.Version != 3 {
+		t.Errorf("Expected version 3, got %d", standard.Version)
+	}
+	if block, ok := standard.GetBlockAt(0, 0, 0); !ok || standard.Palette[block.State].Name != "minecraft:stone" {
+		t.Errorf("Expected stone at the origin, got %+v", block)
+	}
+
+	for input, expected := range map[interface{}]int{"2": 2, "v3": 3, " V2 ": 2, "3.1": 3, int32(2): 2} {
+		if version, ok := parseVersion(input); !ok || version != expected {
+			t.Errorf("parseVersion(%q): expected %d, got %d (ok=%v)", input, expected, version, ok)
+		}
+	}
+	if _, ok := parseVersion("latest"); ok {
+		t.Errorf("Expected no version from a string without digits")
+	}
+}
+
+// TestWorldEditEntityPassengers verifies Sponge entities are read with their
+// NBT, and nested riders survive a round trip through a .schem file
+func TestWorldEditEntityPassengers(t *testing.T) {
+	fixture := map[string]interface{}{
+		"Version":     int32(2),
+		"DataVersion": int32(3465),
+		"Width":       int16(1),
+		"Height":      int16(1),
+		"Length":      int16(1),
+		"PaletteMax":  int32(1),
+		"Palette":     map[string]interface{}{"minecraft:air": int32(0)},
+		"BlockData":   []byte{0},
+		"Entities": []map[string]interface{}{{
+			"Id":  "minecraft:horse",
+			"Pos": []float64{0.5, 0, 0.5},
+			"Passengers": []map[string]interface{}{{
+				"id": "minecraft:skeleton",
+				"Passengers": []map[string]interface{}{
+					{"id": "minecraft:chicken"},
+				},
+			}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := nbt.NewEncoder(gz).Encode(fixture, "Schematic"); err != nil {
+		t.Fatalf("Failed to encode fixture: %v", err)
+	}
+	gz.Close()
+
+	payload := buf.Bytes()
+	for pass := 0; pass < 2; pass++ {
+		data, err := DecodeAny(payload)
+		if err != nil {
+			t.Fatalf("Pass %d: failed to decode: %v", pass, err)
+		}
+		standard, err := ConvertToStandard(data)
+		if err != nil {
+			t.Fatalf("Pass %d: failed to convert: %v", pass, err)
+		}
+		if len(standard.Entities) != 1 {
+			t.Fatalf("Pass %d: expected 1 entity, got %d", pass, len(standard.Entities))
+		}
+		horse := standard.Entities[0]
+		if horse.ID != "minecraft:horse" || horse.Position != (StandardBlockPosition{X: 0.5, Y: 0, Z: 0.5}) {
+			t.Errorf("Pass %d: unexpected entity %+v", pass, horse)
+		}
+		riders, _ := horse.NBT.(map[string]interface{})["Passengers"].([]interface{})
+		if len(riders) != 1 {
+			t.Fatalf("Pass %d: expected 1 rider, got %v", pass, horse.NBT)
+		}
+		skeleton, _ := riders[0].(map[string]interface{})
+		nested, _ := skeleton["Passengers"].([]interface{})
+		if skeleton["id"] != "minecraft:skeleton" || len(nested) != 1 || nested[0].(map[string]interface{})["id"] != "minecraft:chicken" {
+			t.Errorf("Pass %d: expected a chicken riding a skeleton, got %v", pass, skeleton)
+		}
+
+		payload, err = EncodeToBytes(standard, "worldedit")
+		if err != nil {
+			t.Fatalf("Pass %d: failed to encode: %v", pass, err)
+		}
+	}
+}